@@ -11,87 +11,330 @@
 
 // @tag.name        courses
 // @tag.description Operations on courses
+
+// @tag.name        webhooks
+// @tag.description Webhook subscriptions notified of domain events
+
+// @tag.name        events
+// @tag.description Live stream of domain events
+
+// @tag.name        graphql
+// @tag.description GraphQL API over courses, users and enrollments
+
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                "Bearer <token>", with a token from AUTH_TOKENS
 package main
 
 import (
 	"context"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/guycanella/api-courses-golang/internal/admin"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/graph"
 	"github.com/guycanella/api-courses-golang/internal/handlers"
+	"github.com/guycanella/api-courses-golang/internal/handlersv2"
 	"github.com/guycanella/api-courses-golang/internal/httpx"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/ratelimit"
+	"github.com/guycanella/api-courses-golang/internal/rpc"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"github.com/guycanella/api-courses-golang/internal/tasks"
+	"github.com/guycanella/api-courses-golang/internal/webhooks"
 
-	_ "github.com/guycanella/api-courses-golang/internal/docs"
+	_ "github.com/guycanella/api-courses-golang/internal/docs/v1"
+	_ "github.com/guycanella/api-courses-golang/internal/docs/v2"
 	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
 
 	fiberprometheus "github.com/ansrivas/fiberprometheus/v2"
 	swagger "github.com/gofiber/swagger"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 
 	"github.com/gofiber/contrib/otelfiber"
 	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+	"go.opentelemetry.io/otel/metric/noop"
 )
 
 func main() {
 	debug := os.Getenv("APP_DEBUG") == "true"
 	httpx.SetDebug(debug)
+	obs.InitLogger(debug)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	db, err := mysqlrepo.OpenDatabase(nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	app := fiber.New()
+	// enable tracing, OTel metrics (bridged to /metrics) and the OTel log bridge
+	otelCfg := obs.ConfigFromEnv("api-courses-golang")
+	tp, err := obs.InitTracerWithConfig(context.Background(), otelCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	mp, err := obs.InitMeter(context.Background(), otelCfg, prometheus.DefaultRegisterer)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	lp, err := obs.InitLogs(context.Background(), otelCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if lp != nil {
+		defer func() { _ = lp.Shutdown(context.Background()) }()
+	}
+
+	// instrument GORM (create spans for queries and observe their duration)
+	_ = db.Use(otelgorm.NewPlugin())
+	_ = db.Use(metrics.GormPlugin{})
+
+	refresh, err := time.ParseDuration(os.Getenv("METRICS_REFRESH_INTERVAL"))
+	if err != nil || refresh <= 0 {
+		refresh = 30 * time.Second
+	}
+	metrics.StartCatalogGauges(context.Background(), db, refresh)
+
+	// background jobs, run in-process unless JOBS_WORKERS=0 (see cmd/worker)
+	queue := jobs.New(db)
+	tasks.Register(queue, db)
+	go queue.Run(context.Background(), jobs.WorkerConfigFromEnv(2))
+
+	// relay outbox events to the sinks in EVENTS_SINKS; one relay publishes at a time
+	bus := events.NewBus()
+	sinks, err := events.SinksFromEnv(bus)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hooks := webhooks.New(db, queue, webhooks.ConfigFromEnv())
+	sinks = append(sinks, hooks)
+	go events.NewRelay(db, sinks...).Run(context.Background(), events.RelayConfigFromEnv())
+
+	// feed the event stream with what the relays publish
+	streamCfg := events.StreamConfigFromEnv()
+	hub := events.NewHub(streamCfg.Buffer, streamCfg.MaxSubscribers)
+	go events.NewTail(db, streamCfg.PollInterval).Run(context.Background(), hub.Publish)
+
+	// optional admin listener (pprof, build info, config, pool stats, jobs) and profile snapshots
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := admin.Start(ctx, admin.ConfigFromEnv(), admin.Deps{DB: sqlDB, Jobs: queue}); err != nil {
+		log.Fatal(err)
+	}
+
+	// stream request bodies so large imports are not buffered in memory;
+	// httpx.LimitBody bounds every other body by the usual limit
+	app := fiber.New(fiber.Config{StreamRequestBody: true})
 
-	// enable metrics
-	fp := fiberprometheus.New("api-courses-golang")
+	// enable metrics (default registry, so custom and OTel metrics are exposed too)
+	fp := fiberprometheus.NewWithDefaultRegistry("api-courses-golang")
 	fp.RegisterAt(app, "/metrics")
 	app.Use(fp.Middleware)
 
-	// enable request id and logger
+	// API tokens and their scopes (AUTH_TOKENS); requests without one are anonymous
+	tokens, err := auth.TokensFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// enable request id, tracing, request metrics, the request logger, the body
+	// limit and auth; access_token is moved to the Authorization header before
+	// it can be traced
 	app.Use(requestid.New())
-	app.Use(logger.New(logger.Config{
-		Format: `{
-			"ts":"${time}",
-			"ip":"${ip}",
-			"method":"${method}",
-			"path":"${path}",
-			"status":${status},
-			"latency":"${latency}",
-			"request_id":"${locals:requestid}"
-		}`,
+	app.Use(auth.QueryToken())
+	app.Use(otelfiber.Middleware(otelfiber.WithMeterProvider(noop.NewMeterProvider())))
+	app.Use(obs.RequestMetrics())
+	app.Use(obs.RequestLogger())
+	app.Use(httpx.LimitBody(fiber.DefaultBodyLimit, func(c *fiber.Ctx) bool {
+		// the import handler reads the stream, up to IMPORT_MAX_BYTES
+		return c.Method() == fiber.MethodPost && strings.HasSuffix(c.Path(), "/courses:import")
 	}))
+	app.Use(auth.Authenticate(tokens))
 
-	// enable tracing
-	tp, err := obs.InitTracer(context.Background(), "api-courses-golang")
+	// negotiate the locales of course text and messages from Accept-Language
+	i18n.SetConfig(i18n.ConfigFromEnv())
+	app.Use(i18n.Middleware())
+
+	// enable routes
+	courseCache := cache.FromEnv()
+	importMax, err := strconv.ParseInt(os.Getenv("IMPORT_MAX_BYTES"), 10, 64)
+	if err != nil || importMax <= 0 {
+		importMax = 100 << 20
+	}
+	h := handlers.NewCoursesHandler(db, handlers.WithCache(courseCache), handlers.WithJobs(queue), handlers.WithImportMaxBytes(importMax))
+
+	// gRPC on its own port, over the same service layer and tokens
+	grpcSrv, err := rpc.Start(ctx, rpc.ConfigFromEnv(), rpc.Deps{DB: db, Cache: courseCache, Tokens: tokens})
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer func() { _ = tp.Shutdown(context.Background()) }()
 
-	// instrument GORM (create spans for queries)
-	_ = db.Use(otelgorm.NewPlugin())
-	app.Use(otelfiber.Middleware())
+	// enable rate limiting per route group
+	limits := ratelimit.StoreFromEnv()
+	readLimit := ratelimit.New(ratelimit.Config{
+		Group: "courses_read",
+		Rule:  ratelimit.RuleFromEnv("courses_read", ratelimit.Rule{Limit: 300, Period: time.Minute}),
+		Store: limits,
+	})
+	searchLimit := ratelimit.New(ratelimit.Config{
+		Group: "courses_search",
+		Rule:  ratelimit.RuleFromEnv("courses_search", ratelimit.Rule{Limit: 60, Period: time.Minute}),
+		Store: limits,
+		Next:  func(c *fiber.Ctx) bool { return c.Query("q") == "" },
+	})
+	writeLimit := ratelimit.New(ratelimit.Config{
+		Group: "courses_write",
+		Rule:  ratelimit.RuleFromEnv("courses_write", ratelimit.Rule{Limit: 30, Period: time.Minute}),
+		Store: limits,
+	})
 
-	// enable routes
-	h := handlers.NewCoursesHandler(db)
+	bulkLimit := ratelimit.New(ratelimit.Config{
+		Group: "courses_bulk",
+		Rule:  ratelimit.RuleFromEnv("courses_bulk", ratelimit.Rule{Limit: 10, Period: time.Minute}),
+		Store: limits,
+	})
 
-	app.Get("/courses", h.ListCourses)
-	app.Get("/courses/:courseId", h.GetCourseByID)
-	app.Post("/courses", h.CreateCourse)
+	// webhook subscriptions and their delivery log
+	webhookLimit := ratelimit.New(ratelimit.Config{
+		Group: "webhooks",
+		Rule:  ratelimit.RuleFromEnv("webhooks", ratelimit.Rule{Limit: 60, Period: time.Minute}),
+		Store: limits,
+	})
+	webhookAdmin := auth.RequireAny(auth.ScopeWebhooksAdmin)
+	wh := handlers.NewWebhooksHandler(db, hooks)
 
-	app.Get("/swagger/*", swagger.New(swagger.Config{
-		Title: "Go API Courses",
-	}))
+	// server-sent events of course and enrollment changes
+	streamLimit := ratelimit.New(ratelimit.Config{
+		Group: "events_stream",
+		Rule:  ratelimit.RuleFromEnv("events_stream", ratelimit.Rule{Limit: 30, Period: time.Minute}),
+		Store: limits,
+	})
+	es := handlers.NewEventsStreamHandler(hub, streamCfg.Heartbeat)
+
+	// categories and tags, whose writes invalidate the cached course lists
+	ch := handlers.NewCategoriesHandler(db, courseCache)
+	th := handlers.NewTagsHandler(db, courseCache)
+
+	// REST API versions, side by side under /v1 and /v2; the root still
+	// serves v1 as a deprecated alias until its sunset date
+	v1 := apiversion.Version{Name: "v1", Routes: func(r fiber.Router) {
+		r.Get("/courses", readLimit, searchLimit, h.ListCourses)
+		r.Get("/courses/:courseId", readLimit, h.GetCourseByID)
+		r.Post("/courses", writeLimit, h.CreateCourse)
+
+		// bulk import/export (the colon is escaped so Fiber reads it literally)
+		r.Post("/courses\\:import", bulkLimit, auth.RequireAny(auth.ScopeCoursesAdmin), h.ImportCourses)
+		r.Get("/courses\\:import/jobs/:jobId", readLimit, h.GetImportJob)
+		r.Get("/courses\\:export", bulkLimit, h.ExportCourses)
+
+		// batch create/get/enroll, atomic or best effort
+		r.Post("/courses\\:batchCreate", bulkLimit, h.BatchCreateCourses)
+		r.Post("/courses\\:batchGet", bulkLimit, h.BatchGetCourses)
+		r.Post("/courses/:courseId/enrollments\\:batch", bulkLimit, auth.RequireAny(auth.ScopeEnrollmentsWrite), h.BatchEnroll)
+
+		// categories and tags of courses
+		r.Put("/courses/:courseId/categories", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), h.SetCourseCategories)
+		r.Put("/courses/:courseId/tags", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), h.SetCourseTags)
+
+		// translations of course text, written by admins
+		r.Get("/courses/:courseId/translations", readLimit, h.ListCourseTranslations)
+		r.Put("/courses/:courseId/translations/:locale", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), h.PutCourseTranslation)
+		r.Delete("/courses/:courseId/translations/:locale", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), h.DeleteCourseTranslation)
+		r.Get("/categories", readLimit, ch.ListCategories)
+		r.Get("/categories/:categoryId", readLimit, ch.GetCategory)
+		r.Post("/categories", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), ch.CreateCategory)
+		r.Patch("/categories/:categoryId", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), ch.UpdateCategory)
+		r.Delete("/categories/:categoryId", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), ch.DeleteCategory)
+		r.Get("/tags", readLimit, th.ListTags)
+		r.Get("/tags/:tag", readLimit, th.GetTag)
+		r.Post("/tags", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), th.CreateTag)
+		r.Patch("/tags/:tag", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), th.RenameTag)
+		r.Delete("/tags/:tag", writeLimit, auth.RequireAny(auth.ScopeCoursesAdmin), th.DeleteTag)
+
+		// webhook subscriptions and their delivery log, for admins
+		r.Post("/webhooks", webhookLimit, webhookAdmin, wh.CreateWebhook)
+		r.Get("/webhooks", webhookLimit, webhookAdmin, wh.ListWebhooks)
+		r.Get("/webhooks/:webhookId", webhookLimit, webhookAdmin, wh.GetWebhook)
+		r.Patch("/webhooks/:webhookId", webhookLimit, webhookAdmin, wh.UpdateWebhook)
+		r.Delete("/webhooks/:webhookId", webhookLimit, webhookAdmin, wh.DeleteWebhook)
+		r.Get("/webhooks/:webhookId/deliveries", webhookLimit, webhookAdmin, wh.ListWebhookDeliveries)
+		r.Post("/webhooks/:webhookId/deliveries/:deliveryId/redeliver", webhookLimit, webhookAdmin, wh.RedeliverWebhook)
+
+		// server-sent events of course and enrollment changes
+		r.Get("/events/stream", streamLimit, auth.RequireAny(handlers.StreamScopes...), es.StreamEvents)
+	}}
+
+	h2 := handlersv2.NewCoursesHandler(service.NewCourseService(mysqlrepo.NewStore(db), service.WithCache(courseCache)))
+	v2 := apiversion.Version{Name: "v2", Routes: func(r fiber.Router) {
+		r.Get("/courses", readLimit, searchLimit, h2.ListCourses)
+		r.Get("/courses/:courseId", readLimit, h2.GetCourseByID)
+		r.Post("/courses", writeLimit, h2.CreateCourse)
+	}}
+
+	apiversion.Mount(app, v1, v2)
+	apiversion.Alias(app, v1, apiversion.DeprecationFromEnv())
+
+	// GraphQL over the same data, with the same auth as REST
+	graphqlLimit := ratelimit.New(ratelimit.Config{
+		Group: "graphql",
+		Rule:  ratelimit.RuleFromEnv("graphql", ratelimit.Rule{Limit: 120, Period: time.Minute}),
+		Store: limits,
+	})
+	gql := graph.New(db, graph.ConfigFromEnv(), graph.WithCache(courseCache))
+	app.Post("/graphql", graphqlLimit, gql.Handler())
+	app.Get("/graphql", graphqlLimit, gql.Handler())
+
+	// one Swagger UI per version
+	app.Get("/swagger/v1/*", swagger.New(swagger.Config{Title: "Go API Courses v1", InstanceName: "v1"}))
+	app.Get("/swagger/v2/*", swagger.New(swagger.Config{Title: "Go API Courses v2", InstanceName: "v2"}))
+	app.Get("/swagger/*", func(c *fiber.Ctx) error {
+		return c.Redirect("/swagger/v1/index.html", fiber.StatusMovedPermanently)
+	})
 
 	port := os.Getenv("APP_PORT")
 	if port == "" {
 		port = "3333"
 	}
 
-	log.Fatal(app.Listen(":" + port))
+	// on SIGINT/SIGTERM stop taking requests, drain REST, then drain gRPC
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		if grpcSrv != nil {
+			defer grpcSrv.GracefulStop()
+		}
+		if err := app.ShutdownWithTimeout(30 * time.Second); err != nil {
+			log.Printf("http shutdown: %v", err)
+		}
+	}()
+
+	if err := app.Listen(":" + port); err != nil {
+		log.Fatal(err)
+	}
+	<-drained
 }
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 
 	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
 	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
 )
 
@@ -29,12 +32,32 @@ func main() {
 
 	if err := db.AutoMigrate(
 		&domain.User{},
+		&domain.Category{},
+		&domain.Tag{},
 		&domain.Course{},
+		&domain.CourseSlug{},
+		&domain.CourseTranslation{},
 		&domain.Enrollment{},
+		&domain.CourseModule{},
+		&domain.CourseImportJob{},
+		&domain.CourseImportChunk{},
+		&jobs.Job{},
+		&events.OutboxEvent{},
+		&domain.WebhookSubscription{},
+		&domain.WebhookDelivery{},
 	); err != nil {
 		log.Fatal(err)
 	}
 
+	// courses created before slugs existed get the slug of their title
+	slugged, err := mysqlrepo.NewStore(db).BackfillCourseSlugs(context.Background())
+	if err != nil {
+		log.Fatal("backfilling course slugs: ", err)
+	}
+	if slugged > 0 {
+		log.Printf("Backfilled %d course slugs.\n", slugged)
+	}
+
 	if isTest {
 		log.Println("✅ AutoMigrate test concluded.")
 		return
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"strings"
@@ -35,15 +36,19 @@ func main() {
 	gofakeit.Seed(time.Now().UnixNano())
 
 	users := seedUsers(db)
-	courses := seedCourses(db)
+	courses := seedCourses(db, users)
+	modules := seedModules(db, courses)
 	enrolls := seedEnrollments(db, users, courses)
+	categories := seedCategories(db)
+	tags := seedTags(db)
+	seedCourseTaxonomy(db, courses, categories, tags)
 
 	if env == "test" {
-		log.Printf("✅ Test seed ok: %d users, %d courses, %d enrollments\n", len(users), len(courses), len(enrolls))
+		log.Printf("✅ Test seed ok: %d users, %d courses, %d modules, %d enrollments, %d categories, %d tags\n", len(users), len(courses), len(modules), len(enrolls), len(categories), len(tags))
 		return
 	}
 
-	log.Printf("✅ seed ok: %d users, %d courses, %d enrollments\n", len(users), len(courses), len(enrolls))
+	log.Printf("✅ seed ok: %d users, %d courses, %d modules, %d enrollments, %d categories, %d tags\n", len(users), len(courses), len(modules), len(enrolls), len(categories), len(tags))
 }
 
 func seedUsers(db *gorm.DB) []domain.User {
@@ -70,25 +75,49 @@ func seedUsers(db *gorm.DB) []domain.User {
 	return users
 }
 
-func seedCourses(db *gorm.DB) []domain.Course {
+func seedCourses(db *gorm.DB, users []domain.User) []domain.Course {
 	var courses []domain.Course
 
 	for i := 0; i < 2; i++ {
 		title := gofakeit.Sentence(4)
 		desc := gofakeit.Paragraph(1, 3, 20, " ")
 		courses = append(courses, domain.Course{
-			Title:       title,
-			Description: desc,
+			Title:        title,
+			Description:  desc,
+			InstructorID: &users[i].ID,
 		})
 	}
 
 	if err := db.Create(&courses).Error; err != nil {
 		log.Fatal("creating courses: ", err)
 	}
+	if _, err := mysqlrepo.NewStore(db).BackfillCourseSlugs(context.Background()); err != nil {
+		log.Fatal("creating course slugs: ", err)
+	}
 
 	return courses
 }
 
+func seedModules(db *gorm.DB, courses []domain.Course) []domain.CourseModule {
+	var modules []domain.CourseModule
+
+	for _, course := range courses {
+		for position := 1; position <= 3; position++ {
+			modules = append(modules, domain.CourseModule{
+				CourseID: course.ID,
+				Title:    gofakeit.Sentence(3),
+				Position: position,
+			})
+		}
+	}
+
+	if err := db.Create(&modules).Error; err != nil {
+		log.Fatal("creating modules: ", err)
+	}
+
+	return modules
+}
+
 func seedEnrollments(db *gorm.DB, users []domain.User, courses []domain.Course) []domain.Enrollment {
 	enrolls := []domain.Enrollment{
 		{UserID: users[0].ID, CourseID: courses[0].ID},
@@ -104,3 +133,70 @@ func seedEnrollments(db *gorm.DB, users []domain.User, courses []domain.Course)
 
 	return enrolls
 }
+
+// seedCategories creates Programming, with Backend and Frontend below it, and
+// Design.
+func seedCategories(db *gorm.DB) map[string]domain.Category {
+	top := []domain.Category{
+		{Name: "Programming", Slug: "programming"},
+		{Name: "Design", Slug: "design"},
+	}
+	if err := db.Create(&top).Error; err != nil {
+		log.Fatal("creating categories: ", err)
+	}
+
+	children := []domain.Category{
+		{Name: "Backend", Slug: "backend", ParentID: &top[0].ID},
+		{Name: "Frontend", Slug: "frontend", ParentID: &top[0].ID},
+	}
+	if err := db.Create(&children).Error; err != nil {
+		log.Fatal("creating categories: ", err)
+	}
+
+	categories := map[string]domain.Category{}
+	for _, category := range append(top, children...) {
+		categories[category.Slug] = category
+	}
+	return categories
+}
+
+func seedTags(db *gorm.DB) map[string]domain.Tag {
+	tags := []domain.Tag{{Name: "golang"}, {Name: "web-dev"}, {Name: "beginner"}}
+	if err := db.Create(&tags).Error; err != nil {
+		log.Fatal("creating tags: ", err)
+	}
+
+	byName := map[string]domain.Tag{}
+	for _, tag := range tags {
+		byName[tag.Name] = tag
+	}
+	return byName
+}
+
+func seedCourseTaxonomy(db *gorm.DB, courses []domain.Course, categories map[string]domain.Category, tags map[string]domain.Tag) {
+	links := []struct {
+		categories []string
+		tags       []string
+	}{
+		{categories: []string{"backend"}, tags: []string{"golang", "beginner"}},
+		{categories: []string{"frontend", "design"}, tags: []string{"web-dev"}},
+	}
+
+	for i, link := range links {
+		var courseCategories []domain.Category
+		for _, slug := range link.categories {
+			courseCategories = append(courseCategories, categories[slug])
+		}
+		var courseTags []domain.Tag
+		for _, name := range link.tags {
+			courseTags = append(courseTags, tags[name])
+		}
+
+		if err := db.Model(&courses[i]).Association("Categories").Append(courseCategories); err != nil {
+			log.Fatal("linking categories: ", err)
+		}
+		if err := db.Model(&courses[i]).Association("Tags").Append(courseTags); err != nil {
+			log.Fatal("linking tags: ", err)
+		}
+	}
+}
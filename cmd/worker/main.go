@@ -0,0 +1,56 @@
+// Command worker runs background jobs outside the API process. Set
+// JOBS_WORKERS=0 on the API to leave all jobs to it.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/guycanella/api-courses-golang/internal/admin"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/tasks"
+
+	"github.com/uptrace/opentelemetry-go-extra/otelgorm"
+)
+
+func main() {
+	obs.InitLogger(os.Getenv("APP_DEBUG") == "true")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := mysqlrepo.OpenDatabase(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	tp, err := obs.InitTracer(context.Background(), "api-courses-golang-worker")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	_ = db.Use(otelgorm.NewPlugin())
+	_ = db.Use(metrics.GormPlugin{})
+
+	q := jobs.New(db)
+	tasks.Register(q, db)
+
+	// optional admin listener: job admin routes, pprof and pool stats
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := admin.Start(ctx, admin.ConfigFromEnv(), admin.Deps{DB: sqlDB, Jobs: q}); err != nil {
+		log.Fatal(err)
+	}
+
+	// blocks until SIGINT/SIGTERM, then waits for the jobs in flight
+	q.Run(ctx, jobs.WorkerConfigFromEnv(4))
+}
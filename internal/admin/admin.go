@@ -0,0 +1,261 @@
+// Package admin serves pprof, build info, the effective config and database
+// pool stats on a separate listener guarded by a bearer token.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+)
+
+type Config struct {
+	// Addr is the admin listen address; empty disables the listener.
+	Addr  string
+	Token string
+
+	// MutexProfileFraction and BlockProfileRate feed the mutex and block
+	// profiles, which are off by default in the runtime.
+	MutexProfileFraction int
+	BlockProfileRate     int
+
+	Profiles ProfilerConfig
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		Addr:                 os.Getenv("ADMIN_ADDR"),
+		Token:                os.Getenv("ADMIN_TOKEN"),
+		MutexProfileFraction: getint("ADMIN_MUTEX_PROFILE_FRACTION", 5),
+		BlockProfileRate:     getint("ADMIN_BLOCK_PROFILE_RATE", 10000),
+		Profiles: ProfilerConfig{
+			Dir:         getenv("PROFILE_DIR", "profiles"),
+			Interval:    getdur("PROFILE_INTERVAL", 0),
+			CPUDuration: getdur("PROFILE_CPU_DURATION", 10*time.Second),
+			Keep:        getint("PROFILE_KEEP", 24),
+		},
+	}
+}
+
+var startedAt = time.Now()
+
+// Deps are what the admin routes report on. Nil fields disable their routes.
+type Deps struct {
+	DB   *sql.DB
+	Jobs *jobs.Queue
+}
+
+// Handler returns the admin routes.
+func Handler(cfg Config, deps Deps) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("/debug/buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, buildInfo())
+	})
+	mux.HandleFunc("/debug/config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, EffectiveConfig())
+	})
+	mux.HandleFunc("/debug/db", func(w http.ResponseWriter, r *http.Request) {
+		if deps.DB == nil {
+			http.Error(w, "no database", http.StatusNotFound)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, deps.DB.Stats())
+	})
+	mux.Handle("/debug/profiles/", http.StripPrefix("/debug/profiles/", http.FileServer(http.Dir(cfg.Profiles.Dir))))
+
+	if deps.Jobs != nil {
+		mux.HandleFunc("GET /admin/jobs", listJobs(deps.Jobs))
+		mux.HandleFunc("GET /admin/jobs/{id}", jobAction(deps.Jobs.Get))
+		mux.HandleFunc("POST /admin/jobs/{id}/retry", jobAction(deps.Jobs.Retry))
+		mux.HandleFunc("POST /admin/jobs/{id}/cancel", jobAction(deps.Jobs.Cancel))
+	}
+
+	return requireToken(cfg.Token, mux)
+}
+
+// Start serves Handler on cfg.Addr and starts the profile snapshots, each only
+// when configured. The mutex and block profiles are enabled for either. The
+// listener refuses to start without a token.
+func Start(ctx context.Context, cfg Config, deps Deps) error {
+	if cfg.Addr == "" && cfg.Profiles.Interval <= 0 {
+		return nil
+	}
+
+	runtime.SetMutexProfileFraction(cfg.MutexProfileFraction)
+	runtime.SetBlockProfileRate(cfg.BlockProfileRate)
+	StartProfiler(ctx, cfg.Profiles)
+
+	if cfg.Addr == "" {
+		return nil
+	}
+	if cfg.Token == "" {
+		return errMissingToken
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           Handler(cfg, deps),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.ErrorContext(ctx, "admin listener stopped", "addr", cfg.Addr, "error", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	slog.InfoContext(ctx, "admin listener started", "addr", cfg.Addr)
+	return nil
+}
+
+var errMissingToken = errors.New("admin: ADMIN_TOKEN is required when ADMIN_ADDR is set")
+
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type BuildInfo struct {
+	GoVersion  string            `json:"go_version"`
+	Path       string            `json:"path"`
+	Version    string            `json:"version"`
+	AppVersion string            `json:"app_version"`
+	Settings   map[string]string `json:"settings"`
+	StartedAt  time.Time         `json:"started_at"`
+	Uptime     string            `json:"uptime"`
+	NumCPU     int               `json:"num_cpu"`
+	GOMAXPROCS int               `json:"gomaxprocs"`
+	Goroutines int               `json:"goroutines"`
+}
+
+func buildInfo() BuildInfo {
+	info := BuildInfo{
+		GoVersion:  runtime.Version(),
+		AppVersion: getenv("APP_VERSION", "dev"),
+		Settings:   map[string]string{},
+		StartedAt:  startedAt,
+		Uptime:     time.Since(startedAt).Round(time.Second).String(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Goroutines: runtime.NumGoroutine(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.Path = bi.Main.Path
+		info.Version = bi.Main.Version
+		for _, s := range bi.Settings {
+			if strings.HasPrefix(s.Key, "vcs.") || s.Key == "GOOS" || s.Key == "GOARCH" || s.Key == "CGO_ENABLED" {
+				info.Settings[s.Key] = s.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// configPrefixes are the environment variables the API reads.
+var configPrefixes = []string{
+	"ADMIN_", "API_", "APP_", "AUTH_", "CACHE_", "DB_", "EVENTS_", "GRAPHQL_", "GRPC_", "JOBS_", "LOG_", "METRICS_",
+	"OTEL_", "PROFILE_", "RATE_LIMIT_", "WEBHOOKS_",
+}
+
+var (
+	secretKey   = regexp.MustCompile(`PASSWORD|SECRET|TOKEN|API_KEY|HEADERS|CERTIFICATE_KEY`)
+	credentials = regexp.MustCompile(`([^:/@,\s]*):([^@,\s/]+)@`)
+)
+
+// EffectiveConfig returns the configuration environment with secrets redacted
+// and passwords masked inside DSNs and URLs.
+func EffectiveConfig() map[string]string {
+	out := map[string]string{}
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if !hasConfigPrefix(k) {
+			continue
+		}
+
+		switch {
+		case secretKey.MatchString(k):
+			if v != "" {
+				v = "[REDACTED]"
+			}
+		default:
+			v = credentials.ReplaceAllString(v, "$1:***@")
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+func hasConfigPrefix(k string) bool {
+	for _, p := range configPrefixes {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
+
+func getint(k string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(k)); err == nil {
+		return n
+	}
+	return def
+}
+
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+)
+
+// listJobs serves GET /admin/jobs?status=&kind=&limit=.
+func listJobs(q *jobs.Queue) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		list, err := q.List(r.Context(), jobs.Filter{
+			Status: r.URL.Query().Get("status"),
+			Kind:   r.URL.Query().Get("kind"),
+			Limit:  limit,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{"data": list})
+	}
+}
+
+// jobAction serves a route that reads or changes the job named by {id}.
+func jobAction(action func(ctx context.Context, id string) (*jobs.Job, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := action(r.Context(), r.PathValue("id"))
+		switch {
+		case errors.Is(err, jobs.ErrNotFound):
+			http.Error(w, "job not found", http.StatusNotFound)
+		case errors.Is(err, jobs.ErrInvalidState):
+			writeJSON(w, http.StatusConflict, map[string]any{"error": "job is " + job.Status, "job": job})
+		case errors.Is(err, jobs.ErrDuplicate):
+			http.Error(w, "a live job with the same unique key exists", http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		default:
+			writeJSON(w, http.StatusOK, job)
+		}
+	}
+}
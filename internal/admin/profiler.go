@@ -0,0 +1,132 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"sort"
+	"time"
+)
+
+type ProfilerConfig struct {
+	Dir string
+	// Interval between snapshots; zero disables them.
+	Interval    time.Duration
+	CPUDuration time.Duration
+	// Keep is how many snapshots of each kind are kept on disk.
+	Keep int
+}
+
+// snapshotKinds are the runtime profiles written on every snapshot, besides CPU.
+var snapshotKinds = []string{"heap", "goroutine", "mutex", "block", "allocs"}
+
+// StartProfiler writes a CPU profile and the snapshotKinds profiles to cfg.Dir
+// every cfg.Interval until ctx is done. Files are named
+// <kind>-<UTC timestamp>.pprof so `go tool pprof -diff_base` can compare them.
+func StartProfiler(ctx context.Context, cfg ProfilerConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := Snapshot(ctx, cfg); err != nil {
+					slog.WarnContext(ctx, "profile snapshot failed", "dir", cfg.Dir, "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Snapshot writes one set of profiles and prunes old ones.
+func Snapshot(ctx context.Context, cfg ProfilerConfig) error {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return err
+	}
+
+	stamp := time.Now().UTC().Format("20060102T150405.000Z")
+
+	if cfg.CPUDuration > 0 {
+		if err := writeCPUProfile(ctx, filepath.Join(cfg.Dir, "cpu-"+stamp+".pprof"), cfg.CPUDuration); err != nil {
+			return err
+		}
+	}
+
+	for _, kind := range snapshotKinds {
+		if err := writeProfile(filepath.Join(cfg.Dir, kind+"-"+stamp+".pprof"), kind); err != nil {
+			return err
+		}
+	}
+
+	return prune(cfg.Dir, append([]string{"cpu"}, snapshotKinds...), cfg.Keep)
+}
+
+func writeCPUProfile(ctx context.Context, path string, d time.Duration) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Fails when a CPU profile is already running, e.g. from /debug/pprof/profile.
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+	pprof.StopCPUProfile()
+
+	return nil
+}
+
+func writeProfile(path, kind string) error {
+	p := pprof.Lookup(kind)
+	if p == nil {
+		return fmt.Errorf("unknown profile %q", kind)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.WriteTo(f, 0)
+}
+
+// prune keeps the newest keep files of each kind. Timestamps sort lexically.
+func prune(dir string, kinds []string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	for _, kind := range kinds {
+		files, err := filepath.Glob(filepath.Join(dir, kind+"-*.pprof"))
+		if err != nil {
+			return err
+		}
+		sort.Strings(files)
+
+		for _, f := range files[:max(0, len(files)-keep)] {
+			if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+// Package apiversion mounts the REST API versions side by side. Each version
+// registers its routes under /<name>; one version can also be served at the
+// root as a deprecated alias, whose responses carry the Deprecation (RFC 9745)
+// and Sunset (RFC 8594) headers and a Link to the versioned route.
+package apiversion
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Version is one major version of the REST API.
+type Version struct {
+	// Name is the path segment, such as "v1".
+	Name string
+	// Routes registers the routes of the version relative to r.
+	Routes func(r fiber.Router)
+}
+
+const prefixKey = "apiversion:prefix"
+
+// Mount registers each version under /<name>.
+func Mount(app fiber.Router, versions ...Version) {
+	for _, v := range versions {
+		prefix := "/" + v.Name
+		v.Routes(app.Group(prefix, func(c *fiber.Ctx) error {
+			c.Locals(prefixKey, prefix)
+			return c.Next()
+		}))
+	}
+}
+
+// Deprecation describes the retirement of the root alias.
+type Deprecation struct {
+	// At is when the alias was deprecated.
+	At time.Time
+	// Sunset is when the alias stops being served.
+	Sunset time.Time
+}
+
+// DeprecationFromEnv reads API_ROOT_DEPRECATED_AT (default 2026-10-19) and
+// API_ROOT_SUNSET_AT (default 2027-04-19), as RFC 3339 dates or timestamps.
+func DeprecationFromEnv() Deprecation {
+	return Deprecation{
+		At:     gettime("API_ROOT_DEPRECATED_AT", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)),
+		Sunset: gettime("API_ROOT_SUNSET_AT", time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)),
+	}
+}
+
+// Alias also registers the routes of v at the root, with the deprecation
+// headers added to each response. Only the routes of v are affected, unlike
+// middleware on the root group.
+func Alias(app fiber.Router, v Version, d Deprecation) {
+	v.Routes(&deprecated{Router: app, mark: deprecate(v.Name, d)})
+}
+
+func deprecate(name string, d Deprecation) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(d.At.Unix(), 10)
+	sunset := d.Sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", deprecation)
+		c.Set("Sunset", sunset)
+		c.Append(fiber.HeaderLink, `</`+name+c.Path()+`>; rel="successor-version"`)
+		return c.Next()
+	}
+}
+
+// URL returns path under the version the request was routed through, so
+// Location headers point back at the same version.
+func URL(c *fiber.Ctx, path string) string {
+	prefix, _ := c.Locals(prefixKey).(string)
+	return prefix + path
+}
+
+// deprecated prepends mark to the handlers of every route registered on it
+// or its groups. Middleware added with Use is not marked.
+type deprecated struct {
+	fiber.Router
+	mark fiber.Handler
+}
+
+func (r *deprecated) with(handlers []fiber.Handler) []fiber.Handler {
+	return append([]fiber.Handler{r.mark}, handlers...)
+}
+
+func (r *deprecated) Get(path string, handlers ...fiber.Handler) fiber.Router {
+	return r.Router.Get(path, r.with(handlers)...)
+}
+
+func (r *deprecated) Post(path string, handlers ...fiber.Handler) fiber.Router {
+	return r.Router.Post(path, r.with(handlers)...)
+}
+
+func (r *deprecated) Put(path string, handlers ...fiber.Handler) fiber.Router {
+	return r.Router.Put(path, r.with(handlers)...)
+}
+
+func (r *deprecated) Patch(path string, handlers ...fiber.Handler) fiber.Router {
+	return r.Router.Patch(path, r.with(handlers)...)
+}
+
+func (r *deprecated) Delete(path string, handlers ...fiber.Handler) fiber.Router {
+	return r.Router.Delete(path, r.with(handlers)...)
+}
+
+func (r *deprecated) Add(method, path string, handlers ...fiber.Handler) fiber.Router {
+	return r.Router.Add(method, path, r.with(handlers)...)
+}
+
+func (r *deprecated) Group(prefix string, handlers ...fiber.Handler) fiber.Router {
+	return &deprecated{Router: r.Router.Group(prefix, handlers...), mark: r.mark}
+}
+
+func gettime(k string, def time.Time) time.Time {
+	v := os.Getenv(k)
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t
+	}
+	return def
+}
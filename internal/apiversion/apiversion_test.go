@@ -0,0 +1,107 @@
+package apiversion_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func testApp() *fiber.App {
+	app := fiber.New()
+
+	v1 := apiversion.Version{Name: "v1", Routes: func(r fiber.Router) {
+		r.Get("/courses", func(c *fiber.Ctx) error { return c.SendString("v1") })
+		r.Post("/courses", func(c *fiber.Ctx) error {
+			c.Location(apiversion.URL(c, "/courses/42"))
+			return c.SendStatus(fiber.StatusCreated)
+		})
+		r.Group("/webhooks").Get("/:id", func(c *fiber.Ctx) error { return c.SendString(c.Params("id")) })
+	}}
+	v2 := apiversion.Version{Name: "v2", Routes: func(r fiber.Router) {
+		r.Get("/courses", func(c *fiber.Ctx) error { return c.SendString("v2") })
+	}}
+
+	apiversion.Mount(app, v1, v2)
+	apiversion.Alias(app, v1, apiversion.Deprecation{
+		At:     time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC),
+	})
+	app.Get("/health", func(c *fiber.Ctx) error { return c.SendString("ok") })
+
+	return app
+}
+
+func TestMount_VersionsSideBySide(t *testing.T) {
+	app := testApp()
+
+	for path, want := range map[string]string{"/v1/courses": "v1", "/v2/courses": "v2", "/courses": "v1"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || string(body) != want {
+			t.Fatalf("GET %s: expected 200 %q, got %d %q", path, want, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestAlias_DeprecationHeaders(t *testing.T) {
+	app := testApp()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/webhooks/7", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if got := resp.Header.Get("Deprecation"); got != "@1792368000" {
+		t.Fatalf("Unexpected Deprecation: %q", got)
+	}
+	if got := resp.Header.Get("Sunset"); got != "Mon, 19 Apr 2027 00:00:00 GMT" {
+		t.Fatalf("Unexpected Sunset: %q", got)
+	}
+	if got := resp.Header.Get("Link"); got != `</v1/webhooks/7>; rel="successor-version"` {
+		t.Fatalf("Unexpected Link: %q", got)
+	}
+
+	for _, path := range []string{"/v1/courses", "/v2/courses", "/health"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		if resp.Header.Get("Deprecation") != "" || resp.Header.Get("Sunset") != "" {
+			t.Fatalf("GET %s: expected no deprecation headers", path)
+		}
+	}
+}
+
+func TestURL_KeepsVersion(t *testing.T) {
+	app := testApp()
+
+	for path, want := range map[string]string{"/v1/courses": "/v1/courses/42", "/courses": "/courses/42"} {
+		resp, err := app.Test(httptest.NewRequest("POST", path, nil))
+		if err != nil {
+			t.Fatalf("app.Test: %v", err)
+		}
+		if got := resp.Header.Get("Location"); got != want {
+			t.Fatalf("POST %s: expected Location %q, got %q", path, want, got)
+		}
+	}
+}
+
+func TestDeprecationFromEnv(t *testing.T) {
+	t.Setenv("API_ROOT_DEPRECATED_AT", "2026-11-01")
+	t.Setenv("API_ROOT_SUNSET_AT", "2027-05-01T12:00:00Z")
+
+	d := apiversion.DeprecationFromEnv()
+	if !d.At.Equal(time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected At: %v", d.At)
+	}
+	if !d.Sunset.Equal(time.Date(2027, 5, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected Sunset: %v", d.Sunset)
+	}
+}
@@ -0,0 +1,217 @@
+// Package auth authenticates API clients with static bearer tokens and checks
+// their scopes.
+//
+// Tokens are configured in AUTH_TOKENS as ";"-separated entries of
+// name=token=scope,scope, for example
+//
+//	AUTH_TOKENS="dashboard=9f2c...=courses:read,enrollments:read"
+//
+// Only a hash of each token is kept in memory.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Scopes.
+const (
+	ScopeCoursesRead      = "courses:read"
+	ScopeCoursesAdmin     = "courses:admin"
+	ScopeUsersRead        = "users:read"
+	ScopeUsersWrite       = "users:write"
+	ScopeEnrollmentsRead  = "enrollments:read"
+	ScopeEnrollmentsWrite = "enrollments:write"
+	ScopeWebhooksAdmin    = "webhooks:admin"
+)
+
+const principalKey = "auth:principal"
+
+// Principal is an authenticated client.
+type Principal struct {
+	Name   string
+	Scopes []string
+}
+
+func (p Principal) Has(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type entry struct {
+	hash      [32]byte
+	principal Principal
+}
+
+// Tokens is the set of accepted tokens. A nil or empty *Tokens accepts none.
+type Tokens struct {
+	entries []entry
+}
+
+// ParseTokens reads the AUTH_TOKENS format.
+func ParseTokens(spec string) (*Tokens, error) {
+	tokens := &Tokens{}
+	for _, raw := range strings.Split(spec, ";") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+
+		parts := strings.SplitN(raw, "=", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("auth: malformed token entry %q, want name=token=scopes", parts[0])
+		}
+
+		var scopes []string
+		for _, s := range strings.Split(parts[2], ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				scopes = append(scopes, s)
+			}
+		}
+		tokens.entries = append(tokens.entries, entry{
+			hash:      sha256.Sum256([]byte(parts[1])),
+			principal: Principal{Name: parts[0], Scopes: scopes},
+		})
+	}
+
+	return tokens, nil
+}
+
+// TokensFromEnv parses AUTH_TOKENS.
+func TokensFromEnv() (*Tokens, error) {
+	return ParseTokens(os.Getenv("AUTH_TOKENS"))
+}
+
+// Lookup returns the principal of token.
+func (t *Tokens) Lookup(token string) (Principal, bool) {
+	if t == nil || token == "" {
+		return Principal{}, false
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	var found *Principal
+	// Compare against every entry so timing does not reveal which matched.
+	for i := range t.entries {
+		if subtle.ConstantTimeCompare(hash[:], t.entries[i].hash[:]) == 1 {
+			found = &t.entries[i].principal
+		}
+	}
+	if found == nil {
+		return Principal{}, false
+	}
+
+	return *found, true
+}
+
+// Authenticate resolves the bearer token of the request, if any, and stores
+// the principal in the locals and the user context; its name becomes the
+// userId local. Requests without a token
+// continue anonymously, an unknown token is rejected with 401.
+func Authenticate(tokens *Tokens) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderAuthorization)
+		if header == "" {
+			return c.Next()
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return invalidToken(c)
+		}
+		return authenticate(c, tokens, token)
+	}
+}
+
+// QueryToken moves the access_token query parameter into the Authorization
+// header, for clients such as EventSource that cannot set headers. The
+// parameter is removed from the URL so it is neither traced nor logged;
+// register it before the tracing middleware.
+func QueryToken() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		args := c.Request().URI().QueryArgs()
+		token := string(args.Peek("access_token"))
+		if token == "" {
+			return c.Next()
+		}
+
+		args.Del("access_token")
+		c.Request().SetRequestURIBytes(c.Request().URI().RequestURI())
+		if c.Get(fiber.HeaderAuthorization) == "" {
+			c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+
+		return c.Next()
+	}
+}
+
+func authenticate(c *fiber.Ctx, tokens *Tokens, token string) error {
+	principal, ok := tokens.Lookup(token)
+	if !ok {
+		return invalidToken(c)
+	}
+
+	c.Locals(principalKey, principal)
+	c.Locals("userId", principal.Name)
+	c.SetUserContext(NewContext(c.UserContext(), principal))
+	return c.Next()
+}
+
+func invalidToken(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": "invalid token",
+	})
+}
+
+// FromCtx returns the authenticated principal.
+func FromCtx(c *fiber.Ctx) (Principal, bool) {
+	p, ok := c.Locals(principalKey).(Principal)
+	return p, ok
+}
+
+type principalCtxKey struct{}
+
+// NewContext returns ctx carrying p, for code that only sees a
+// context.Context, such as GraphQL resolvers.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, p)
+}
+
+// FromContext returns the principal stored by NewContext.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalCtxKey{}).(Principal)
+	return p, ok
+}
+
+// RequireAny rejects anonymous requests with 401 and principals holding none
+// of scopes with 403.
+func RequireAny(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		p, ok := FromCtx(c)
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "authentication required",
+			})
+		}
+
+		for _, s := range scopes {
+			if p.Has(s) {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "missing scope",
+		})
+	}
+}
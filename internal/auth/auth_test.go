@@ -0,0 +1,128 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+)
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := auth.ParseTokens("dashboard=tok1=courses:read, enrollments:read; ops=tok2=")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	p, ok := tokens.Lookup("tok1")
+	if !ok || p.Name != "dashboard" || !p.Has(auth.ScopeCoursesRead) || !p.Has(auth.ScopeEnrollmentsRead) {
+		t.Fatalf("tok1 = %+v, %v", p, ok)
+	}
+	if p, ok := tokens.Lookup("tok2"); !ok || p.Name != "ops" || len(p.Scopes) != 0 {
+		t.Fatalf("tok2 = %+v, %v", p, ok)
+	}
+	if _, ok := tokens.Lookup("nope"); ok {
+		t.Fatal("unknown token accepted")
+	}
+
+	if _, err := auth.ParseTokens("dashboard:tok1"); err == nil {
+		t.Fatal("expected an error for a malformed entry")
+	}
+}
+
+func newApp(t *testing.T) *fiber.App {
+	t.Helper()
+
+	tokens, err := auth.ParseTokens("dashboard=tok1=courses:read;ops=tok2=metrics:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.QueryToken())
+	app.Use(auth.Authenticate(tokens))
+	app.Get("/open", func(c *fiber.Ctx) error {
+		user, _ := c.Locals("userId").(string)
+		return c.SendString(user + "|" + c.OriginalURL())
+	})
+	app.Get("/courses", auth.RequireAny(auth.ScopeCoursesRead), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	return app
+}
+
+func TestAuthenticateAndRequire(t *testing.T) {
+	app := newApp(t)
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"anonymous open route", "/open", "", http.StatusOK},
+		{"invalid token", "/open", "Bearer nope", http.StatusUnauthorized},
+		{"not bearer", "/open", "Basic Zm9vOmJhcg==", http.StatusUnauthorized},
+		{"anonymous protected", "/courses", "", http.StatusUnauthorized},
+		{"missing scope", "/courses", "Bearer tok2", http.StatusForbidden},
+		{"granted", "/courses", "Bearer tok1", http.StatusOK},
+		{"query token", "/courses?access_token=tok1", "", http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status=%d want=%d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
+
+func TestQueryToken_StripsTheParameter(t *testing.T) {
+	resp, err := newApp(t).Test(httptest.NewRequest("GET", "/open?access_token=tok1&x=1", nil))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	body := make([]byte, 128)
+	n, _ := resp.Body.Read(body)
+	if got := string(body[:n]); got != "dashboard|/open?x=1" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestAuthenticate_StoresPrincipalInUserContext(t *testing.T) {
+	tokens, err := auth.ParseTokens("dashboard=tok1=courses:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.Authenticate(tokens))
+	app.Get("/", func(c *fiber.Ctx) error {
+		p, ok := auth.FromContext(c.UserContext())
+		if !ok || !p.Has(auth.ScopeCoursesRead) {
+			return c.SendStatus(fiber.StatusForbidden)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer tok1")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusOK)
+	}
+}
@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// Store holds raw cache entries. Counters created by Incr are readable with Get.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Incr(ctx context.Context, key string) (int64, error)
+}
+
+// Cache is a cache-aside layer over a Store. A nil *Cache is valid and
+// always loads from the source.
+type Cache struct {
+	store Store
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+func New(store Store, ttl time.Duration) *Cache {
+	return &Cache{store: store, ttl: ttl}
+}
+
+// FromEnv builds the cache configured by CACHE_* variables, or returns nil when
+// CACHE_DISABLED=true.
+func FromEnv() *Cache {
+	if os.Getenv("CACHE_DISABLED") == "true" {
+		return nil
+	}
+
+	ttl := getdur("CACHE_TTL", 30*time.Second)
+	size, err := strconv.Atoi(os.Getenv("CACHE_SIZE"))
+	if err != nil || size < 1 {
+		size = 10000
+	}
+
+	var store Store = NewLRU(size)
+	if addr := os.Getenv("CACHE_REDIS_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: os.Getenv("CACHE_REDIS_PASSWORD"),
+		})
+		store = NewTiered(store, NewRedis(client, "api-courses:"), getdur("CACHE_LOCAL_TTL", 5*time.Second))
+	}
+
+	return New(store, ttl)
+}
+
+// Fetch returns the value cached under key, or loads it, caches it and returns it.
+// Concurrent misses for the same key share a single call to load. Store errors are
+// treated as misses so an unavailable backend never fails a request.
+func Fetch[T any](ctx context.Context, c *Cache, name, key string, load func() (T, error)) (T, error) {
+	if c == nil {
+		return load()
+	}
+
+	raw, ok, err := c.store.Get(ctx, key)
+	if err != nil {
+		obs.Logger(ctx).WarnContext(ctx, "cache get failed", "key", key, "error", err)
+	}
+	if ok {
+		var value T
+		if err := json.Unmarshal(raw, &value); err == nil {
+			metrics.CacheHitsTotal.WithLabelValues(name).Inc()
+			return value, nil
+		}
+	}
+
+	metrics.CacheMissesTotal.WithLabelValues(name).Inc()
+
+	value, err, _ := c.group.Do(key, func() (any, error) {
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+
+		if raw, err := json.Marshal(value); err == nil {
+			if err := c.store.Set(ctx, key, raw, c.ttl); err != nil {
+				obs.Logger(ctx).WarnContext(ctx, "cache set failed", "key", key, "error", err)
+			}
+		}
+
+		return value, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return value.(T), nil
+}
+
+// Namespace returns the current prefix for keys in ns. Keys built on it are
+// invalidated all at once by Bump.
+func (c *Cache) Namespace(ctx context.Context, ns string) string {
+	if c == nil {
+		return ns
+	}
+
+	raw, ok, err := c.store.Get(ctx, ns+":gen")
+	if err != nil || !ok {
+		return ns + ":v0"
+	}
+
+	return ns + ":v" + string(raw)
+}
+
+// Bump invalidates every key built on the namespace ns.
+func (c *Cache) Bump(ctx context.Context, ns string) {
+	if c == nil {
+		return
+	}
+
+	if _, err := c.store.Incr(ctx, ns+":gen"); err != nil {
+		obs.Logger(ctx).WarnContext(ctx, "cache bump failed", "namespace", ns, "error", err)
+	}
+}
+
+// Delete invalidates the given keys.
+func (c *Cache) Delete(ctx context.Context, keys ...string) {
+	if c == nil {
+		return
+	}
+
+	if err := c.store.Delete(ctx, keys...); err != nil {
+		obs.Logger(ctx).WarnContext(ctx, "cache delete failed", "keys", keys, "error", err)
+	}
+}
+
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
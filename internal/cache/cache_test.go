@@ -0,0 +1,196 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+type item struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func TestFetch_CachesLoadedValue(t *testing.T) {
+	c := New(NewLRU(10), time.Minute)
+	ctx := context.Background()
+	var loads int
+
+	load := func() (item, error) {
+		loads++
+		return item{ID: "1", Title: "Go"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		got, err := Fetch(ctx, c, "item", "item:1", load)
+		if err != nil {
+			t.Fatalf("Fetch: %v", err)
+		}
+		if got.Title != "Go" {
+			t.Fatalf("Unexpected value: %#v", got)
+		}
+	}
+
+	if loads != 1 {
+		t.Fatalf("loads=%d want=1", loads)
+	}
+}
+
+func TestFetch_DoesNotCacheErrors(t *testing.T) {
+	c := New(NewLRU(10), time.Minute)
+	ctx := context.Background()
+	var loads int
+
+	load := func() (item, error) {
+		loads++
+		return item{}, errors.New("boom")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := Fetch(ctx, c, "item", "item:1", load); err == nil {
+			t.Fatal("Expected error")
+		}
+	}
+
+	if loads != 2 {
+		t.Fatalf("loads=%d want=2", loads)
+	}
+}
+
+func TestFetch_CollapsesConcurrentMisses(t *testing.T) {
+	c := New(NewLRU(10), time.Minute)
+	ctx := context.Background()
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	load := func() (item, error) {
+		loads.Add(1)
+		<-release
+		return item{ID: "1"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = Fetch(ctx, c, "item", "item:1", load)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("loads=%d want=1", n)
+	}
+}
+
+func TestFetch_NilCacheAlwaysLoads(t *testing.T) {
+	var c *Cache
+	var loads int
+
+	for i := 0; i < 2; i++ {
+		_, _ = Fetch(context.Background(), c, "item", "item:1", func() (item, error) {
+			loads++
+			return item{}, nil
+		})
+	}
+
+	c.Delete(context.Background(), "item:1")
+	c.Bump(context.Background(), "items")
+
+	if loads != 2 {
+		t.Fatalf("loads=%d want=2", loads)
+	}
+}
+
+func TestCache_DeleteAndBumpInvalidate(t *testing.T) {
+	c := New(NewLRU(10), time.Minute)
+	ctx := context.Background()
+
+	ns := c.Namespace(ctx, "items")
+	_, _ = Fetch(ctx, c, "item", ns+":page1", func() (item, error) { return item{ID: "old"}, nil })
+	_, _ = Fetch(ctx, c, "item", "item:1", func() (item, error) { return item{ID: "old"}, nil })
+
+	c.Bump(ctx, "items")
+	c.Delete(ctx, "item:1")
+
+	if c.Namespace(ctx, "items") == ns {
+		t.Fatalf("Expected namespace to change after Bump")
+	}
+
+	got, _ := Fetch(ctx, c, "item", "item:1", func() (item, error) { return item{ID: "new"}, nil })
+	if got.ID != "new" {
+		t.Fatalf("Expected reload after Delete, got %#v", got)
+	}
+}
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	l := NewLRU(2)
+	ctx := context.Background()
+
+	_ = l.Set(ctx, "a", []byte("1"), 0)
+	_ = l.Set(ctx, "b", []byte("2"), 0)
+	_, _, _ = l.Get(ctx, "a")
+	_ = l.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := l.Get(ctx, "b"); ok {
+		t.Fatal("Expected b to be evicted")
+	}
+	if _, ok, _ := l.Get(ctx, "a"); !ok {
+		t.Fatal("Expected a to be kept")
+	}
+	if l.Len() != 2 {
+		t.Fatalf("Len=%d want=2", l.Len())
+	}
+}
+
+func TestLRU_ExpiresAfterTTL(t *testing.T) {
+	l := NewLRU(10)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	_ = l.Set(context.Background(), "a", []byte("1"), time.Second)
+	now = now.Add(2 * time.Second)
+
+	if _, ok, _ := l.Get(context.Background(), "a"); ok {
+		t.Fatal("Expected a to be expired")
+	}
+}
+
+func TestTiered_RedisBackend(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	ctx := context.Background()
+
+	far := NewRedis(client, "test:")
+	c := New(NewTiered(NewLRU(10), far, time.Second), time.Minute)
+
+	_, _ = Fetch(ctx, c, "item", "item:1", func() (item, error) { return item{ID: "1"}, nil })
+	if !srv.Exists("test:item:1") {
+		t.Fatal("Expected entry to be written to redis")
+	}
+
+	// A second instance sharing redis gets the entry without loading.
+	other := New(NewTiered(NewLRU(10), far, time.Second), time.Minute)
+	got, err := Fetch(ctx, other, "item", "item:1", func() (item, error) {
+		return item{}, errors.New("should not load")
+	})
+	if err != nil || got.ID != "1" {
+		t.Fatalf("Fetch=%#v err=%v", got, err)
+	}
+
+	ns := other.Namespace(ctx, "items")
+	c.Bump(ctx, "items")
+	if other.Namespace(ctx, "items") == ns {
+		t.Fatal("Expected bump to be visible to other instances")
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis is a Store shared by every API instance.
+type Redis struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedis(client redis.UniversalClient, prefix string) *Redis {
+	return &Redis{client: client, prefix: prefix}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.client.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+key, value, ttl).Err()
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = r.prefix + key
+	}
+
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+func (r *Redis) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, r.prefix+key).Result()
+}
+
+// Tiered keeps a short-lived near copy of far entries, typically an LRU in
+// front of Redis. Invalidations only reach the near store of this instance,
+// so nearTTL bounds how stale other instances can be.
+type Tiered struct {
+	near    Store
+	far     Store
+	nearTTL time.Duration
+}
+
+func NewTiered(near, far Store, nearTTL time.Duration) *Tiered {
+	return &Tiered{near: near, far: far, nearTTL: nearTTL}
+}
+
+func (t *Tiered) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	if value, ok, err := t.near.Get(ctx, key); err == nil && ok {
+		return value, true, nil
+	}
+
+	value, ok, err := t.far.Get(ctx, key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	_ = t.near.Set(ctx, key, value, t.nearTTL)
+	return value, true, nil
+}
+
+func (t *Tiered) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	nearTTL := t.nearTTL
+	if ttl > 0 && ttl < nearTTL {
+		nearTTL = ttl
+	}
+
+	_ = t.near.Set(ctx, key, value, nearTTL)
+	return t.far.Set(ctx, key, value, ttl)
+}
+
+func (t *Tiered) Delete(ctx context.Context, keys ...string) error {
+	_ = t.near.Delete(ctx, keys...)
+	return t.far.Delete(ctx, keys...)
+}
+
+func (t *Tiered) Incr(ctx context.Context, key string) (int64, error) {
+	_ = t.near.Delete(ctx, key)
+	return t.far.Incr(ctx, key)
+}
@@ -1,30 +1,23 @@
-// Package docs Code generated by swaggo/swag. DO NOT EDIT
-package docs
+// Package v2 Code generated by swaggo/swag. DO NOT EDIT
+package v2
 
 import "github.com/swaggo/swag"
 
-const docTemplate = `{
+const docTemplatev2 = `{
     "schemes": {{ marshal .Schemes }},
     "swagger": "2.0",
     "info": {
         "description": "{{escape .Description}}",
         "title": "{{.Title}}",
-        "contact": {
-            "name": "Guilherme Arantes Canella",
-            "url": "https://github.com/guycanella",
-            "email": "guycanella@gmail.com"
-        },
+        "contact": {},
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/courses": {
+        "/v2/courses": {
             "get": {
                 "description": "Returns paginated courses, with optional search by title.",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
@@ -51,10 +44,8 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "maxLength": 100,
-                        "minLength": 2,
                         "type": "string",
-                        "description": "Title fragment (2..100)",
+                        "description": "Title fragment",
                         "name": "q",
                         "in": "query"
                     }
@@ -63,25 +54,31 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CoursesResponse"
+                            "$ref": "#/definitions/handlersv2.CoursesResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Creates a course and returns only the courseId and the Location header.",
+                "description": "Creates a course and returns it with the Location header.",
                 "consumes": [
                     "application/json"
                 ],
@@ -99,7 +96,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreateCourseDTO"
+                            "$ref": "#/definitions/handlersv2.CreateCourseDTO"
                         }
                     }
                 ],
@@ -107,50 +104,56 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CreatedIDResponse"
+                            "$ref": "#/definitions/handlersv2.CourseResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     },
                     "409": {
                         "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     },
                     "422": {
                         "description": "Unprocessable Entity",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ValidationErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/courses/{courseId}": {
+        "/v2/courses/{courseId}": {
             "get": {
+                "description": "A former slug of the course is redirected to the current one with 301.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "courses"
                 ],
-                "summary": "Get course by ID",
+                "summary": "Get course by ID or slug",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "Course ID",
+                        "description": "Course ID or slug",
                         "name": "courseId",
                         "in": "path",
                         "required": true
@@ -160,25 +163,37 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.CourseResponse"
+                            "$ref": "#/definitions/handlersv2.CourseResponse"
+                        }
+                    },
+                    "301": {
+                        "description": "Location is the URL of the current slug",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/handlersv2.ErrorResponse"
                         }
                     }
                 }
@@ -186,24 +201,7 @@ const docTemplate = `{
         }
     },
     "definitions": {
-        "domain.Course": {
-            "type": "object",
-            "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
-                }
-            }
-        },
-        "handlers.CourseDoc": {
+        "handlersv2.Course": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -218,44 +216,39 @@ const docTemplate = `{
                     "type": "string",
                     "example": "4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"
                 },
+                "slug": {
+                    "type": "string",
+                    "example": "go-para-iniciantes"
+                },
                 "title": {
                     "type": "string",
                     "example": "Go para Iniciantes"
                 }
             }
         },
-        "handlers.CourseResponse": {
+        "handlersv2.CourseResponse": {
             "type": "object",
             "properties": {
-                "course": {
-                    "$ref": "#/definitions/domain.Course"
+                "data": {
+                    "$ref": "#/definitions/handlersv2.Course"
                 }
             }
         },
-        "handlers.CoursesResponse": {
+        "handlersv2.CoursesResponse": {
             "type": "object",
             "properties": {
                 "data": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/handlers.CourseDoc"
+                        "$ref": "#/definitions/handlersv2.Course"
                     }
                 },
-                "limit": {
-                    "type": "integer",
-                    "example": 10
-                },
-                "page": {
-                    "type": "integer",
-                    "example": 1
-                },
-                "total": {
-                    "type": "integer",
-                    "example": 42
+                "meta": {
+                    "$ref": "#/definitions/handlersv2.PageMeta"
                 }
             }
         },
-        "handlers.CreateCourseDTO": {
+        "handlersv2.CreateCourseDTO": {
             "type": "object",
             "properties": {
                 "description": {
@@ -268,39 +261,62 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.CreatedIDResponse": {
+        "handlersv2.Error": {
             "type": "object",
             "properties": {
-                "courseId": {
+                "code": {
                     "type": "string",
-                    "example": "4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"
+                    "example": "not_found"
+                },
+                "fields": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "example": {
+                        "{\"title\"": "\"must be at least 3 characters\"}"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "course not found"
                 }
             }
         },
-        "handlers.ErrorResponse": {
+        "handlersv2.ErrorResponse": {
             "type": "object",
             "properties": {
                 "error": {
-                    "type": "string",
-                    "example": "internal server error"
+                    "$ref": "#/definitions/handlersv2.Error"
                 }
             }
         },
-        "handlers.ValidationErrorResponse": {
+        "handlersv2.PageMeta": {
             "type": "object",
             "properties": {
-                "errors": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "string"
-                    },
-                    "example": {
-                        "{\"title\"": "\"is required\"}"
-                    }
+                "limit": {
+                    "type": "integer",
+                    "example": 10
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
                 }
             }
         }
     },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "\"Bearer \u003ctoken\u003e\", with a token from AUTH_TOKENS",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    },
     "tags": [
         {
             "description": "Operations on courses",
@@ -309,20 +325,20 @@ const docTemplate = `{
     ]
 }`
 
-// SwaggerInfo holds exported Swagger Info so clients can modify it
-var SwaggerInfo = &swag.Spec{
-	Version:          "1.0",
+// SwaggerInfov2 holds exported Swagger Info so clients can modify it
+var SwaggerInfov2 = &swag.Spec{
+	Version:          "2.0",
 	Host:             "localhost:3333",
 	BasePath:         "/",
 	Schemes:          []string{"http"},
 	Title:            "Go API Courses",
-	Description:      "API to manage courses, users and enrollments",
-	InfoInstanceName: "swagger",
-	SwaggerTemplate:  docTemplate,
+	Description:      "API to manage courses, users and enrollments, with uniform response envelopes",
+	InfoInstanceName: "v2",
+	SwaggerTemplate:  docTemplatev2,
 	LeftDelim:        "{{",
 	RightDelim:       "}}",
 }
 
 func init() {
-	swag.Register(SwaggerInfo.InstanceName(), SwaggerInfo)
+	swag.Register(SwaggerInfov2.InstanceName(), SwaggerInfov2)
 }
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Category is a topic of the catalog. Categories nest under ParentID; a
+// category with subcategories cannot be deleted.
+type Category struct {
+	ID        string    `json:"id"         gorm:"type:char(36);primaryKey"`
+	Name      string    `json:"name"       gorm:"type:varchar(100);not null"`
+	Slug      string    `json:"slug"       gorm:"type:varchar(100);not null;uniqueIndex"`
+	ParentID  *string   `json:"parent_id"  gorm:"type:char(36);index"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Parent *Category `json:"-" gorm:"foreignKey:ParentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;"`
+}
+
+func (category *Category) BeforeCreate(tx *gorm.DB) (err error) {
+	if category.ID == "" {
+		category.ID = uuid.NewString()
+	}
+
+	return nil
+}
+
+// Tag is a free-form label of courses. Its name is kept in slug form.
+type Tag struct {
+	ID        string    `json:"id"         gorm:"type:char(36);primaryKey"`
+	Name      string    `json:"name"       gorm:"type:varchar(50);not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (tag *Tag) BeforeCreate(tx *gorm.DB) (err error) {
+	if tag.ID == "" {
+		tag.ID = uuid.NewString()
+	}
+
+	return nil
+}
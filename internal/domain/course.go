@@ -10,8 +10,15 @@ import (
 type Course struct {
 	ID          string    `json:"id" gorm:"type:char(36);primaryKey"`
 	Title       string    `json:"title" gorm:"type:varchar(255);not null;uniqueIndex"`
+	Slug        *string   `json:"slug,omitempty" gorm:"type:varchar(120);uniqueIndex"`
 	Description string    `json:"description" gorm:"type:text"`
 	CreatedAt   time.Time `json:"created_at"`
+
+	InstructorID *string `json:"instructor_id,omitempty" gorm:"type:char(36);index"`
+	Instructor   *User   `json:"-"                       gorm:"foreignKey:InstructorID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+
+	Categories []Category `json:"-" gorm:"many2many:course_categories;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Tags       []Tag      `json:"-" gorm:"many2many:course_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
 }
 
 func (course *Course) BeforeCreate(tx *gorm.DB) (err error) {
@@ -21,3 +28,26 @@ func (course *Course) BeforeCreate(tx *gorm.DB) (err error) {
 
 	return nil
 }
+
+// CourseSlug is a former slug of a course, kept so that old URLs redirect to
+// the current one.
+type CourseSlug struct {
+	Slug      string    `json:"slug" gorm:"type:varchar(120);primaryKey"`
+	CourseID  string    `json:"course_id" gorm:"type:char(36);not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Course *Course `json:"-" gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+// CourseTranslation is the title and description of a course in a locale
+// other than the default one. Titles are unique per locale.
+type CourseTranslation struct {
+	CourseID    string    `json:"-" gorm:"type:char(36);primaryKey"`
+	Locale      string    `json:"locale" gorm:"type:varchar(16);primaryKey;uniqueIndex:idx_course_translations_title,priority:1"`
+	Title       string    `json:"title" gorm:"type:varchar(255);not null;uniqueIndex:idx_course_translations_title,priority:2"`
+	Description string    `json:"description" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Course *Course `json:"-" gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
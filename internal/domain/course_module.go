@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// CourseModule is a section of a course, listed by Position.
+type CourseModule struct {
+	ID        string    `json:"id"        gorm:"type:char(36);primaryKey"`
+	CourseID  string    `json:"course_id" gorm:"type:char(36);not null;index:idx_course_position"`
+	Title     string    `json:"title"     gorm:"type:varchar(255);not null"`
+	Position  int       `json:"position"  gorm:"not null;index:idx_course_position"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Course Course `json:"-" gorm:"foreignKey:CourseID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+func (module *CourseModule) BeforeCreate(tx *gorm.DB) (err error) {
+	if module.ID == "" {
+		module.ID = uuid.NewString()
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ImportJobPending   = "pending"
+	ImportJobRunning   = "running"
+	ImportJobSucceeded = "succeeded"
+	ImportJobFailed    = "failed"
+)
+
+// CourseImportJob tracks a course import running in the background. Report
+// holds the JSON import report and is updated as rows are processed; with up
+// to 1000 row errors it outgrows a text column.
+type CourseImportJob struct {
+	ID         string     `json:"id"          gorm:"type:char(36);primaryKey"`
+	Status     string     `json:"status"      gorm:"type:varchar(16);not null;index"`
+	Format     string     `json:"format"      gorm:"type:varchar(16);not null"`
+	DryRun     bool       `json:"dry_run"     gorm:"not null"`
+	Report     string     `json:"-"           gorm:"type:mediumtext"`
+	Error      string     `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (job *CourseImportJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+
+	return nil
+}
+
+// CourseImportChunk is a piece of the body of an import job, kept in the
+// database so any instance can run the job. Seq orders the chunks from 0.
+type CourseImportChunk struct {
+	JobID string           `gorm:"type:char(36);primaryKey"`
+	Seq   int              `gorm:"primaryKey;autoIncrement:false"`
+	Data  []byte           `gorm:"type:mediumblob;not null"`
+	Job   *CourseImportJob `gorm:"foreignKey:JobID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
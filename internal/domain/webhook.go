@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription is a partner endpoint notified of the listed event
+// types. Secret signs every delivery and is only shown when created.
+// FailureCount counts consecutive failed attempts; the subscription is
+// disabled once it reaches the configured limit.
+type WebhookSubscription struct {
+	ID             string     `json:"id"                        gorm:"type:char(36);primaryKey"`
+	URL            string     `json:"url"                       gorm:"type:varchar(2048);not null"`
+	EventTypes     []string   `json:"event_types"               gorm:"type:text;not null;serializer:json"`
+	Secret         string     `json:"-"                         gorm:"type:varchar(128);not null"`
+	Active         bool       `json:"active"                    gorm:"not null;index"`
+	FailureCount   int        `json:"failure_count"             gorm:"not null"`
+	DisabledAt     *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason string     `json:"disabled_reason,omitempty" gorm:"type:varchar(255)"`
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (sub *WebhookSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	if sub.ID == "" {
+		sub.ID = uuid.NewString()
+	}
+
+	return nil
+}
+
+// Subscribes reports whether sub wants events of eventType.
+func (sub *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range sub.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one attempt to deliver an event, with snapshots of the
+// request and response. ResponseBody is truncated.
+type WebhookDelivery struct {
+	ID              string            `json:"id"                         gorm:"type:char(36);primaryKey"`
+	SubscriptionID  string            `json:"subscription_id"            gorm:"type:char(36);not null;index:idx_webhook_deliveries_sub,priority:1"`
+	EventID         string            `json:"event_id"                   gorm:"type:char(36);not null;index"`
+	EventType       string            `json:"event_type"                 gorm:"type:varchar(64);not null"`
+	Attempt         int               `json:"attempt"                    gorm:"not null"`
+	Succeeded       bool              `json:"succeeded"                  gorm:"not null"`
+	RequestURL      string            `json:"request_url"                gorm:"type:varchar(2048);not null"`
+	RequestHeaders  map[string]string `json:"request_headers"            gorm:"type:text;serializer:json"`
+	RequestBody     string            `json:"request_body"               gorm:"type:mediumtext"`
+	ResponseStatus  int               `json:"response_status,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty" gorm:"type:text;serializer:json"`
+	ResponseBody    string            `json:"response_body,omitempty"    gorm:"type:text"`
+	Error           string            `json:"error,omitempty"            gorm:"type:text"`
+	DurationMs      int64             `json:"duration_ms"`
+	CreatedAt       time.Time         `json:"created_at"                 gorm:"index:idx_webhook_deliveries_sub,priority:2"`
+}
+
+func (delivery *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	if delivery.ID == "" {
+		delivery.ID = uuid.NewString()
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Package events records domain events in a transactional outbox and relays
+// them to sinks.
+//
+// Events are written with Record in the same transaction as the entity they
+// describe, so an event exists if and only if the change was committed. The
+// Relay then publishes pending events to every configured Sink in outbox
+// order. Delivery is at least once: consumers should deduplicate on Event.ID.
+// Events of one aggregate are published in order; a failing event holds back
+// the later events of its aggregate until it is delivered.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+)
+
+// Aggregate types.
+const (
+	AggregateCourse     = "course"
+	AggregateUser       = "user"
+	AggregateEnrollment = "enrollment"
+)
+
+// Event types.
+const (
+	TypeCourseCreated       = "course.created"
+	TypeCourseUpdated       = "course.updated"
+	TypeUserCreated         = "user.created"
+	TypeUserUpdated         = "user.updated"
+	TypeEnrollmentCreated   = "enrollment.created"
+	TypeEnrollmentCancelled = "enrollment.cancelled"
+)
+
+// Types lists every event type.
+var Types = []string{
+	TypeCourseCreated,
+	TypeCourseUpdated,
+	TypeUserCreated,
+	TypeUserUpdated,
+	TypeEnrollmentCreated,
+	TypeEnrollmentCancelled,
+}
+
+// Event is the envelope delivered to sinks. Sequence is the outbox position:
+// it grows with every event, so it orders the events of an aggregate.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Sequence      uint64          `json:"sequence"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+func newEvent(eventType, aggregateType, aggregateID string, data any) Event {
+	// Payloads are plain domain structs, which always encode.
+	raw, _ := json.Marshal(data)
+
+	return Event{
+		ID:            uuid.NewString(),
+		Type:          eventType,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		OccurredAt:    time.Now().UTC(),
+		Data:          raw,
+	}
+}
+
+func CourseCreated(course domain.Course) Event {
+	return newEvent(TypeCourseCreated, AggregateCourse, course.ID, course)
+}
+
+func CourseUpdated(course domain.Course) Event {
+	return newEvent(TypeCourseUpdated, AggregateCourse, course.ID, course)
+}
+
+func UserCreated(user domain.User) Event {
+	return newEvent(TypeUserCreated, AggregateUser, user.ID, user)
+}
+
+func UserUpdated(user domain.User) Event {
+	return newEvent(TypeUserUpdated, AggregateUser, user.ID, user)
+}
+
+func EnrollmentCreated(enrollment domain.Enrollment) Event {
+	return newEvent(TypeEnrollmentCreated, AggregateEnrollment, enrollment.ID, enrollment)
+}
+
+func EnrollmentCancelled(enrollment domain.Enrollment) Event {
+	return newEvent(TypeEnrollmentCancelled, AggregateEnrollment, enrollment.ID, enrollment)
+}
@@ -0,0 +1,253 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+// recordingSink remembers what it published and fails the events in failIDs.
+type recordingSink struct {
+	got     []string
+	failIDs map[string]bool
+}
+
+func (s *recordingSink) Name() string { return "bus" }
+
+func (s *recordingSink) Publish(_ context.Context, e events.Event) error {
+	if s.failIDs[e.ID] {
+		return errors.New("unavailable")
+	}
+	s.got = append(s.got, e.ID)
+	return nil
+}
+
+func TestCourseCreated_Envelope(t *testing.T) {
+	e := events.CourseCreated(domain.Course{ID: "c1", Title: "Go"})
+
+	if e.ID == "" || e.Type != events.TypeCourseCreated || e.AggregateType != events.AggregateCourse || e.AggregateID != "c1" {
+		t.Fatalf("unexpected envelope: %+v", e)
+	}
+
+	var data domain.Course
+	if err := json.Unmarshal(e.Data, &data); err != nil || data.Title != "Go" {
+		t.Fatalf("data = %s (%v)", e.Data, err)
+	}
+}
+
+func TestBus_SubscribeAndUnsubscribe(t *testing.T) {
+	bus := events.NewBus()
+
+	var n int
+	unsubscribe := bus.Subscribe(func(context.Context, events.Event) { n++ })
+
+	_ = bus.Publish(context.Background(), events.Event{ID: "1"})
+	unsubscribe()
+	_ = bus.Publish(context.Background(), events.Event{ID: "2"})
+
+	if n != 1 {
+		t.Fatalf("subscriber called %d times, want 1", n)
+	}
+}
+
+func TestHTTPSink_PostsEvent(t *testing.T) {
+	var got events.Event
+	var key string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get("Idempotency-Key")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &events.HTTPSink{URL: srv.URL}
+	e := events.CourseCreated(domain.Course{ID: "c1", Title: "Go"})
+
+	if err := sink.Publish(context.Background(), e); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got.ID != e.ID || key != e.ID {
+		t.Fatalf("got event %q with key %q, want %q", got.ID, key, e.ID)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := sink.Publish(context.Background(), e); err == nil {
+		t.Fatalf("expected an error for a 503")
+	}
+}
+
+func TestRedisStreamSink_Appends(t *testing.T) {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	sink := &events.RedisStreamSink{Client: client, Stream: "test:events", MaxLen: 10}
+
+	e := events.CourseCreated(domain.Course{ID: "c1", Title: "Go"})
+	if err := sink.Publish(context.Background(), e); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	msgs, err := client.XRange(context.Background(), "test:events", "-", "+").Result()
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("XRange: %v %v", msgs, err)
+	}
+	if msgs[0].Values["id"] != e.ID || msgs[0].Values["type"] != events.TypeCourseCreated {
+		t.Fatalf("unexpected entry: %v", msgs[0].Values)
+	}
+}
+
+func TestSinksFromEnv(t *testing.T) {
+	t.Setenv("EVENTS_SINKS", "bus, webhook")
+	if _, err := events.SinksFromEnv(events.NewBus()); err == nil {
+		t.Fatalf("expected an error without EVENTS_WEBHOOK_URL")
+	}
+
+	t.Setenv("EVENTS_WEBHOOK_URL", "http://localhost:9999/events")
+	sinks, err := events.SinksFromEnv(events.NewBus())
+	if err != nil || len(sinks) != 2 || sinks[0].Name() != "bus" || sinks[1].Name() != "webhook" {
+		t.Fatalf("sinks = %v, err = %v", sinks, err)
+	}
+
+	t.Setenv("EVENTS_SINKS", "kafka")
+	if _, err := events.SinksFromEnv(events.NewBus()); err == nil {
+		t.Fatalf("expected an error for an unknown sink")
+	}
+}
+
+func TestRecord_RequiresTransaction(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	if err := events.Record(db, events.CourseCreated(domain.Course{ID: "c1"})); err == nil {
+		t.Fatalf("expected an error outside a transaction")
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `outbox_events`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return events.Record(tx, events.CourseCreated(domain.Course{ID: "c1"}))
+	})
+	if err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+// pendingQuery matches the relay's batch query, which leaves out aggregates
+// waiting for a retry.
+const pendingQuery = "SELECT \\* FROM `outbox_events` WHERE \\(published_at IS NULL AND dead_at IS NULL\\) " +
+	"AND \\(NOT EXISTS \\(SELECT 1 FROM outbox_events AS head .*head.next_attempt_at > \\?\\)\\) ORDER BY sequence LIMIT \\?"
+
+func TestRelay_HoldsBackAggregateAfterFailure(t *testing.T) {
+	db, mock := openMockDB(t)
+	sink := &recordingSink{failIDs: map[string]bool{"a1": true}}
+
+	mock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectQuery(pendingQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "id", "type", "aggregate_type", "aggregate_id", "payload", "attempts"}).
+			AddRow(1, "a1", events.TypeCourseCreated, "course", "A", "{}", 0).
+			AddRow(2, "b1", events.TypeCourseCreated, "course", "B", "{}", 0).
+			AddRow(3, "a2", events.TypeCourseUpdated, "course", "A", "{}", 0))
+	// a1 fails and is rescheduled, b1 is published, a2 waits for a1
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `outbox_events` SET .*`last_error`=.*`next_attempt_at`=.* WHERE sequence = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `outbox_events` SET .*`published_at`=.* WHERE sequence = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := events.NewRelay(db, sink).Tick(context.Background(), events.RelayConfig{BatchSize: 10}); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if len(sink.got) != 1 || sink.got[0] != "b1" {
+		t.Fatalf("published %v, want [b1]", sink.got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestRelay_DeadLettersAfterMaxAttempts(t *testing.T) {
+	db, mock := openMockDB(t)
+	sink := &recordingSink{failIDs: map[string]bool{"a1": true}}
+
+	mock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectQuery(pendingQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "id", "type", "aggregate_type", "aggregate_id", "payload", "attempts"}).
+			AddRow(1, "a1", events.TypeCourseCreated, "course", "A", "{}", 2).
+			AddRow(2, "a2", events.TypeCourseUpdated, "course", "A", "{}", 0))
+	// a1 fails its third attempt and is dead-lettered, so a2 goes out
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `outbox_events` SET `attempts`=\\?,`dead_at`=\\?,`last_error`=\\? WHERE sequence = \\?").
+		WithArgs(3, sqlmock.AnyArg(), "bus: unavailable", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `outbox_events` SET .*`published_at`=.* WHERE sequence = \\?").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("SELECT RELEASE_LOCK").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := events.NewRelay(db, sink).Tick(context.Background(), events.RelayConfig{BatchSize: 10, MaxAttempts: 3}); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if len(sink.got) != 1 || sink.got[0] != "a2" {
+		t.Fatalf("published %v, want [a2]", sink.got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestRelay_SkipsWithoutLock(t *testing.T) {
+	db, mock := openMockDB(t)
+	sink := &recordingSink{}
+
+	mock.ExpectQuery("SELECT GET_LOCK").WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+	if err := events.NewRelay(db, sink).Tick(context.Background(), events.RelayConfig{BatchSize: 10}); err != nil {
+		t.Fatalf("Tick: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
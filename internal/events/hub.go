@@ -0,0 +1,154 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+)
+
+var ErrTooManySubscribers = errors.New("events: too many stream subscribers")
+
+// subscriberBuffer is how many events a stream subscriber may lag behind
+// before it is disconnected.
+const subscriberBuffer = 64
+
+// Hub fans published events out to stream subscribers and keeps the latest
+// ones in a bounded log, so clients can resume after a reconnect.
+//
+// Positions are only meaningful to the Hub that issued them: a cursor carries
+// the epoch of its Hub, and a cursor from another epoch (another instance or
+// a restart) or older than the log cannot resume.
+type Hub struct {
+	epoch   string
+	maxSubs int
+
+	mu   sync.Mutex
+	log  []Event // ring buffer of the last cap(log) events
+	head uint64  // position of the next event
+	subs map[*Subscription]struct{}
+}
+
+// NewHub keeps the last size events and accepts up to maxSubscribers streams.
+func NewHub(size, maxSubscribers int) *Hub {
+	return &Hub{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		maxSubs: maxSubscribers,
+		log:     make([]Event, 0, size),
+		subs:    map[*Subscription]struct{}{},
+	}
+}
+
+// Positioned is an event with its stream cursor.
+type Positioned struct {
+	Cursor string
+	Event  Event
+}
+
+// Subscription receives the events matching its filter. C is closed when the
+// subscriber falls too far behind.
+type Subscription struct {
+	C      <-chan Positioned
+	c      chan Positioned
+	filter func(Event) bool
+}
+
+// Publish appends e to the log and hands it to the subscribers. It is fed by
+// a Tail.
+func (h *Hub) Publish(_ context.Context, e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if cap(h.log) > 0 {
+		if len(h.log) < cap(h.log) {
+			h.log = append(h.log, e)
+		} else {
+			h.log[h.head%uint64(cap(h.log))] = e
+		}
+	}
+	p := Positioned{Cursor: h.cursor(h.head), Event: e}
+	h.head++
+
+	for sub := range h.subs {
+		if !sub.filter(e) {
+			continue
+		}
+		select {
+		case sub.c <- p:
+		default:
+			// Too slow: drop it so it reconnects and resumes from the log.
+			metrics.EventStreamDisconnectsTotal.Inc()
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for the events matching filter. With a
+// cursor, it also returns the logged events after it; resumed is false when
+// the cursor can no longer be resumed and events may have been missed.
+func (h *Hub) Subscribe(cursor string, filter func(Event) bool) (sub *Subscription, backlog []Positioned, resumed bool, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subs) >= h.maxSubs {
+		return nil, nil, false, ErrTooManySubscribers
+	}
+
+	resumed = true
+	if cursor != "" {
+		from, ok := h.position(cursor)
+		oldest := h.head - uint64(len(h.log))
+		if !ok || from+1 < oldest || from >= h.head {
+			resumed = false
+			from = h.head - 1
+		}
+		for pos := from + 1; pos < h.head; pos++ {
+			e := h.log[pos%uint64(cap(h.log))]
+			if filter(e) {
+				backlog = append(backlog, Positioned{Cursor: h.cursor(pos), Event: e})
+			}
+		}
+	}
+
+	c := make(chan Positioned, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, filter: filter}
+	h.subs[sub] = struct{}{}
+	metrics.EventStreamSubscribersGauge.Set(float64(len(h.subs)))
+
+	return sub, backlog, resumed, nil
+}
+
+// Unsubscribe removes sub. It is safe to call more than once.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(sub)
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.c)
+	metrics.EventStreamSubscribersGauge.Set(float64(len(h.subs)))
+}
+
+func (h *Hub) cursor(pos uint64) string {
+	return fmt.Sprintf("%s-%d", h.epoch, pos)
+}
+
+func (h *Hub) position(cursor string) (uint64, bool) {
+	epoch, pos, ok := strings.Cut(cursor, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(pos, 10, 64)
+	return n, err == nil
+}
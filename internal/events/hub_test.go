@@ -0,0 +1,90 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/guycanella/api-courses-golang/internal/events"
+)
+
+func all(events.Event) bool { return true }
+
+func TestHub_DeliversMatchingEvents(t *testing.T) {
+	hub := events.NewHub(10, 10)
+	sub, _, _, err := hub.Subscribe("", func(e events.Event) bool { return e.Type == events.TypeCourseCreated })
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	hub.Publish(context.Background(), events.Event{ID: "1", Type: events.TypeCourseUpdated})
+	hub.Publish(context.Background(), events.Event{ID: "2", Type: events.TypeCourseCreated})
+
+	if p := <-sub.C; p.Event.ID != "2" || p.Cursor == "" {
+		t.Fatalf("got %+v, want event 2", p)
+	}
+	if len(sub.C) != 0 {
+		t.Fatalf("unexpected extra events")
+	}
+}
+
+func TestHub_ResumesFromCursor(t *testing.T) {
+	hub := events.NewHub(3, 10)
+	first, _, _, _ := hub.Subscribe("", all)
+
+	for _, id := range []string{"1", "2", "3"} {
+		hub.Publish(context.Background(), events.Event{ID: id})
+	}
+	cursor := (<-first.C).Cursor
+
+	_, backlog, resumed, err := hub.Subscribe(cursor, all)
+	if err != nil || !resumed || len(backlog) != 2 || backlog[0].Event.ID != "2" || backlog[1].Event.ID != "3" {
+		t.Fatalf("backlog=%+v resumed=%v err=%v", backlog, resumed, err)
+	}
+
+	// The log only keeps three events, so event 1 has been evicted after two more.
+	hub.Publish(context.Background(), events.Event{ID: "4"})
+	hub.Publish(context.Background(), events.Event{ID: "5"})
+	if _, backlog, resumed, _ := hub.Subscribe(cursor, all); resumed || len(backlog) != 0 {
+		t.Fatalf("evicted cursor resumed: backlog=%+v", backlog)
+	}
+
+	if _, _, resumed, _ := hub.Subscribe("other-0", all); resumed {
+		t.Fatal("cursor of another hub resumed")
+	}
+}
+
+func TestHub_CapsSubscribers(t *testing.T) {
+	hub := events.NewHub(10, 1)
+
+	sub, _, _, err := hub.Subscribe("", all)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if _, _, _, err := hub.Subscribe("", all); !errors.Is(err, events.ErrTooManySubscribers) {
+		t.Fatalf("err = %v, want ErrTooManySubscribers", err)
+	}
+
+	hub.Unsubscribe(sub)
+	hub.Unsubscribe(sub)
+	if _, _, _, err := hub.Subscribe("", all); err != nil {
+		t.Fatalf("Subscribe after Unsubscribe: %v", err)
+	}
+}
+
+func TestHub_DropsSlowSubscribers(t *testing.T) {
+	hub := events.NewHub(0, 10)
+	sub, _, _, _ := hub.Subscribe("", all)
+
+	for range 100 {
+		hub.Publish(context.Background(), events.Event{ID: "x"})
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n == 0 || n == 100 {
+		t.Fatalf("received %d events before being dropped", n)
+	}
+}
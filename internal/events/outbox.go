@@ -0,0 +1,85 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a row of the outbox_events table. PublishedAt is set once
+// every sink accepted the event, DeadAt once the relay gave up on it.
+type OutboxEvent struct {
+	Sequence      uint64 `gorm:"primaryKey;autoIncrement"`
+	ID            string `gorm:"type:char(36);not null;uniqueIndex"`
+	Type          string `gorm:"type:varchar(64);not null"`
+	AggregateType string `gorm:"type:varchar(32);not null;index:idx_outbox_aggregate,priority:1"`
+	AggregateID   string `gorm:"type:char(36);not null;index:idx_outbox_aggregate,priority:2"`
+	Payload       string `gorm:"type:mediumtext;not null"`
+	// TraceContext carries the W3C trace headers of the writing request, so
+	// publishing continues its trace.
+	TraceContext  map[string]string `gorm:"type:text;serializer:json"`
+	OccurredAt    time.Time         `gorm:"not null"`
+	PublishedAt   *time.Time        `gorm:"index"`
+	Attempts      int               `gorm:"not null"`
+	NextAttemptAt time.Time         `gorm:"not null"`
+	LastError     string            `gorm:"type:text"`
+	DeadAt        *time.Time        `gorm:"index"`
+}
+
+func (row OutboxEvent) event() Event {
+	return Event{
+		ID:            row.ID,
+		Type:          row.Type,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Sequence:      row.Sequence,
+		OccurredAt:    row.OccurredAt,
+		Data:          []byte(row.Payload),
+	}
+}
+
+var errNotInTransaction = errors.New("events: Record must run inside a transaction")
+
+// Record adds events to the outbox. It must be called with the transaction
+// that writes the entity, so the events commit or roll back with it.
+func Record(tx *gorm.DB, events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if _, ok := tx.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return errNotInTransaction
+	}
+
+	ctx := tx.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	rows := make([]OutboxEvent, len(events))
+	for i, e := range events {
+		rows[i] = OutboxEvent{
+			ID:            e.ID,
+			Type:          e.Type,
+			AggregateType: e.AggregateType,
+			AggregateID:   e.AggregateID,
+			Payload:       string(e.Data),
+			TraceContext:  carrier,
+			OccurredAt:    e.OccurredAt,
+			NextAttemptAt: e.OccurredAt,
+		}
+	}
+
+	return tx.Create(&rows).Error
+}
+
+// PurgePublished deletes events published before cutoff.
+func PurgePublished(ctx context.Context, db *gorm.DB, cutoff time.Time) (int64, error) {
+	res := db.WithContext(ctx).Where("published_at < ?", cutoff).Delete(&OutboxEvent{})
+	return res.RowsAffected, res.Error
+}
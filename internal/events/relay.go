@@ -0,0 +1,259 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// relayLock is the MySQL named lock held by the relay that publishes. Only
+// one relay publishes at a time, which keeps the outbox order.
+const relayLock = "api-courses:outbox-relay"
+
+// gaugeInterval paces refreshing the outbox gauges.
+const gaugeInterval = 10 * time.Second
+
+type RelayConfig struct {
+	// Interval is how often the outbox is polled; zero disables the relay.
+	Interval time.Duration
+	// BatchSize is the number of events read per query.
+	BatchSize int
+	// MaxAttempts is the number of attempts after which a failing event is
+	// dead-lettered and stops holding back its aggregate; zero retries
+	// forever.
+	MaxAttempts int
+}
+
+// RelayConfigFromEnv reads EVENTS_RELAY_INTERVAL (default 500ms),
+// EVENTS_RELAY_BATCH (default 100) and EVENTS_RELAY_MAX_ATTEMPTS (default 10).
+func RelayConfigFromEnv() RelayConfig {
+	cfg := RelayConfig{
+		Interval:    getdur("EVENTS_RELAY_INTERVAL", 500*time.Millisecond),
+		BatchSize:   100,
+		MaxAttempts: 10,
+	}
+	if n, err := strconv.Atoi(os.Getenv("EVENTS_RELAY_BATCH")); err == nil && n > 0 {
+		cfg.BatchSize = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("EVENTS_RELAY_MAX_ATTEMPTS")); err == nil && n >= 0 {
+		cfg.MaxAttempts = n
+	}
+
+	return cfg
+}
+
+// Relay publishes outbox events to its sinks. Every API and worker process
+// may run one; a MySQL named lock elects the one that publishes.
+type Relay struct {
+	db    *gorm.DB
+	sinks []Sink
+}
+
+func NewRelay(db *gorm.DB, sinks ...Sink) *Relay {
+	return &Relay{db: db, sinks: sinks}
+}
+
+// Run polls the outbox until ctx is done.
+func (r *Relay) Run(ctx context.Context, cfg RelayConfig) {
+	if cfg.Interval <= 0 || len(r.sinks) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	var refreshed time.Time
+	for {
+		if err := r.Tick(ctx, cfg); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "outbox relay failed", "error", err)
+		}
+		if time.Since(refreshed) >= gaugeInterval {
+			if err := r.refreshGauges(ctx); err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "refresh outbox gauges failed", "error", err)
+			}
+			refreshed = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick publishes the pending events if this relay gets the lock.
+func (r *Relay) Tick(ctx context.Context, cfg RelayConfig) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	// The named lock belongs to a connection, so hold one for the whole tick.
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", relayLock).Scan(&locked); err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return nil
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", relayLock)
+	}()
+
+	for ctx.Err() == nil {
+		read, published, err := r.publishBatch(ctx, cfg)
+		if err != nil || read < cfg.BatchSize || published == 0 {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pendingEvents selects the unpublished, live events. notWaiting leaves out
+// the events of an aggregate from its first event waiting for a retry on, so
+// a held back aggregate does not fill the batch and stall the others.
+const (
+	pendingEvents = "published_at IS NULL AND dead_at IS NULL"
+	notWaiting    = "NOT EXISTS (SELECT 1 FROM outbox_events AS head" +
+		" WHERE head.aggregate_type = outbox_events.aggregate_type" +
+		" AND head.aggregate_id = outbox_events.aggregate_id" +
+		" AND head.sequence <= outbox_events.sequence" +
+		" AND head.published_at IS NULL AND head.dead_at IS NULL" +
+		" AND head.next_attempt_at > ?)"
+)
+
+// publishBatch publishes the oldest pending events in order. After a failure,
+// and while a failed event waits for its retry, the later events of the same
+// aggregate are held back. An event that fails MaxAttempts times is
+// dead-lettered: dead_at is set and its aggregate moves on.
+func (r *Relay) publishBatch(ctx context.Context, cfg RelayConfig) (read, published int, err error) {
+	var rows []OutboxEvent
+	err = r.db.WithContext(ctx).Clauses(dbresolver.Write).
+		Where(pendingEvents).
+		Where(notWaiting, time.Now()).
+		Order("sequence").
+		Limit(cfg.BatchSize).
+		Find(&rows).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	blocked := map[string]bool{}
+	for _, row := range rows {
+		aggregate := row.AggregateType + "/" + row.AggregateID
+		if blocked[aggregate] {
+			continue
+		}
+
+		now := time.Now()
+		if row.NextAttemptAt.After(now) {
+			blocked[aggregate] = true
+			continue
+		}
+
+		attempt := row.Attempts + 1
+		fields := map[string]any{"attempts": attempt}
+		if err := r.publish(ctx, row); err != nil {
+			fields["last_error"] = err.Error()
+			if cfg.MaxAttempts > 0 && attempt >= cfg.MaxAttempts {
+				fields["dead_at"] = now
+				metrics.OutboxDeadEventsTotal.Inc()
+				slog.ErrorContext(ctx, "event dead-lettered",
+					"event_id", row.ID, "event_type", row.Type, "attempt", attempt, "error", err)
+			} else {
+				blocked[aggregate] = true
+
+				retryAt := now.Add(jobs.Backoff(attempt))
+				fields["next_attempt_at"] = retryAt
+				slog.WarnContext(ctx, "publish event failed",
+					"event_id", row.ID, "event_type", row.Type, "attempt", attempt, "retry_at", retryAt, "error", err)
+			}
+		} else {
+			fields["published_at"], fields["last_error"] = now, ""
+			published++
+		}
+
+		err := r.db.WithContext(ctx).Model(&OutboxEvent{}).Where("sequence = ?", row.Sequence).Updates(fields).Error
+		if err != nil {
+			return len(rows), published, err
+		}
+	}
+
+	return len(rows), published, nil
+}
+
+// publish hands the event to every sink, in a span that continues the trace
+// of the request that recorded it.
+func (r *Relay) publish(ctx context.Context, row OutboxEvent) error {
+	parent := otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(row.TraceContext))
+	ctx, span := otel.Tracer("github.com/guycanella/api-courses-golang/internal/events").Start(parent, "event "+row.Type,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("event.id", row.ID),
+			attribute.String("event.type", row.Type),
+			attribute.String("event.aggregate_type", row.AggregateType),
+			attribute.String("event.aggregate_id", row.AggregateID),
+			attribute.Int("event.attempt", row.Attempts+1),
+		),
+	)
+	defer span.End()
+
+	e := row.event()
+	for _, sink := range r.sinks {
+		if err := sink.Publish(ctx, e); err != nil {
+			metrics.EventsPublishedTotal.WithLabelValues(sink.Name(), metrics.EventFailed).Inc()
+
+			err = fmt.Errorf("%s: %w", sink.Name(), err)
+			obs.SpanError(ctx, err)
+			return err
+		}
+		metrics.EventsPublishedTotal.WithLabelValues(sink.Name(), metrics.EventPublished).Inc()
+	}
+
+	return nil
+}
+
+func (r *Relay) refreshGauges(ctx context.Context) error {
+	var pending struct {
+		N      int64
+		Oldest *time.Time
+	}
+	err := r.db.WithContext(ctx).Clauses(dbresolver.Write).Model(&OutboxEvent{}).
+		Select("COUNT(*) AS n, MIN(occurred_at) AS oldest").
+		Where(pendingEvents).
+		Scan(&pending).Error
+	if err != nil {
+		return err
+	}
+
+	metrics.OutboxPendingGauge.Set(float64(pending.N))
+	lag := 0.0
+	if pending.Oldest != nil {
+		lag = time.Since(*pending.Oldest).Seconds()
+	}
+	metrics.OutboxLagSeconds.Set(lag)
+
+	return nil
+}
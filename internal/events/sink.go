@@ -0,0 +1,207 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Sink receives published events. Publish must be safe to repeat: an event is
+// published again when any sink fails.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e Event) error
+}
+
+// Bus is an in-process sink. Subscribers run synchronously in the relay and
+// must not block. Only the relay holding the lock publishes, so with several
+// API instances each event reaches the bus of one of them; use the redis sink
+// to fan out.
+type Bus struct {
+	mu   sync.RWMutex
+	next int
+	subs map[int]func(context.Context, Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: map[int]func(context.Context, Event){}}
+}
+
+// Subscribe calls fn with every published event until unsubscribe is called.
+func (b *Bus) Subscribe(fn func(context.Context, Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	b.subs[id] = fn
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+func (b *Bus) Name() string { return "bus" }
+
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, fn := range b.subs {
+		fn(ctx, e)
+	}
+
+	return nil
+}
+
+// HTTPSink POSTs each event as JSON to a fixed URL. The Idempotency-Key header
+// is the event id; any status outside 2xx is a failure.
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s *HTTPSink) Name() string { return "webhook" }
+
+func (s *HTTPSink) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", e.ID)
+	req.Header.Set("X-Event-Type", e.Type)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook responded %s", res.Status)
+	}
+
+	return nil
+}
+
+// RedisStreamSink appends each event to a Redis stream, trimmed to about
+// MaxLen entries. Consumers read it with consumer groups (XREADGROUP).
+type RedisStreamSink struct {
+	Client redis.UniversalClient
+	Stream string
+	MaxLen int64
+}
+
+func (s *RedisStreamSink) Name() string { return "redis" }
+
+func (s *RedisStreamSink) Publish(ctx context.Context, e Event) error {
+	return s.Client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.Stream,
+		MaxLen: s.MaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":             e.ID,
+			"type":           e.Type,
+			"aggregate_type": e.AggregateType,
+			"aggregate_id":   e.AggregateID,
+			"sequence":       e.Sequence,
+			"occurred_at":    e.OccurredAt.Format(time.RFC3339Nano),
+			"data":           string(e.Data),
+		},
+	}).Err()
+}
+
+// SinksFromEnv builds the sinks listed in EVENTS_SINKS (comma separated,
+// default "bus"):
+//
+//   - bus publishes to b.
+//   - webhook POSTs to EVENTS_WEBHOOK_URL with an EVENTS_WEBHOOK_TIMEOUT
+//     (default 5s).
+//   - redis appends to the EVENTS_REDIS_STREAM stream (default
+//     "api-courses:events", trimmed to EVENTS_REDIS_MAXLEN, default 100000)
+//     on EVENTS_REDIS_ADDR, falling back to CACHE_REDIS_ADDR.
+func SinksFromEnv(b *Bus) ([]Sink, error) {
+	names := os.Getenv("EVENTS_SINKS")
+	if names == "" {
+		names = "bus"
+	}
+
+	var sinks []Sink
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "bus":
+			sinks = append(sinks, b)
+		case "webhook":
+			url := os.Getenv("EVENTS_WEBHOOK_URL")
+			if url == "" {
+				return nil, fmt.Errorf("events: webhook sink needs EVENTS_WEBHOOK_URL")
+			}
+			sinks = append(sinks, &HTTPSink{
+				URL:    url,
+				Client: &http.Client{Timeout: getdur("EVENTS_WEBHOOK_TIMEOUT", 5*time.Second)},
+			})
+		case "redis":
+			addr := getenv("EVENTS_REDIS_ADDR", os.Getenv("CACHE_REDIS_ADDR"))
+			if addr == "" {
+				return nil, fmt.Errorf("events: redis sink needs EVENTS_REDIS_ADDR")
+			}
+			maxLen, err := strconv.ParseInt(getenv("EVENTS_REDIS_MAXLEN", "100000"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("events: EVENTS_REDIS_MAXLEN: %w", err)
+			}
+			sinks = append(sinks, &RedisStreamSink{
+				Client: redis.NewClient(&redis.Options{
+					Addr:     addr,
+					Password: getenv("EVENTS_REDIS_PASSWORD", os.Getenv("CACHE_REDIS_PASSWORD")),
+				}),
+				Stream: getenv("EVENTS_REDIS_STREAM", "api-courses:events"),
+				MaxLen: maxLen,
+			})
+		default:
+			return nil, fmt.Errorf("events: unknown sink %q", name)
+		}
+	}
+
+	return sinks, nil
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
+
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
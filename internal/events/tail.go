@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// tailBatch bounds the events read per poll.
+const tailBatch = 500
+
+// Tail follows the events published by whichever relay holds the lock, in
+// publish order, so every instance sees them. It only reports events
+// published after Run starts.
+type Tail struct {
+	db       *gorm.DB
+	interval time.Duration
+}
+
+func NewTail(db *gorm.DB, interval time.Duration) *Tail {
+	return &Tail{db: db, interval: interval}
+}
+
+// Run calls fn with every newly published event until ctx is done.
+func (t *Tail) Run(ctx context.Context, fn func(context.Context, Event)) {
+	since := time.Now()
+	// seen holds the events already reported at the since timestamp, since
+	// the next poll reads that timestamp again.
+	seen := map[string]bool{}
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var rows []OutboxEvent
+		err := t.db.WithContext(ctx).Clauses(dbresolver.Write).
+			Where("published_at >= ?", since).
+			Order("published_at, sequence").
+			Limit(tailBatch).
+			Find(&rows).Error
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "tail outbox failed", "error", err)
+			}
+			continue
+		}
+
+		for _, row := range rows {
+			if seen[row.ID] {
+				continue
+			}
+			if !row.PublishedAt.Equal(since) {
+				since, seen = *row.PublishedAt, map[string]bool{}
+			}
+			seen[row.ID] = true
+			fn(ctx, row.event())
+		}
+	}
+}
+
+type StreamConfig struct {
+	// Buffer is the number of events kept for resuming streams.
+	Buffer int
+	// MaxSubscribers caps the open streams per instance.
+	MaxSubscribers int
+	// Heartbeat is the interval of keep-alive comments.
+	Heartbeat time.Duration
+	// PollInterval paces the Tail feeding the streams.
+	PollInterval time.Duration
+}
+
+// StreamConfigFromEnv reads EVENTS_STREAM_BUFFER (default 1000),
+// EVENTS_STREAM_MAX_SUBSCRIBERS (100), EVENTS_STREAM_HEARTBEAT (15s) and
+// EVENTS_STREAM_POLL_INTERVAL (500ms).
+func StreamConfigFromEnv() StreamConfig {
+	cfg := StreamConfig{
+		Buffer:         1000,
+		MaxSubscribers: 100,
+		Heartbeat:      getdur("EVENTS_STREAM_HEARTBEAT", 15*time.Second),
+		PollInterval:   getdur("EVENTS_STREAM_POLL_INTERVAL", 500*time.Millisecond),
+	}
+	if n, err := strconv.Atoi(os.Getenv("EVENTS_STREAM_BUFFER")); err == nil && n >= 0 {
+		cfg.Buffer = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("EVENTS_STREAM_MAX_SUBSCRIBERS")); err == nil && n >= 0 {
+		cfg.MaxSubscribers = n
+	}
+
+	return cfg
+}
@@ -0,0 +1,119 @@
+package graph
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// defaultFirst is the page size of connections queried without first, as in
+// the schema. Larger values than maxFirst are rejected by the resolvers, so
+// they are counted as maxFirst; maxCost keeps the sum from overflowing.
+const (
+	defaultFirst = 10
+	maxFirst     = 100
+	maxCost      = 1 << 40
+)
+
+// Complexity returns the type of the selected operation and its estimated
+// cost: every field costs 1, and the selections under a field taking first
+// cost first times over, as that many nodes may be returned. Variables not
+// in vars take the default of their definition, as in Exec. Selections
+// deeper than maxDepth and fragment cycles are not counted; Exec rejects
+// them.
+func Complexity(query, operationName string, vars map[string]any, maxDepth int) (string, int, error) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		return "", 0, err
+	}
+
+	op := doc.Operations.ForName(operationName)
+	if op == nil {
+		return "", 0, errors.New("operation not found")
+	}
+
+	// Parsing does not link variables to their definitions, so their
+	// defaults are resolved here.
+	resolved := make(map[string]any, len(vars)+len(op.VariableDefinitions))
+	for _, def := range op.VariableDefinitions {
+		if def.DefaultValue == nil {
+			continue
+		}
+		if v, err := def.DefaultValue.Value(nil); err == nil {
+			resolved[def.Variable] = v
+		}
+	}
+	for name, v := range vars {
+		resolved[name] = v
+	}
+
+	c := complexity{fragments: doc.Fragments, vars: resolved, maxDepth: maxDepth, spreading: map[string]bool{}}
+	return string(op.Operation), c.selections(op.SelectionSet, 1), nil
+}
+
+type complexity struct {
+	fragments ast.FragmentDefinitionList
+	vars      map[string]any
+	maxDepth  int
+	spreading map[string]bool
+}
+
+func (c complexity) selections(set ast.SelectionSet, depth int) int {
+	if depth > c.maxDepth+1 {
+		return 0
+	}
+
+	cost := 0
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			cost += 1 + c.multiplier(sel)*c.selections(sel.SelectionSet, depth+1)
+		case *ast.InlineFragment:
+			cost += c.selections(sel.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			f := c.fragments.ForName(sel.Name)
+			if f == nil || c.spreading[f.Name] {
+				continue
+			}
+			c.spreading[f.Name] = true
+			cost += c.selections(f.SelectionSet, depth)
+			delete(c.spreading, f.Name)
+		}
+		cost = min(cost, maxCost)
+	}
+
+	return cost
+}
+
+// connections are the fields paginated with first.
+var connections = map[string]bool{"courses": true, "users": true, "enrollments": true}
+
+func (c complexity) multiplier(f *ast.Field) int {
+	arg := f.Arguments.ForName("first")
+	if arg == nil {
+		if connections[f.Name] {
+			return defaultFirst
+		}
+		return 1
+	}
+
+	v, err := arg.Value.Value(c.vars)
+	if err != nil {
+		return defaultFirst
+	}
+	n := defaultFirst
+	switch v := v.(type) {
+	case int64:
+		n = int(v)
+	case float64:
+		n = int(v)
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			n = i
+		}
+	}
+
+	return min(max(n, 1), maxFirst)
+}
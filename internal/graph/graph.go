@@ -0,0 +1,235 @@
+// Package graph serves the GraphQL API over courses, users and enrollments.
+//
+// Nested fields are resolved through per-request loaders that batch their
+// keys into one query per level, so a page of courses with their enrollments
+// and users costs a fixed number of queries. Queries are rejected before they
+// run when they nest deeper than Config.MaxDepth or their estimated cost
+// exceeds Config.MaxComplexity.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"go.opentelemetry.io/otel/attribute"
+	"gorm.io/gorm"
+)
+
+//go:embed schema.graphql
+var Schema string
+
+type Config struct {
+	// MaxDepth is how deeply selections may nest.
+	MaxDepth int
+	// MaxComplexity bounds the estimated number of objects a query returns;
+	// see Complexity.
+	MaxComplexity int
+	// MaxParallelism is how many fields resolve at once per request. It also
+	// bounds how many keys a loader collects before its wait expires.
+	MaxParallelism int
+	// BatchWait is how long a loader waits for more keys before fetching.
+	BatchWait time.Duration
+}
+
+// ConfigFromEnv reads GRAPHQL_MAX_DEPTH (default 10), GRAPHQL_MAX_COMPLEXITY
+// (default 5000), GRAPHQL_MAX_PARALLELISM (default 100) and GRAPHQL_BATCH_WAIT
+// (default 2ms).
+func ConfigFromEnv() Config {
+	return Config{
+		MaxDepth:       getint("GRAPHQL_MAX_DEPTH", 10),
+		MaxComplexity:  getint("GRAPHQL_MAX_COMPLEXITY", 5000),
+		MaxParallelism: getint("GRAPHQL_MAX_PARALLELISM", 100),
+		BatchWait:      getdur("GRAPHQL_BATCH_WAIT", 2*time.Millisecond),
+	}
+}
+
+type Server struct {
+	db     *gorm.DB
+	cache  *cache.Cache
+	cfg    Config
+	schema *graphql.Schema
+
+	courses     *service.CourseService
+	users       *service.UserService
+	enrollments *service.EnrollmentService
+}
+
+type Option func(*Server)
+
+// WithCache invalidates the course read cache of the REST API on writes.
+func WithCache(c *cache.Cache) Option {
+	return func(s *Server) { s.cache = c }
+}
+
+func New(db *gorm.DB, cfg Config, opts ...Option) *Server {
+	s := &Server{db: db, cfg: cfg}
+	for _, opt := range opts {
+		opt(s)
+	}
+	store := mysqlrepo.NewStore(db)
+	s.courses = service.NewCourseService(store, service.WithCache(s.cache))
+	s.users = service.NewUserService(store)
+	s.enrollments = service.NewEnrollmentService(store)
+
+	s.schema = graphql.MustParseSchema(Schema, &resolver{s: s},
+		graphql.MaxDepth(cfg.MaxDepth),
+		graphql.MaxParallelism(cfg.MaxParallelism),
+		graphql.UseStringDescriptions(),
+	)
+
+	return s
+}
+
+// Request is the body of a GraphQL POST.
+type Request struct {
+	Query         string         `json:"query"                   example:"{ courses(first: 5) { edges { node { id title } } } }"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Handler godoc
+// @Summary      GraphQL
+// @Description  Runs a GraphQL query or mutation against the schema in internal/graph/schema.graphql. GET takes query, operationName and variables (JSON) as query parameters and only runs queries. Users and enrollments need the users:read and enrollments:read scopes, their mutations users:write and enrollments:write; updateCourse needs courses:admin. Queries nesting deeper than GRAPHQL_MAX_DEPTH or costing more than GRAPHQL_MAX_COMPLEXITY are rejected. Resolver errors carry extensions.code.
+// @Tags         graphql
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      graph.Request  true  "GraphQL request"
+// @Success      200      {object}  object
+// @Failure      400      {object}  object
+// @Failure      401      {object}  handlers.ErrorResponse
+// @Failure      405      {object}  object
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /graphql [post]
+func (s *Server) Handler() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		var req Request
+		switch ctx.Method() {
+		case fiber.MethodGet:
+			req.Query = ctx.Query("query")
+			req.OperationName = ctx.Query("operationName")
+			if v := ctx.Query("variables"); v != "" {
+				if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+					return reject(ctx, fiber.StatusBadRequest, "invalid variables")
+				}
+			}
+		default:
+			if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+				return reject(ctx, fiber.StatusBadRequest, "invalid JSON body")
+			}
+		}
+		if req.Query == "" {
+			return reject(ctx, fiber.StatusBadRequest, "query is required")
+		}
+
+		// A query whose cost cannot be estimated is not run.
+		op, cost, err := Complexity(req.Query, req.OperationName, req.Variables, s.cfg.MaxDepth)
+		if err != nil {
+			metrics.GraphQLRequestsTotal.WithLabelValues(metrics.GraphQLRejected).Inc()
+			return reject(ctx, fiber.StatusBadRequest, err.Error())
+		}
+		if ctx.Method() == fiber.MethodGet && op != "query" {
+			return reject(ctx, fiber.StatusMethodNotAllowed, "only queries can be sent with GET")
+		}
+		if cost > s.cfg.MaxComplexity {
+			metrics.GraphQLRequestsTotal.WithLabelValues(metrics.GraphQLRejected).Inc()
+			return reject(ctx, fiber.StatusBadRequest,
+				"query complexity "+strconv.Itoa(cost)+" exceeds the maximum of "+strconv.Itoa(s.cfg.MaxComplexity))
+		}
+
+		name := "graphql"
+		if op != "" {
+			name += "." + op
+		}
+		span := obs.StartSpan(ctx, name,
+			attribute.String("graphql.operation.name", req.OperationName),
+			attribute.Int("graphql.complexity", cost),
+		)
+		defer span.End()
+		metrics.GraphQLComplexity.Observe(float64(cost))
+
+		rctx := withLoaders(ctx.UserContext(), s)
+		resp := s.schema.Exec(rctx, req.Query, req.OperationName, req.Variables)
+
+		outcome := metrics.GraphQLOK
+		if len(resp.Errors) > 0 {
+			outcome = metrics.GraphQLErrors
+		}
+		metrics.GraphQLRequestsTotal.WithLabelValues(outcome).Inc()
+
+		return ctx.JSON(resp)
+	}
+}
+
+func reject(ctx *fiber.Ctx, status int, msg string) error {
+	return ctx.Status(status).JSON(graphql.Response{
+		Errors: []*gqlerrors.QueryError{{Message: msg}},
+	})
+}
+
+// Error codes set as extensions.code on resolver errors.
+const (
+	CodeBadUserInput    = "BAD_USER_INPUT"
+	CodeUnauthenticated = "UNAUTHENTICATED"
+	CodeForbidden       = "FORBIDDEN"
+	CodeNotFound        = "NOT_FOUND"
+	CodeConflict        = "CONFLICT"
+	CodeInternal        = "INTERNAL"
+)
+
+// Error is a resolver error reported with a code and, for invalid input, the
+// message per field.
+type Error struct {
+	Message string
+	Code    string
+	Fields  map[string]string
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Extensions() map[string]any {
+	ext := map[string]any{"code": e.Code}
+	if e.Fields != nil {
+		ext["fields"] = e.Fields
+	}
+	return ext
+}
+
+// internalError logs err and hides it from the client.
+func internalError(ctx context.Context, err error) error {
+	var gqlErr *Error
+	if errors.As(err, &gqlErr) {
+		return err
+	}
+
+	obs.Logger(ctx).ErrorContext(ctx, "graphql internal error", "error", err)
+	obs.SpanError(ctx, err)
+	return &Error{Message: "internal server error", Code: CodeInternal}
+}
+
+func getint(k string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(k)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,292 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/graph"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+func setup(t *testing.T, db *gorm.DB) *fiber.App {
+	t.Helper()
+
+	tokens, err := auth.ParseTokens("dashboard=tok1=courses:read,enrollments:read,users:read;ops=tok2=courses:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	srv := graph.New(db, graph.Config{MaxDepth: 8, MaxComplexity: 500, MaxParallelism: 50, BatchWait: 5 * time.Millisecond})
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.Authenticate(tokens))
+	app.Post("/graphql", srv.Handler())
+	app.Get("/graphql", srv.Handler())
+
+	return app
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string         `json:"message"`
+		Extensions map[string]any `json:"extensions"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, app *fiber.App, token, query string) (int, response) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]any{"query": query})
+	req := httptest.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := app.Test(req, 5000)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	var out response
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestComplexity(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  int
+	}{
+		{"flat", `{ course(id: "x") { id title } }`, nil, 3},
+		{"connection", `{ courses(first: 5) { edges { node { id } } } }`, nil, 1 + 5*(1+1+1)},
+		{"default page", `{ courses { totalCount } }`, nil, 1 + 10*1},
+		{"variable", `query($n: Int) { courses(first: $n) { totalCount } }`, map[string]any{"n": float64(3)}, 1 + 3*1},
+		{"variable default", `query($n: Int = 100) { courses(first: $n) { totalCount } }`, nil, 1 + 100*1},
+		{"variable over default", `query($n: Int = 100) { courses(first: $n) { totalCount } }`, map[string]any{"n": float64(3)}, 1 + 3*1},
+		{"fragment", `{ courses(first: 2) { ...c } } fragment c on CourseConnection { totalCount }`, nil, 1 + 2*1},
+		{"cycle", `{ courses(first: 2) { ...a } } fragment a on CourseConnection { ...a totalCount }`, nil, 1 + 2*1},
+		{"capped", `{ courses(first: 100000) { totalCount } }`, nil, 1 + 100*1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, got, err := graph.Complexity(tc.query, "", tc.vars, 10)
+			if err != nil {
+				t.Fatalf("Complexity: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("complexity=%d want=%d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHandler_RejectsComplexQueries(t *testing.T) {
+	app := setup(t, nil)
+
+	status, out := post(t, app, "tok1", `{ courses(first: 100) { edges { node { enrollments(first: 100) { totalCount } } } } }`)
+	if status != http.StatusBadRequest || len(out.Errors) != 1 || !strings.Contains(out.Errors[0].Message, "complexity") {
+		t.Fatalf("status=%d errors=%+v", status, out.Errors)
+	}
+}
+
+func TestHandler_RejectsComplexQueriesThroughVariableDefaults(t *testing.T) {
+	app := setup(t, nil)
+
+	query := `query($n: Int = 100) { courses(first: $n) { edges { node { enrollments(first: $n) { totalCount } } } } }`
+	status, out := post(t, app, "tok1", query)
+	if status != http.StatusBadRequest || len(out.Errors) != 1 || !strings.Contains(out.Errors[0].Message, "complexity") {
+		t.Fatalf("status=%d errors=%+v", status, out.Errors)
+	}
+}
+
+func TestHandler_RejectsQueriesThatDoNotParse(t *testing.T) {
+	app := setup(t, nil)
+
+	status, out := post(t, app, "tok1", `{ courses(first: 1) { totalCount }`)
+	if status != http.StatusBadRequest || len(out.Errors) != 1 {
+		t.Fatalf("status=%d errors=%+v", status, out.Errors)
+	}
+}
+
+func TestHandler_RejectsDeepQueries(t *testing.T) {
+	app := setup(t, nil)
+
+	query := `{ course(id: "x") { enrollments(first: 1) { edges { node { course { enrollments(first: 1) { edges { node { id } } } } } } } } }`
+	_, out := post(t, app, "tok1", query)
+	if len(out.Errors) == 0 || !strings.Contains(out.Errors[0].Message, "depth") {
+		t.Fatalf("errors=%+v", out.Errors)
+	}
+}
+
+func TestHandler_ChecksScopes(t *testing.T) {
+	app := setup(t, nil)
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		code  string
+	}{
+		{"anonymous", "", graph.CodeUnauthenticated},
+		{"missing scope", "tok2", graph.CodeForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, out := post(t, app, tc.token, `{ users { totalCount } }`)
+			if len(out.Errors) != 1 || out.Errors[0].Extensions["code"] != tc.code {
+				t.Fatalf("errors=%+v", out.Errors)
+			}
+		})
+	}
+}
+
+func TestHandler_UpdateCourseNeedsCoursesAdmin(t *testing.T) {
+	app := setup(t, nil)
+
+	for _, tc := range []struct {
+		name  string
+		token string
+		code  string
+	}{
+		{"anonymous", "", graph.CodeUnauthenticated},
+		{"missing scope", "tok1", graph.CodeForbidden},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, out := post(t, app, tc.token, `mutation { updateCourse(id: "x", input: {title: "Go basics"}) { id } }`)
+			if len(out.Errors) != 1 || out.Errors[0].Extensions["code"] != tc.code {
+				t.Fatalf("errors=%+v", out.Errors)
+			}
+		})
+	}
+}
+
+func TestHandler_GetOnlyRunsQueries(t *testing.T) {
+	app := setup(t, nil)
+
+	q := url.QueryEscape(`mutation { createCourse(input: {title: "Go"}) { id } }`)
+	resp, err := app.Test(httptest.NewRequest("GET", "/graphql?query="+q, nil))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandler_ValidatesMutationInput(t *testing.T) {
+	app := setup(t, nil)
+
+	_, out := post(t, app, "", `mutation { createCourse(input: {title: "Go"}) { id } }`)
+	if len(out.Errors) != 1 || out.Errors[0].Extensions["code"] != graph.CodeBadUserInput {
+		t.Fatalf("errors=%+v", out.Errors)
+	}
+	if fields, _ := out.Errors[0].Extensions["fields"].(map[string]any); fields["title"] != "must be at least 3 characters" {
+		t.Fatalf("fields=%v", out.Errors[0].Extensions["fields"])
+	}
+}
+
+// TestHandler_BatchesNestedFields resolves three courses, their enrollments
+// and the enrolled users with one query per level.
+func TestHandler_BatchesNestedFields(t *testing.T) {
+	db, mock := openMockDB(t)
+	app := setup(t, db)
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT \\* FROM `courses` ORDER BY created_at DESC, id DESC LIMIT \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at"}).
+			AddRow("c1", "Go", "", now).
+			AddRow("c2", "Rust", "", now.Add(-time.Minute)).
+			AddRow("c3", "Zig", "", now.Add(-2*time.Minute)))
+	mock.ExpectQuery("ROW_NUMBER\\(\\) OVER \\(PARTITION BY course_id .*rn <= \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "course_id", "created_at", "rn"}).
+			AddRow("e1", "u1", "c1", now, 1).
+			AddRow("e2", "u2", "c1", now, 2).
+			AddRow("e3", "u1", "c2", now, 1).
+			AddRow("e4", "u3", "c1", now, 3))
+	// u3 is only enrolled beyond the first page.
+	mock.ExpectQuery("SELECT \\* FROM `users` WHERE id IN \\(\\?,\\?\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "name", "created_at"}).
+			AddRow("u1", "ana@example.com", "Ana", now).
+			AddRow("u2", "bia@example.com", "Bia", now))
+
+	status, out := post(t, app, "tok1", `{
+		courses(first: 3) {
+			edges { node { title enrollments(first: 2) {
+				edges { node { user { name } } }
+				pageInfo { hasNextPage }
+			} } }
+		}
+	}`)
+	if status != http.StatusOK || len(out.Errors) != 0 {
+		t.Fatalf("status=%d errors=%+v", status, out.Errors)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("queries: %v", err)
+	}
+
+	var data struct {
+		Courses struct {
+			Edges []struct {
+				Node struct {
+					Title       string
+					Enrollments struct {
+						Edges []struct {
+							Node struct{ User struct{ Name string } }
+						}
+						PageInfo struct{ HasNextPage bool }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(out.Data, &data); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	got := map[string][]string{}
+	next := map[string]bool{}
+	for _, c := range data.Courses.Edges {
+		for _, e := range c.Node.Enrollments.Edges {
+			got[c.Node.Title] = append(got[c.Node.Title], e.Node.User.Name)
+		}
+		next[c.Node.Title] = c.Node.Enrollments.PageInfo.HasNextPage
+	}
+	if strings.Join(got["Go"], ",") != "Ana,Bia" || strings.Join(got["Rust"], ",") != "Ana" || len(got["Zig"]) != 0 {
+		t.Fatalf("enrollments=%v", got)
+	}
+	if !next["Go"] || next["Rust"] || next["Zig"] {
+		t.Fatalf("hasNextPage=%v", next)
+	}
+}
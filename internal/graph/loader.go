@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+)
+
+// loader batches the keys requested within wait of each other, or up to max
+// keys, into one fetch and caches the results for the rest of the request.
+// Keys missing from the fetched map resolve to the zero value.
+type loader[K comparable, V any] struct {
+	name  string
+	ctx   context.Context
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+	wait  time.Duration
+	max   int
+
+	mu      sync.Mutex
+	results map[K]*result[V]
+	pending []K
+	timer   *time.Timer
+}
+
+type result[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+func newLoader[K comparable, V any](ctx context.Context, name string, wait time.Duration, max int, fetch func(context.Context, []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		name:    name,
+		ctx:     ctx,
+		fetch:   fetch,
+		wait:    wait,
+		max:     max,
+		results: map[K]*result[V]{},
+	}
+}
+
+// Load returns the value of key, fetching it with the keys loaded alongside.
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	r, ok := l.results[key]
+	if !ok {
+		r = &result[V]{done: make(chan struct{})}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+
+		switch {
+		case len(l.pending) >= l.max:
+			l.dispatchLocked()
+		case l.timer == nil:
+			l.timer = time.AfterFunc(l.wait, l.dispatch)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (l *loader[K, V]) dispatch() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.dispatchLocked()
+}
+
+// dispatchLocked fetches the pending keys in the background. l.mu is held.
+func (l *loader[K, V]) dispatchLocked() {
+	if l.timer != nil {
+		l.timer.Stop()
+		l.timer = nil
+	}
+	if len(l.pending) == 0 {
+		return
+	}
+
+	keys := l.pending
+	l.pending = nil
+	batch := make([]*result[V], len(keys))
+	for i, k := range keys {
+		batch[i] = l.results[k]
+	}
+
+	go func() {
+		metrics.GraphQLBatchSize.WithLabelValues(l.name).Observe(float64(len(keys)))
+		values, err := l.fetch(l.ctx, keys)
+		for i, k := range keys {
+			batch[i].value, batch[i].err = values[k], err
+			close(batch[i].done)
+		}
+	}()
+}
@@ -0,0 +1,207 @@
+package graph
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxBatch keeps IN lists short.
+const maxBatch = 500
+
+// Columns enrollments are listed by.
+const (
+	byCourse = "course_id"
+	byUser   = "user_id"
+)
+
+// pageKey names a page of the enrollments of one course or user.
+type pageKey struct {
+	by     string
+	parent string
+	first  int
+	after  string
+}
+
+type countKey struct {
+	by     string
+	parent string
+}
+
+type enrollmentPage struct {
+	rows    []domain.Enrollment
+	hasNext bool
+}
+
+type loaders struct {
+	courses          *loader[string, *domain.Course]
+	users            *loader[string, *domain.User]
+	enrollmentPages  *loader[pageKey, enrollmentPage]
+	enrollmentCounts *loader[countKey, int64]
+}
+
+type loadersKey struct{}
+
+// withLoaders returns ctx carrying a fresh set of loaders, so results are
+// only shared within one request.
+func withLoaders(ctx context.Context, s *Server) context.Context {
+	wait := s.cfg.BatchWait
+	l := &loaders{
+		courses:          newLoader(ctx, "courses", wait, maxBatch, s.fetchCourses),
+		users:            newLoader(ctx, "users", wait, maxBatch, s.fetchUsers),
+		enrollmentPages:  newLoader(ctx, "enrollment_pages", wait, maxBatch, s.fetchEnrollmentPages),
+		enrollmentCounts: newLoader(ctx, "enrollment_counts", wait, maxBatch, s.fetchEnrollmentCounts),
+	}
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func (s *Server) fetchCourses(ctx context.Context, ids []string) (map[string]*domain.Course, error) {
+	var list []domain.Course
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*domain.Course, len(list))
+	for i := range list {
+		out[list[i].ID] = &list[i]
+	}
+	return out, nil
+}
+
+func (s *Server) fetchUsers(ctx context.Context, ids []string) (map[string]*domain.User, error) {
+	var list []domain.User
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]*domain.User, len(list))
+	for i := range list {
+		out[list[i].ID] = &list[i]
+	}
+	return out, nil
+}
+
+// fetchEnrollmentPages runs one query per distinct (by, first, after), taking
+// the first rows of every parent with ROW_NUMBER.
+func (s *Server) fetchEnrollmentPages(ctx context.Context, keys []pageKey) (map[pageKey]enrollmentPage, error) {
+	groups := map[pageKey][]string{}
+	for _, k := range keys {
+		g := k
+		g.parent = ""
+		groups[g] = append(groups[g], k.parent)
+	}
+
+	out := make(map[pageKey]enrollmentPage, len(keys))
+	for g, parents := range groups {
+		ranked := s.db.WithContext(ctx).Model(&domain.Enrollment{}).
+			Select("enrollments.*, ROW_NUMBER() OVER (PARTITION BY "+g.by+" ORDER BY created_at DESC, id DESC) AS rn").
+			Where(g.by+" IN ?", parents)
+		if g.after != "" {
+			c, err := decodeCursor(g.after)
+			if err != nil {
+				return nil, err
+			}
+			ranked = ranked.Where(before(c))
+		}
+
+		var rows []domain.Enrollment
+		err := s.db.WithContext(ctx).Table("(?) AS ranked", ranked).
+			Where("rn <= ?", g.first+1).
+			Order("created_at DESC, id DESC").
+			Find(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			k := g
+			k.parent = row.CourseID
+			if g.by == byUser {
+				k.parent = row.UserID
+			}
+
+			page := out[k]
+			if len(page.rows) < g.first {
+				page.rows = append(page.rows, row)
+			} else {
+				page.hasNext = true
+			}
+			out[k] = page
+		}
+	}
+
+	return out, nil
+}
+
+func (s *Server) fetchEnrollmentCounts(ctx context.Context, keys []countKey) (map[countKey]int64, error) {
+	groups := map[string][]string{}
+	for _, k := range keys {
+		groups[k.by] = append(groups[k.by], k.parent)
+	}
+
+	out := make(map[countKey]int64, len(keys))
+	for by, parents := range groups {
+		var rows []struct {
+			Parent string
+			N      int64
+		}
+		err := s.db.WithContext(ctx).Model(&domain.Enrollment{}).
+			Select(by+" AS parent, COUNT(*) AS n").
+			Where(by+" IN ?", parents).
+			Group(by).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, row := range rows {
+			out[countKey{by: by, parent: row.Parent}] = row.N
+		}
+	}
+
+	return out, nil
+}
+
+// cursor is the position of a row in created_at DESC, id DESC order.
+type cursor struct {
+	createdAt time.Time
+	id        string
+}
+
+func encodeCursor(createdAt time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(createdAt.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+var errBadCursor = &Error{Message: "invalid cursor", Code: CodeBadUserInput}
+
+func decodeCursor(s string) (cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, errBadCursor
+	}
+
+	ts, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return cursor{}, errBadCursor
+	}
+	t, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return cursor{}, errBadCursor
+	}
+
+	return cursor{createdAt: t, id: id}, nil
+}
+
+// before is the condition for rows after c in created_at DESC, id DESC order.
+func before(c cursor) clause.Expr {
+	return gorm.Expr("created_at < ? OR (created_at = ? AND id < ?)", c.createdAt, c.createdAt, c.id)
+}
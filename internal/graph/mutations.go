@@ -0,0 +1,137 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/service"
+)
+
+// serviceError maps the errors of the service layer to resolver errors.
+func serviceError(ctx context.Context, err error) error {
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return &Error{Message: "invalid input", Code: CodeBadUserInput, Fields: invalid.Fields}
+	case errors.Is(err, service.ErrNotFound):
+		return &Error{Message: err.Error(), Code: CodeNotFound}
+	case errors.Is(err, service.ErrConflict):
+		return &Error{Message: err.Error(), Code: CodeConflict}
+	}
+	return internalError(ctx, err)
+}
+
+func (r *resolver) CreateCourse(ctx context.Context, args struct {
+	Input struct {
+		Title       string
+		Description *string
+	}
+}) (*courseResolver, error) {
+	course, err := r.s.courses.Create(ctx, service.CourseInput{
+		Title:       args.Input.Title,
+		Description: deref(args.Input.Description),
+	})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	return &courseResolver{&course}, nil
+}
+
+func (r *resolver) UpdateCourse(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Title       *string
+		Description *string
+	}
+}) (*courseResolver, error) {
+	if err := require(ctx, auth.ScopeCoursesAdmin); err != nil {
+		return nil, err
+	}
+
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	course, err := r.s.courses.Update(ctx, id, service.CourseUpdate{
+		Title:       args.Input.Title,
+		Description: args.Input.Description,
+	})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	return &courseResolver{&course}, nil
+}
+
+func (r *resolver) CreateUser(ctx context.Context, args struct {
+	Input struct {
+		Email string
+		Name  string
+	}
+}) (*userResolver, error) {
+	if err := require(ctx, auth.ScopeUsersWrite); err != nil {
+		return nil, err
+	}
+
+	user, err := r.s.users.Create(ctx, service.UserInput{Email: args.Input.Email, Name: args.Input.Name})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	return &userResolver{&user}, nil
+}
+
+func (r *resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Email *string
+		Name  *string
+	}
+}) (*userResolver, error) {
+	if err := require(ctx, auth.ScopeUsersWrite); err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.s.users.Update(ctx, id, service.UserUpdate{Email: args.Input.Email, Name: args.Input.Name})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	return &userResolver{&user}, nil
+}
+
+func (r *resolver) CreateEnrollment(ctx context.Context, args struct {
+	Input struct {
+		UserID   graphql.ID
+		CourseID graphql.ID
+	}
+}) (*enrollmentResolver, error) {
+	if err := require(ctx, auth.ScopeEnrollmentsWrite); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := r.s.enrollments.Create(ctx, service.EnrollmentInput{
+		UserID:   string(args.Input.UserID),
+		CourseID: string(args.Input.CourseID),
+	})
+	if err != nil {
+		return nil, serviceError(ctx, err)
+	}
+
+	return &enrollmentResolver{&enrollment}, nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
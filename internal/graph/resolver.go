@@ -0,0 +1,212 @@
+package graph
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"gorm.io/gorm"
+)
+
+// resolver is the Query and Mutation root.
+type resolver struct {
+	s *Server
+}
+
+type pageArgs struct {
+	First int32
+	After *string
+}
+
+// page validates first and after.
+func (a pageArgs) page() (int, *cursor, error) {
+	first := int(a.First)
+	if first < 1 || first > maxFirst {
+		return 0, nil, &Error{Message: "first must be between 1 and 100", Code: CodeBadUserInput}
+	}
+
+	if a.After == nil || *a.After == "" {
+		return first, nil, nil
+	}
+	c, err := decodeCursor(*a.After)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return first, &c, nil
+}
+
+func (a pageArgs) after() string {
+	if a.After == nil {
+		return ""
+	}
+	return *a.After
+}
+
+func require(ctx context.Context, scope string) error {
+	p, ok := auth.FromContext(ctx)
+	if !ok {
+		return &Error{Message: "authentication required", Code: CodeUnauthenticated}
+	}
+	if !p.Has(scope) {
+		return &Error{Message: "missing scope " + scope, Code: CodeForbidden}
+	}
+	return nil
+}
+
+func parseID(id graphql.ID) (string, error) {
+	if _, err := uuid.Parse(string(id)); err != nil {
+		return "", &Error{Message: "invalid id", Code: CodeBadUserInput}
+	}
+	return string(id), nil
+}
+
+func (r *resolver) Course(ctx context.Context, args struct{ ID graphql.ID }) (*courseResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	course, err := loadersFrom(ctx).courses.Load(ctx, id)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if course == nil {
+		return nil, nil
+	}
+
+	return &courseResolver{course}, nil
+}
+
+func (r *resolver) Courses(ctx context.Context, args struct {
+	pageArgs
+	Search *string
+}) (*connection[*courseResolver], error) {
+	first, after, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+
+	scope := func() *gorm.DB {
+		tx := r.s.db.WithContext(ctx).Model(&domain.Course{})
+		if args.Search != nil {
+			if q := strings.TrimSpace(*args.Search); q != "" {
+				tx = tx.Where("title LIKE ?", "%"+q+"%")
+			}
+		}
+		return tx
+	}
+
+	var list []domain.Course
+	if err := keyset(scope(), first, after).Find(&list).Error; err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	conn := &connection[*courseResolver]{count: count(scope)}
+	for i := range list {
+		if i == first {
+			conn.hasNext = true
+			break
+		}
+		conn.edges = append(conn.edges, &edge[*courseResolver]{
+			cursor: encodeCursor(list[i].CreatedAt, list[i].ID),
+			node:   &courseResolver{&list[i]},
+		})
+	}
+
+	return conn, nil
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	if err := require(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := loadersFrom(ctx).users.Load(ctx, id)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if user == nil {
+		return nil, nil
+	}
+
+	return &userResolver{user}, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args pageArgs) (*connection[*userResolver], error) {
+	if err := require(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+	first, after, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+
+	scope := func() *gorm.DB { return r.s.db.WithContext(ctx).Model(&domain.User{}) }
+
+	var list []domain.User
+	if err := keyset(scope(), first, after).Find(&list).Error; err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	conn := &connection[*userResolver]{count: count(scope)}
+	for i := range list {
+		if i == first {
+			conn.hasNext = true
+			break
+		}
+		conn.edges = append(conn.edges, &edge[*userResolver]{
+			cursor: encodeCursor(list[i].CreatedAt, list[i].ID),
+			node:   &userResolver{&list[i]},
+		})
+	}
+
+	return conn, nil
+}
+
+func (r *resolver) Enrollment(ctx context.Context, args struct{ ID graphql.ID }) (*enrollmentResolver, error) {
+	if err := require(ctx, auth.ScopeEnrollmentsRead); err != nil {
+		return nil, err
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var enrollment domain.Enrollment
+	err = r.s.db.WithContext(ctx).Where("id = ?", id).Limit(1).Find(&enrollment).Error
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if enrollment.ID == "" {
+		return nil, nil
+	}
+
+	return &enrollmentResolver{&enrollment}, nil
+}
+
+// keyset orders tx newest first and takes the first+1 rows after c, the
+// extra one telling whether there is a next page.
+func keyset(tx *gorm.DB, first int, c *cursor) *gorm.DB {
+	if c != nil {
+		tx = tx.Where(before(*c))
+	}
+	return tx.Order("created_at DESC, id DESC").Limit(first + 1)
+}
+
+// count returns a lazy count of the rows in scope, run only when totalCount
+// is selected.
+func count(scope func() *gorm.DB) func(context.Context) (int64, error) {
+	return func(context.Context) (int64, error) {
+		var n int64
+		err := scope().Count(&n).Error
+		return n, err
+	}
+}
@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"context"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+)
+
+type courseResolver struct{ c *domain.Course }
+
+func (r *courseResolver) ID() graphql.ID          { return graphql.ID(r.c.ID) }
+func (r *courseResolver) Title() string           { return r.c.Title }
+func (r *courseResolver) Description() string     { return r.c.Description }
+func (r *courseResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.c.CreatedAt} }
+
+func (r *courseResolver) Enrollments(ctx context.Context, args pageArgs) (*connection[*enrollmentResolver], error) {
+	return enrollments(ctx, byCourse, r.c.ID, args)
+}
+
+type userResolver struct{ u *domain.User }
+
+func (r *userResolver) ID() graphql.ID          { return graphql.ID(r.u.ID) }
+func (r *userResolver) Email() string           { return r.u.Email }
+func (r *userResolver) Name() string            { return r.u.Name }
+func (r *userResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.u.CreatedAt} }
+
+func (r *userResolver) Enrollments(ctx context.Context, args pageArgs) (*connection[*enrollmentResolver], error) {
+	return enrollments(ctx, byUser, r.u.ID, args)
+}
+
+type enrollmentResolver struct{ e *domain.Enrollment }
+
+func (r *enrollmentResolver) ID() graphql.ID          { return graphql.ID(r.e.ID) }
+func (r *enrollmentResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.e.CreatedAt} }
+
+func (r *enrollmentResolver) Course(ctx context.Context) (*courseResolver, error) {
+	course, err := loadersFrom(ctx).courses.Load(ctx, r.e.CourseID)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if course == nil {
+		return nil, &Error{Message: "course not found", Code: CodeNotFound}
+	}
+
+	return &courseResolver{course}, nil
+}
+
+func (r *enrollmentResolver) User(ctx context.Context) (*userResolver, error) {
+	if err := require(ctx, auth.ScopeUsersRead); err != nil {
+		return nil, err
+	}
+
+	user, err := loadersFrom(ctx).users.Load(ctx, r.e.UserID)
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+	if user == nil {
+		return nil, &Error{Message: "user not found", Code: CodeNotFound}
+	}
+
+	return &userResolver{user}, nil
+}
+
+// enrollments returns a page of the enrollments of the course or user parent
+// through the request loaders.
+func enrollments(ctx context.Context, by, parent string, args pageArgs) (*connection[*enrollmentResolver], error) {
+	if err := require(ctx, auth.ScopeEnrollmentsRead); err != nil {
+		return nil, err
+	}
+	first, _, err := args.page()
+	if err != nil {
+		return nil, err
+	}
+
+	l := loadersFrom(ctx)
+	page, err := l.enrollmentPages.Load(ctx, pageKey{by: by, parent: parent, first: first, after: args.after()})
+	if err != nil {
+		return nil, internalError(ctx, err)
+	}
+
+	conn := &connection[*enrollmentResolver]{
+		hasNext: page.hasNext,
+		count: func(ctx context.Context) (int64, error) {
+			return l.enrollmentCounts.Load(ctx, countKey{by: by, parent: parent})
+		},
+	}
+	for i := range page.rows {
+		conn.edges = append(conn.edges, &edge[*enrollmentResolver]{
+			cursor: encodeCursor(page.rows[i].CreatedAt, page.rows[i].ID),
+			node:   &enrollmentResolver{&page.rows[i]},
+		})
+	}
+
+	return conn, nil
+}
+
+// connection is a page of nodes in the Relay cursor connection shape.
+type connection[N any] struct {
+	edges   []*edge[N]
+	hasNext bool
+	count   func(context.Context) (int64, error)
+}
+
+func (c *connection[N]) Edges() []*edge[N] { return c.edges }
+
+func (c *connection[N]) PageInfo() *pageInfo {
+	info := &pageInfo{hasNext: c.hasNext}
+	if len(c.edges) > 0 {
+		info.endCursor = &c.edges[len(c.edges)-1].cursor
+	}
+	return info
+}
+
+func (c *connection[N]) TotalCount(ctx context.Context) (int32, error) {
+	n, err := c.count(ctx)
+	if err != nil {
+		return 0, internalError(ctx, err)
+	}
+	return int32(n), nil
+}
+
+type edge[N any] struct {
+	cursor string
+	node   N
+}
+
+func (e *edge[N]) Cursor() string { return e.cursor }
+func (e *edge[N]) Node() N        { return e.node }
+
+type pageInfo struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (p *pageInfo) HasNextPage() bool  { return p.hasNext }
+func (p *pageInfo) EndCursor() *string { return p.endCursor }
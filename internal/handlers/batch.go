@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/httpx"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type BatchCreateCoursesDTO struct {
+	Mode  service.BatchMode `json:"mode" example:"atomic" enums:"atomic,best_effort"`
+	Items []CreateCourseDTO `json:"items"`
+}
+
+type BatchGetCoursesDTO struct {
+	Mode service.BatchMode `json:"mode" example:"best_effort" enums:"atomic,best_effort"`
+	IDs  []string          `json:"ids" example:"4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"`
+}
+
+type BatchEnrollDTO struct {
+	Mode    service.BatchMode `json:"mode" example:"atomic" enums:"atomic,best_effort"`
+	UserIDs []string          `json:"userIds" example:"9b2f4c1e-8d6a-4b3e-a1f0-5c7d9e2b4a61"`
+}
+
+// BatchItemResult is the outcome of one item, with the status and body the
+// single endpoint would answer. Items of a failed atomic batch that were
+// valid on their own get 424.
+type BatchItemResult struct {
+	Index      int                `json:"index" example:"0"`
+	Status     int                `json:"status" example:"201"`
+	CourseID   string             `json:"courseId,omitempty" example:"4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"`
+	Course     *domain.Course     `json:"course,omitempty"`
+	Enrollment *domain.Enrollment `json:"enrollment,omitempty"`
+	Error      string             `json:"error,omitempty" example:"title already exists"`
+	Errors     map[string]string  `json:"errors,omitempty"`
+}
+
+type BatchResponse struct {
+	Mode      service.BatchMode `json:"mode" example:"atomic"`
+	Succeeded int               `json:"succeeded" example:"2"`
+	Failed    int               `json:"failed" example:"0"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// BatchCreateCourses godoc
+// @Summary      Create courses in a batch
+// @Description  Creates up to 100 courses. In atomic mode (the default) all are created in one transaction or none; in best_effort mode each is created on its own.
+// @Description  Each result carries the status the single create would answer. An atomic batch that fails answers with the status of its first failing item.
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      handlers.BatchCreateCoursesDTO  true  "Mode and new courses"
+// @Success      200      {object}  handlers.BatchResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      409      {object}  handlers.BatchResponse
+// @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Router       /v1/courses:batchCreate [post]
+func (handler *CoursesHandler) BatchCreateCourses(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.batch_create")
+	defer span.End()
+
+	var body BatchCreateCoursesDTO
+	if err := json.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+	span.SetAttributes(attribute.String("batch.mode", string(body.Mode)), attribute.Int("batch.size", len(body.Items)))
+
+	inputs := make([]service.CourseInput, len(body.Items))
+	for i, item := range body.Items {
+		inputs[i] = service.CourseInput{Title: item.Title, Description: item.Description}
+	}
+
+	results, err := handler.courses.CreateBatch(ctx.UserContext(), body.Mode, inputs)
+	if err != nil {
+		return batchError(ctx, err)
+	}
+
+	items := make([]BatchItemResult, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			items[i] = batchItemError(ctx, i, result.Err)
+			continue
+		}
+		items[i] = BatchItemResult{Index: i, Status: fiber.StatusCreated, CourseID: result.Value.ID}
+	}
+	return sendBatch(ctx, body.Mode, items)
+}
+
+// BatchGetCourses godoc
+// @Summary      Get courses in a batch
+// @Description  Reads up to 100 courses by ID. In atomic mode (the default) no course is returned unless all exist; in best_effort mode each is read on its own.
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      handlers.BatchGetCoursesDTO  true  "Mode and course IDs"
+// @Success      200      {object}  handlers.BatchResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      404      {object}  handlers.BatchResponse
+// @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Router       /v1/courses:batchGet [post]
+func (handler *CoursesHandler) BatchGetCourses(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.batch_get")
+	defer span.End()
+
+	var body BatchGetCoursesDTO
+	if err := json.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+	span.SetAttributes(attribute.String("batch.mode", string(body.Mode)), attribute.Int("batch.size", len(body.IDs)))
+
+	results, err := handler.courses.GetBatch(ctx.UserContext(), body.Mode, body.IDs)
+	if err != nil {
+		return batchError(ctx, err)
+	}
+
+	items := make([]BatchItemResult, len(results))
+	for i, result := range results {
+		var invalid *service.ValidationError
+		switch {
+		case errors.As(result.Err, &invalid):
+			items[i] = BatchItemResult{Index: i, Status: fiber.StatusBadRequest, Error: "invalid courseId"}
+		case result.Err != nil:
+			items[i] = batchItemError(ctx, i, result.Err)
+		default:
+			items[i] = BatchItemResult{Index: i, Status: fiber.StatusOK, Course: &result.Value}
+		}
+	}
+	return sendBatch(ctx, body.Mode, items)
+}
+
+// BatchEnroll godoc
+// @Summary      Enroll users in a course in a batch
+// @Description  Enrolls up to 100 users in the course. In atomic mode (the default) all are enrolled in one transaction or none; in best_effort mode each is enrolled on its own.
+// @Description  Users already enrolled get 409 and missing users 404. Requires the enrollments:write scope.
+// @Tags         enrollments
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        courseId  path      string                   true  "Course ID"  format(uuid)
+// @Param        payload   body      handlers.BatchEnrollDTO  true  "Mode and user IDs"
+// @Success      200       {object}  handlers.BatchResponse
+// @Failure      400       {object}  handlers.ErrorResponse
+// @Failure      401       {object}  handlers.ErrorResponse
+// @Failure      403       {object}  handlers.ErrorResponse
+// @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      409       {object}  handlers.BatchResponse
+// @Failure      422       {object}  handlers.ValidationErrorResponse
+// @Failure      429       {object}  handlers.ErrorResponse
+// @Failure      500       {object}  handlers.ErrorResponse
+// @Router       /v1/courses/{courseId}/enrollments:batch [post]
+func (handler *CoursesHandler) BatchEnroll(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "enrollments.batch_create", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	if _, err := uuid.Parse(courseId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid courseId",
+		})
+	}
+
+	var body BatchEnrollDTO
+	if err := json.Unmarshal(ctx.Body(), &body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+	span.SetAttributes(attribute.String("batch.mode", string(body.Mode)), attribute.Int("batch.size", len(body.UserIDs)))
+
+	if _, err := handler.courses.Get(ctx.UserContext(), courseId); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "course not found",
+			})
+		}
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	results, err := handler.enrollments.EnrollBatch(ctx.UserContext(), body.Mode, courseId, body.UserIDs)
+	if err != nil {
+		return batchError(ctx, err)
+	}
+
+	items := make([]BatchItemResult, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			items[i] = batchItemError(ctx, i, result.Err)
+			continue
+		}
+		items[i] = BatchItemResult{Index: i, Status: fiber.StatusCreated, Enrollment: &result.Value}
+	}
+	return sendBatch(ctx, body.Mode, items)
+}
+
+// batchError answers for a batch rejected as a whole.
+func batchError(ctx *fiber.Ctx, err error) error {
+	var invalid *service.ValidationError
+	if errors.As(err, &invalid) {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"errors": invalid.Fields,
+		})
+	}
+	return httpx.InternalServerError(ctx, err)
+}
+
+// batchItemError maps the error of item i like the single endpoints do.
+// Unexpected errors are logged and hidden.
+func batchItemError(ctx *fiber.Ctx, i int, err error) BatchItemResult {
+	item := BatchItemResult{Index: i, Error: err.Error()}
+
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		item.Status, item.Error, item.Errors = fiber.StatusUnprocessableEntity, "", invalid.Fields
+	case errors.Is(err, service.ErrConflict):
+		item.Status = fiber.StatusConflict
+	case errors.Is(err, service.ErrNotFound):
+		item.Status = fiber.StatusNotFound
+	case errors.Is(err, service.ErrNotApplied):
+		item.Status = fiber.StatusFailedDependency
+	default:
+		obs.Logger(ctx.UserContext()).ErrorContext(ctx.UserContext(), "batch item failed", "index", i, "error", err)
+		item.Status, item.Error = fiber.StatusInternalServerError, "internal server error"
+	}
+	return item
+}
+
+// sendBatch answers 200 unless an atomic batch failed, which answers with
+// the status of its first failing item.
+func sendBatch(ctx *fiber.Ctx, mode service.BatchMode, items []BatchItemResult) error {
+	if mode == "" {
+		mode = service.BatchAtomic
+	}
+
+	resp := BatchResponse{Mode: mode, Results: items}
+	status := fiber.StatusOK
+	for _, item := range items {
+		if item.Status >= 400 {
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		if mode == service.BatchAtomic && status == fiber.StatusOK && item.Status >= 400 && item.Status != fiber.StatusFailedDependency {
+			status = item.Status
+		}
+	}
+	return ctx.Status(status).JSON(resp)
+}
@@ -0,0 +1,169 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+)
+
+const batchUserID = "9b2f4c1e-8d6a-4b3e-a1f0-5c7d9e2b4a61"
+
+func setupBatch(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Post("/courses\\:batchCreate", h.BatchCreateCourses)
+	app.Post("/courses\\:batchGet", h.BatchGetCourses)
+	app.Post("/courses/:courseId/enrollments\\:batch", h.BatchEnroll)
+
+	return app, mock
+}
+
+func postBatch(t *testing.T, app *fiber.App, path, body string, wantStatus int) handlers.BatchResponse {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	_, raw := send(t, app, req, wantStatus)
+
+	var out handlers.BatchResponse
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, raw)
+	}
+	return out
+}
+
+func statuses(resp handlers.BatchResponse) []int {
+	out := make([]int, len(resp.Results))
+	for i, item := range resp.Results {
+		out[i] = item.Status
+	}
+	return out
+}
+
+func TestBatchCreateCourses_AtomicRollsBackOnDuplicate(t *testing.T) {
+	app, mock := setupBatch(t)
+	mock.ExpectBegin()
+	expectFreeSlug(mock)
+	mock.ExpectExec("INSERT INTO `courses`").
+		WithArgs(sqlmock.AnyArg(), "Go advanced", "go-advanced", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectFreeSlug(mock)
+	mock.ExpectExec("INSERT INTO `courses`").
+		WithArgs(sqlmock.AnyArg(), "Go basics", "go-basics", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	resp := postBatch(t, app, "/courses:batchCreate",
+		`{"items":[{"title":"Go advanced"},{"title":"Go basics"}]}`, http.StatusConflict)
+
+	if resp.Mode != "atomic" || resp.Succeeded != 0 || resp.Failed != 2 {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	if got := statuses(resp); got[0] != http.StatusFailedDependency || got[1] != http.StatusConflict {
+		t.Fatalf("statuses=%v", got)
+	}
+	if resp.Results[1].Error != "title already exists" || resp.Results[0].CourseID != "" {
+		t.Fatalf("Unexpected results: %+v", resp.Results)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestBatchCreateCourses_BestEffortReportsEachItem(t *testing.T) {
+	app, mock := setupBatch(t)
+	mock.ExpectBegin()
+	expectFreeSlug(mock)
+	mock.ExpectExec("INSERT INTO `courses`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	resp := postBatch(t, app, "/courses:batchCreate",
+		`{"mode":"best_effort","items":[{"title":"Go basics"},{"title":"Go"}]}`, http.StatusOK)
+
+	if resp.Succeeded != 1 || resp.Failed != 1 {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	if got := statuses(resp); got[0] != http.StatusCreated || got[1] != http.StatusUnprocessableEntity {
+		t.Fatalf("statuses=%v", got)
+	}
+	if resp.Results[0].CourseID == "" || resp.Results[1].Errors["title"] != "must be at least 3 characters" {
+		t.Fatalf("Unexpected results: %+v", resp.Results)
+	}
+}
+
+func TestBatchCreateCourses_RejectsTheBatch(t *testing.T) {
+	app, _ := setupBatch(t)
+
+	req := httptest.NewRequest("POST", "/courses:batchCreate", strings.NewReader(`{"mode":"all","items":[]}`))
+	_, body := send(t, app, req, http.StatusUnprocessableEntity)
+	if !strings.Contains(string(body), `"mode":"is invalid"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+
+	req = httptest.NewRequest("POST", "/courses:batchCreate", strings.NewReader(`{"items":`))
+	send(t, app, req, http.StatusBadRequest)
+}
+
+func TestBatchGetCourses(t *testing.T) {
+	app, mock := setupBatch(t)
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id IN \\(\\?,\\?\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description"}).
+			AddRow(negotiatedID, "Go basics", "Intro"))
+
+	resp := postBatch(t, app, "/courses:batchGet",
+		`{"mode":"best_effort","ids":["`+negotiatedID+`","`+batchUserID+`","nope"]}`, http.StatusOK)
+
+	got := statuses(resp)
+	if got[0] != http.StatusOK || got[1] != http.StatusNotFound || got[2] != http.StatusBadRequest {
+		t.Fatalf("statuses=%v", got)
+	}
+	if resp.Results[0].Course == nil || resp.Results[0].Course.Title != "Go basics" {
+		t.Fatalf("Unexpected results: %+v", resp.Results)
+	}
+	if resp.Results[1].Error != "course not found" || resp.Results[2].Error != "invalid courseId" {
+		t.Fatalf("Unexpected results: %+v", resp.Results)
+	}
+}
+
+func TestBatchEnroll_AtomicMissingUser(t *testing.T) {
+	app, mock := setupBatch(t)
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(negotiatedID, "Go basics"))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `enrollments`").
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+	mock.ExpectRollback()
+
+	resp := postBatch(t, app, "/courses/"+negotiatedID+"/enrollments:batch",
+		`{"userIds":["`+batchUserID+`"]}`, http.StatusNotFound)
+
+	if got := statuses(resp); got[0] != http.StatusNotFound || resp.Results[0].Error != "user or course not found" {
+		t.Fatalf("Unexpected results: %+v", resp.Results)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestBatchEnroll_404MissingCourse(t *testing.T) {
+	app, mock := setupBatch(t)
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	req := httptest.NewRequest("POST", "/courses/"+negotiatedID+"/enrollments:batch",
+		strings.NewReader(`{"userIds":["`+batchUserID+`"]}`))
+	send(t, app, req, http.StatusNotFound)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/httpx"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"gorm.io/gorm"
+)
+
+type CategoriesHandler struct {
+	categories *service.CategoryService
+}
+
+// NewCategoriesHandler returns the handler. Writes invalidate the course
+// lists cached in c.
+func NewCategoriesHandler(db *gorm.DB, c *cache.Cache) *CategoriesHandler {
+	return &CategoriesHandler{categories: service.NewCategoryService(mysqlrepo.NewStore(db), c)}
+}
+
+// ListCategories godoc
+// @Summary      List categories
+// @Description  Returns every category by name. Subcategories name their parent in parent_id.
+// @Tags         categories
+// @Produce      json
+// @Success      200  {object}  handlers.CategoriesResponse
+// @Failure      429  {object}  handlers.ErrorResponse
+// @Failure      500  {object}  handlers.ErrorResponse
+// @Router       /v1/categories [get]
+func (handler *CategoriesHandler) ListCategories(ctx *fiber.Ctx) error {
+	categories, err := handler.categories.List(ctx.UserContext())
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"data": categories})
+}
+
+// GetCategory godoc
+// @Summary      Get category
+// @Tags         categories
+// @Produce      json
+// @Param        categoryId  path      string  true  "Category ID"  format(uuid)
+// @Success      200         {object}  handlers.CategoryResponse
+// @Failure      400         {object}  handlers.ErrorResponse
+// @Failure      404         {object}  handlers.ErrorResponse
+// @Failure      429         {object}  handlers.ErrorResponse
+// @Failure      500         {object}  handlers.ErrorResponse
+// @Router       /v1/categories/{categoryId} [get]
+func (handler *CategoriesHandler) GetCategory(ctx *fiber.Ctx) error {
+	id, done, err := categoryID(ctx)
+	if done {
+		return err
+	}
+
+	category, err := handler.categories.Get(ctx.UserContext(), id)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"category": category})
+}
+
+// CreateCategory godoc
+// @Summary      Create category
+// @Description  Creates a category, below parent_id when set. The slug defaults to the slug of the name.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      handlers.CreateCategoryDTO  true  "New category"
+// @Success      201      {object}  handlers.CategoryResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      401      {object}  handlers.ErrorResponse
+// @Failure      403      {object}  handlers.ErrorResponse
+// @Failure      409      {object}  handlers.ErrorResponse
+// @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/categories [post]
+func (handler *CategoriesHandler) CreateCategory(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "categories.create")
+	defer span.End()
+
+	var Body service.CategoryInput
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	category, err := handler.categories.Create(ctx.UserContext(), Body)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	ctx.Location(apiversion.URL(ctx, "/categories/"+category.ID))
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"category": category})
+}
+
+// UpdateCategory godoc
+// @Summary      Update category
+// @Description  Changes the name, slug or parent. An empty parent_id moves the category to the top level; a parent below the category is rejected.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        categoryId  path      string                      true  "Category ID"  format(uuid)
+// @Param        payload     body      handlers.UpdateCategoryDTO  true  "Fields to change"
+// @Success      200         {object}  handlers.CategoryResponse
+// @Failure      400         {object}  handlers.ErrorResponse
+// @Failure      401         {object}  handlers.ErrorResponse
+// @Failure      403         {object}  handlers.ErrorResponse
+// @Failure      404         {object}  handlers.ErrorResponse
+// @Failure      409         {object}  handlers.ErrorResponse
+// @Failure      422         {object}  handlers.ValidationErrorResponse
+// @Failure      429         {object}  handlers.ErrorResponse
+// @Failure      500         {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/categories/{categoryId} [patch]
+func (handler *CategoriesHandler) UpdateCategory(ctx *fiber.Ctx) error {
+	id, done, err := categoryID(ctx)
+	if done {
+		return err
+	}
+
+	var Body UpdateCategoryDTO
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	category, err := handler.categories.Update(ctx.UserContext(), id, service.CategoryUpdate{
+		Name:     Body.Name,
+		Slug:     Body.Slug,
+		ParentID: Body.ParentID,
+	})
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"category": category})
+}
+
+// DeleteCategory godoc
+// @Summary      Delete category
+// @Description  Removes the category from its courses and deletes it. A category with subcategories cannot be deleted.
+// @Tags         categories
+// @Param        categoryId  path  string  true  "Category ID"  format(uuid)
+// @Success      204
+// @Failure      400         {object}  handlers.ErrorResponse
+// @Failure      401         {object}  handlers.ErrorResponse
+// @Failure      403         {object}  handlers.ErrorResponse
+// @Failure      404         {object}  handlers.ErrorResponse
+// @Failure      409         {object}  handlers.ErrorResponse
+// @Failure      429         {object}  handlers.ErrorResponse
+// @Failure      500         {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/categories/{categoryId} [delete]
+func (handler *CategoriesHandler) DeleteCategory(ctx *fiber.Ctx) error {
+	id, done, err := categoryID(ctx)
+	if done {
+		return err
+	}
+
+	if _, err := handler.categories.Delete(ctx.UserContext(), id); err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// categoryID returns the categoryId path parameter, answering 400 when it is
+// not a UUID.
+func categoryID(ctx *fiber.Ctx) (id string, done bool, err error) {
+	id = ctx.Params("categoryId")
+	if _, err := uuid.Parse(id); err != nil {
+		return "", true, ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid categoryId",
+		})
+	}
+	return id, false, nil
+}
+
+// taxonomyError answers a failed category, tag or course taxonomy write.
+func taxonomyError(ctx *fiber.Ctx, err error) error {
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"errors": invalid.Fields,
+		})
+	case errors.Is(err, service.ErrNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrConflict):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	default:
+		return httpx.InternalServerError(ctx, err)
+	}
+}
@@ -0,0 +1,61 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetCourseByID_200_BySlug(t *testing.T) {
+	app, mock := setupMock(t)
+	mock.ExpectQuery("SELECT `id`,`slug` FROM `courses` WHERE slug = \\?").
+		WithArgs("go-basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(negotiatedID, "go-basics"))
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WithArgs(negotiatedID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "slug"}).AddRow(negotiatedID, "Go basics", "go-basics"))
+
+	req := httptest.NewRequest("GET", "/courses/go-basics", nil)
+	_, body := send(t, app, req, http.StatusOK)
+	if !strings.Contains(string(body), `"slug":"go-basics"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
+
+func TestGetCourseByID_301_FormerSlug(t *testing.T) {
+	app, mock := setupMock(t)
+	mock.ExpectQuery("SELECT `id`,`slug` FROM `courses` WHERE slug = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}))
+	mock.ExpectQuery("SELECT courses.id, courses.slug FROM `courses` JOIN course_slugs").
+		WithArgs("go-basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(negotiatedID, "go-fundamentals"))
+
+	req := httptest.NewRequest("GET", "/courses/go-basics?fields=id,title", nil)
+	resp, _ := send(t, app, req, http.StatusMovedPermanently)
+	if got := resp.Header.Get("Location"); got != "/courses/go-fundamentals?fields=id,title" {
+		t.Fatalf("Location=%q", got)
+	}
+}
+
+func TestGetCourseByID_404_UnknownSlug(t *testing.T) {
+	app, mock := setupMock(t)
+	mock.ExpectQuery("SELECT `id`,`slug` FROM `courses` WHERE slug = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}))
+	mock.ExpectQuery("SELECT courses.id, courses.slug FROM `courses` JOIN course_slugs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}))
+
+	req := httptest.NewRequest("GET", "/courses/no-such-course", nil)
+	send(t, app, req, http.StatusNotFound)
+}
+
+func TestGetCourseByID_400_NeitherIDNorSlug(t *testing.T) {
+	app, _ := setupMock(t)
+
+	for _, id := range []string{"1233", "Go_Basics"} {
+		req := httptest.NewRequest("GET", "/courses/"+id, nil)
+		send(t, app, req, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,93 @@
+package handlers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+)
+
+func setupTranslations(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(i18n.Middleware())
+	app.Get("/courses/:courseId", h.GetCourseByID)
+	app.Post("/courses", h.CreateCourse)
+	app.Put("/courses/:courseId/translations/:locale", h.PutCourseTranslation)
+
+	return app, mock
+}
+
+func TestGetCourseByID_200_InTheAcceptedLocale(t *testing.T) {
+	app, mock := setupTranslations(t)
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description"}).AddRow(negotiatedID, "Go basics", "Intro"))
+	mock.ExpectQuery("SELECT \\* FROM `course_translations` WHERE course_id IN \\(\\?\\) AND locale IN \\(\\?\\)").
+		WithArgs(negotiatedID, "pt-BR").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "locale", "title", "description"}).AddRow(negotiatedID, "pt-BR", "Go básico", ""))
+
+	req := httptest.NewRequest("GET", "/courses/"+negotiatedID, nil)
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9,en;q=0.8")
+	resp, body := send(t, app, req, http.StatusOK)
+
+	if !strings.Contains(string(body), `"title":"Go básico"`) || !strings.Contains(string(body), `"description":"Intro"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+	if resp.Header.Get("Content-Language") != "pt-BR" {
+		t.Fatalf("Content-Language=%q", resp.Header.Get("Content-Language"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestCreateCourse422_MessagesInTheAcceptedLocale(t *testing.T) {
+	app, _ := setupTranslations(t)
+
+	req := httptest.NewRequest("POST", "/courses", strings.NewReader(`{"title":"Go"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "pt-BR")
+	_, body := send(t, app, req, http.StatusUnprocessableEntity)
+	if !strings.Contains(string(body), `"title":"deve ter pelo menos 3 caracteres"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
+
+func TestPutCourseTranslation201_AndLocaleChecks(t *testing.T) {
+	app, mock := setupTranslations(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT `id` FROM `courses` WHERE id = \\?.*FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(negotiatedID))
+	mock.ExpectQuery("SELECT \\* FROM `course_translations` WHERE course_id = \\? AND locale = \\?.*FOR UPDATE").
+		WithArgs(negotiatedID, "pt-BR", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "locale"}))
+	mock.ExpectExec("INSERT INTO `course_translations`").
+		WithArgs(negotiatedID, "pt-BR", "Go básico", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest("PUT", "/courses/"+negotiatedID+"/translations/pt-br", strings.NewReader(`{"title":" Go básico "}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, body := send(t, app, req, http.StatusCreated)
+	if !strings.Contains(string(body), `"locale":"pt-BR"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+
+	for locale, want := range map[string]string{"en": "is the default", "fr": "is not supported"} {
+		req := httptest.NewRequest("PUT", "/courses/"+negotiatedID+"/translations/"+locale, strings.NewReader(`{"title":"Go basics"}`))
+		req.Header.Set("Content-Type", "application/json")
+		_, body := send(t, app, req, http.StatusUnprocessableEntity)
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("%s: unexpected body: %s", locale, body)
+		}
+	}
+}
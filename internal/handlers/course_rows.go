@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/service"
+)
+
+// Formats accepted by imports and produced by exports.
+const (
+	formatCSV    = service.FormatCSV
+	formatNDJSON = service.FormatNDJSON
+)
+
+var formatMediaTypes = map[string]string{
+	formatCSV:    "text/csv",
+	formatNDJSON: "application/x-ndjson",
+}
+
+// formatFromQuery maps ?format= to a format, or "" when unknown.
+func formatFromQuery(q string) string {
+	switch strings.ToLower(q) {
+	case "csv":
+		return formatCSV
+	case "ndjson", "jsonl":
+		return formatNDJSON
+	}
+
+	return ""
+}
+
+// formatFromMediaType maps a Content-Type to a format, or "" when unknown.
+func formatFromMediaType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return formatCSV
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return formatNDJSON
+	}
+
+	return ""
+}
+
+type rowWriter interface {
+	Write(course domain.Course) error
+	// Close flushes anything still buffered.
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer) (rowWriter, error) {
+	switch format {
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"id", "title", "description", "created_at"}); err != nil {
+			return nil, err
+		}
+		return &csvWriter{cw}, nil
+	case formatNDJSON:
+		return &ndjsonWriter{json.NewEncoder(w)}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+type csvWriter struct{ w *csv.Writer }
+
+func (cw *csvWriter) Write(course domain.Course) error {
+	return cw.w.Write([]string{course.ID, course.Title, course.Description, course.CreatedAt.UTC().Format(time.RFC3339)})
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+type ndjsonWriter struct{ enc *json.Encoder }
+
+func (nw *ndjsonWriter) Write(course domain.Course) error { return nw.enc.Encode(course) }
+
+func (nw *ndjsonWriter) Close() error { return nil }
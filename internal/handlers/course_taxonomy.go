@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+// SetCourseCategories godoc
+// @Summary      Set course categories
+// @Description  Files the course under the given categories only; an empty list removes them all.
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        courseId  path      string                        true  "Course ID"  format(uuid)
+// @Param        payload   body      handlers.CourseCategoriesDTO  true  "Category IDs"
+// @Success      200       {object}  handlers.CourseCategoriesResponse
+// @Failure      400       {object}  handlers.ErrorResponse
+// @Failure      401       {object}  handlers.ErrorResponse
+// @Failure      403       {object}  handlers.ErrorResponse
+// @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      422       {object}  handlers.ValidationErrorResponse
+// @Failure      429       {object}  handlers.ErrorResponse
+// @Failure      500       {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/courses/{courseId}/categories [put]
+func (handler *CoursesHandler) SetCourseCategories(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "courses.set_categories", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	if _, err := uuid.Parse(courseId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid courseId",
+		})
+	}
+
+	var Body CourseCategoriesDTO
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	categories, err := handler.courses.SetCategories(ctx.UserContext(), courseId, Body.CategoryIDs)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"categories": categories})
+}
+
+// SetCourseTags godoc
+// @Summary      Set course tags
+// @Description  Tags the course with the given names only, in slug form, creating the tags that do not exist yet; an empty list removes them all.
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        courseId  path      string                  true  "Course ID"  format(uuid)
+// @Param        payload   body      handlers.CourseTagsDTO  true  "Tag names"
+// @Success      200       {object}  handlers.CourseTagsResponse
+// @Failure      400       {object}  handlers.ErrorResponse
+// @Failure      401       {object}  handlers.ErrorResponse
+// @Failure      403       {object}  handlers.ErrorResponse
+// @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      422       {object}  handlers.ValidationErrorResponse
+// @Failure      429       {object}  handlers.ErrorResponse
+// @Failure      500       {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/courses/{courseId}/tags [put]
+func (handler *CoursesHandler) SetCourseTags(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "courses.set_tags", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	if _, err := uuid.Parse(courseId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid courseId",
+		})
+	}
+
+	var Body CourseTagsDTO
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	tags, err := handler.courses.SetTags(ctx.UserContext(), courseId, Body.Tags)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"tags": tags})
+}
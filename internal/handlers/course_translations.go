@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/service"
+)
+
+// ListCourseTranslations godoc
+// @Summary      List course translations
+// @Description  Returns the title and description of the course in each locale other than the default one.
+// @Tags         courses
+// @Produce      json
+// @Param        courseId  path      string  true  "Course ID"  format(uuid)
+// @Success      200       {object}  handlers.CourseTranslationsResponse
+// @Failure      400       {object}  handlers.ErrorResponse
+// @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      429       {object}  handlers.ErrorResponse
+// @Failure      500       {object}  handlers.ErrorResponse
+// @Router       /v1/courses/{courseId}/translations [get]
+func (handler *CoursesHandler) ListCourseTranslations(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "courses.list_translations", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	if _, err := uuid.Parse(courseId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid courseId",
+		})
+	}
+
+	translations, err := handler.courses.Translations(ctx.UserContext(), courseId)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"data": translations})
+}
+
+// PutCourseTranslation godoc
+// @Summary      Create or replace a course translation
+// @Description  Sets the title and description of the course in a supported locale other than the default one. Titles are unique per locale; an empty description falls back to the default one.
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        courseId  path      string                         true  "Course ID"  format(uuid)
+// @Param        locale    path      string                         true  "Locale, such as pt-BR"
+// @Param        payload   body      handlers.CourseTranslationDTO  true  "Translated text"
+// @Success      200       {object}  handlers.CourseTranslationResponse
+// @Success      201       {object}  handlers.CourseTranslationResponse
+// @Failure      400       {object}  handlers.ErrorResponse
+// @Failure      401       {object}  handlers.ErrorResponse
+// @Failure      403       {object}  handlers.ErrorResponse
+// @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      409       {object}  handlers.ErrorResponse
+// @Failure      422       {object}  handlers.ValidationErrorResponse
+// @Failure      429       {object}  handlers.ErrorResponse
+// @Failure      500       {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/courses/{courseId}/translations/{locale} [put]
+func (handler *CoursesHandler) PutCourseTranslation(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "courses.put_translation", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	if _, err := uuid.Parse(courseId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid courseId",
+		})
+	}
+
+	var Body CourseTranslationDTO
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	translation, created, err := handler.courses.PutTranslation(ctx.UserContext(), courseId, ctx.Params("locale"), service.CourseTranslationInput{
+		Title:       Body.Title,
+		Description: Body.Description,
+	})
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return ctx.Status(status).JSON(fiber.Map{"translation": translation})
+}
+
+// DeleteCourseTranslation godoc
+// @Summary      Delete a course translation
+// @Description  The course is then served in the next locale of each client's fallback chain.
+// @Tags         courses
+// @Param        courseId  path  string  true  "Course ID"  format(uuid)
+// @Param        locale    path  string  true  "Locale, such as pt-BR"
+// @Success      204
+// @Failure      400       {object}  handlers.ErrorResponse
+// @Failure      401       {object}  handlers.ErrorResponse
+// @Failure      403       {object}  handlers.ErrorResponse
+// @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      422       {object}  handlers.ValidationErrorResponse
+// @Failure      429       {object}  handlers.ErrorResponse
+// @Failure      500       {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/courses/{courseId}/translations/{locale} [delete]
+func (handler *CoursesHandler) DeleteCourseTranslation(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "courses.delete_translation", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	if _, err := uuid.Parse(courseId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid courseId",
+		})
+	}
+
+	if _, err := handler.courses.DeleteTranslation(ctx.UserContext(), courseId, ctx.Params("locale")); err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
@@ -5,40 +5,83 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
-	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/cache"
 	"github.com/guycanella/api-courses-golang/internal/httpx"
-	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
 	"gorm.io/gorm"
 )
 
 type CoursesHandler struct {
-	db *gorm.DB
+	cache   *cache.Cache
+	courses *service.CourseService
+
+	enrollments *service.EnrollmentService
+
+	jobs             *jobs.Queue
+	importAsyncBytes int
+	importMaxBytes   int64
+}
+
+type Option func(*CoursesHandler)
+
+// WithCache serves course reads through c and invalidates it on writes.
+func WithCache(c *cache.Cache) Option {
+	return func(handler *CoursesHandler) { handler.cache = c }
 }
 
-func NewCoursesHandler(db *gorm.DB) *CoursesHandler {
-	return &CoursesHandler{
-		db: db,
+func NewCoursesHandler(db *gorm.DB, opts ...Option) *CoursesHandler {
+	handler := &CoursesHandler{
+		importAsyncBytes: defaultImportAsyncBytes,
+		importMaxBytes:   defaultImportMaxBytes,
+	}
+
+	for _, opt := range opts {
+		opt(handler)
 	}
+	store := mysqlrepo.NewStore(db)
+	handler.courses = service.NewCourseService(store, service.WithCache(handler.cache))
+	handler.enrollments = service.NewEnrollmentService(store)
+
+	return handler
 }
 
 // ListCourses godoc
 // @Summary      Get courses
-// @Description  Returns paginated courses, with optional search by title.
+// @Description  Returns paginated courses, with optional search by title, category and tag, and the number of matches per category and tag in facets.
+// @Description  Answers in JSON, XML, MessagePack or CSV by Accept; CSV puts the page in X-Total-Count, X-Page and X-Limit.
 // @Tags         courses
 // @Accept       json
 // @Produce      json
+// @Produce      xml
+// @Produce      application/msgpack
+// @Produce      text/csv
 // @Param        page   query     int    false  "Page (>=1)"              minimum(1) default(1)
 // @Param        limit  query     int    false  "Items per page [1..100]" minimum(1) maximum(100) default(10)
 // @Param        q      query     string false  "Title fragment (2..100)" minlength(2) maxlength(100)
+// @Param        fields   query   string false  "Fields to return (id,title,description,instructor_id,created_at,slug)"
+// @Param        include  query   string false  "Relations to embed (enrollments_count,instructor,modules,categories,tags)"
+// @Param        category query   string false  "Category slug; includes its subcategories"
+// @Param        tag      query   string false  "Tag name"
 // @Success      200    {object}  handlers.CoursesResponse
 // @Failure      400    {object}  handlers.ErrorResponse
+// @Failure      406    {object}  handlers.NotAcceptableResponse
+// @Failure      429    {object}  handlers.ErrorResponse
 // @Failure      500    {object}  handlers.ErrorResponse
-// @Router       /courses [get]
+// @Router       /v1/courses [get]
 func (handler *CoursesHandler) ListCourses(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.list")
+	defer span.End()
+
+	mediaType := negotiate(ctx)
+	if mediaType == "" {
+		return nil
+	}
+
 	page, err := strconv.Atoi(ctx.Query("page", "1"))
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -55,6 +98,18 @@ func (handler *CoursesHandler) ListCourses(ctx *fiber.Ctx) error {
 
 	q := strings.TrimSpace(ctx.Query("q", ""))
 
+	projection, err := service.ParseCourseProjection(ctx.Query("fields"), ctx.Query("include"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if canonicalMedia(mediaType) == mediaCSV && !csvIncludable(projection) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "only enrollments_count can be included in CSV",
+		})
+	}
+
 	if page < 1 {
 		page = 1
 	}
@@ -62,59 +117,98 @@ func (handler *CoursesHandler) ListCourses(ctx *fiber.Ctx) error {
 		limit = 10
 	}
 
-	var courses []domain.Course
-	tx := handler.db.Model(&domain.Course{})
+	span.SetAttributes(
+		obs.AttrPaginationPage.Int(page),
+		obs.AttrPaginationLimit.Int(limit),
+		obs.AttrSearchQueryLength.Int(len(q)),
+	)
 
-	if q != "" {
-		tx = tx.Where("title LIKE ?", "%"+q+"%")
+	result, err := handler.courses.ListViews(ctx.UserContext(), service.ListCourses{
+		Page:     service.Page{Page: page, Limit: limit},
+		Query:    q,
+		Category: ctx.Query("category"),
+		Tag:      ctx.Query("tag"),
+		Facets:   true,
+	}, projection)
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
 	}
 
-	var total int64
-	tx.Count(&total)
-	if err := tx.
-		Order("created_at desc").
-		Limit(limit).
-		Offset((page - 1) * limit).
-		Find(&courses).Error; err != nil {
+	if err := sendCourses(ctx, mediaType, projection, result, page, limit); err != nil {
 		return httpx.InternalServerError(ctx, err)
 	}
-
-	return ctx.JSON(fiber.Map{
-		"data":  courses,
-		"page":  page,
-		"limit": limit,
-		"total": total,
-	})
+	return nil
 }
 
 // GetCourseByID godoc
-// @Summary      Get course by ID
+// @Summary      Get course by ID or slug
+// @Description  Answers in JSON, XML, MessagePack or CSV by Accept. A former slug of the course is redirected to the current one with 301.
 // @Tags         courses
 // @Produce      json
-// @Param        courseId  path      string  true  "Course ID"  format(uuid)
+// @Produce      xml
+// @Produce      application/msgpack
+// @Produce      text/csv
+// @Param        courseId  path      string  true  "Course ID or slug"
+// @Param        fields    query     string  false "Fields to return (id,title,description,instructor_id,created_at,slug)"
+// @Param        include   query     string  false "Relations to embed (enrollments_count,instructor,modules,categories,tags)"
 // @Success      200       {object}  handlers.CourseResponse
+// @Success      301       {string}  string  "Location is the URL of the current slug"
 // @Failure      400       {object}  handlers.ErrorResponse
 // @Failure      404       {object}  handlers.ErrorResponse
+// @Failure      406       {object}  handlers.NotAcceptableResponse
+// @Failure      429       {object}  handlers.ErrorResponse
 // @Failure      500       {object}  handlers.ErrorResponse
-// @Router       /courses/{courseId} [get]
+// @Router       /v1/courses/{courseId} [get]
 func (handler *CoursesHandler) GetCourseByID(ctx *fiber.Ctx) error {
-	var course domain.Course
 	courseId := ctx.Params("courseId")
 
+	span := obs.StartSpan(ctx, "courses.get", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	mediaType := negotiate(ctx)
+	if mediaType == "" {
+		return nil
+	}
+
 	if courseId == "" {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "courseId is required",
 		})
 	}
 
-	if _, fieldErr := uuid.Parse(courseId); fieldErr != nil {
+	id, moved, err := handler.courses.Resolve(ctx.UserContext(), courseId)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "invalid courseId",
 		})
+	case errors.Is(err, service.ErrNotFound):
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "course not found",
+		})
+	case err != nil:
+		return httpx.InternalServerError(ctx, err)
+	case moved != "":
+		return redirectCourse(ctx, moved)
+	}
+
+	projection, err := service.ParseCourseProjection(ctx.Query("fields"), ctx.Query("include"))
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if canonicalMedia(mediaType) == mediaCSV && !csvIncludable(projection) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "only enrollments_count can be included in CSV",
+		})
 	}
 
-	if err := handler.db.First(&course, "id = ?", courseId).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+	course, err := handler.courses.GetView(ctx.UserContext(), id, projection)
+	if err != nil {
+		if errors.Is(err, service.ErrNotFound) {
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
 				"error": "course not found",
 			})
@@ -123,83 +217,73 @@ func (handler *CoursesHandler) GetCourseByID(ctx *fiber.Ctx) error {
 		return httpx.InternalServerError(ctx, err)
 	}
 
-	return ctx.JSON(fiber.Map{"course": course})
+	if err := sendCourse(ctx, mediaType, course); err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+	return nil
 }
 
-var validate = validator.New()
+// redirectCourse answers 301 with the course URL for slug, keeping the query.
+func redirectCourse(ctx *fiber.Ctx, slug string) error {
+	location := apiversion.URL(ctx, "/courses/"+slug)
+	if query := ctx.Request().URI().QueryString(); len(query) > 0 {
+		location += "?" + string(query)
+	}
+	return ctx.Redirect(location, fiber.StatusMovedPermanently)
+}
 
 // CreateCourse godoc
 // @Summary      Create course
-// @Description  Creates a course and returns only the courseId and the Location header.
+// @Description  Creates a course and returns only the courseId and the Location header. The body may be JSON, XML (<course><title/><description/></course>), MessagePack or CSV with a header and one row.
 // @Tags         courses
 // @Accept       json
+// @Accept       xml
+// @Accept       application/msgpack
+// @Accept       text/csv
 // @Produce      json
 // @Param        payload  body      handlers.CreateCourseDTO  true  "New course"
 // @Success      201      {object}  handlers.CreatedIDResponse
 // @Failure      400      {object}  handlers.ErrorResponse
 // @Failure      409      {object}  handlers.ErrorResponse
+// @Failure      415      {object}  handlers.UnsupportedMediaTypeResponse
 // @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
 // @Failure      500      {object}  handlers.ErrorResponse
-// @Router       /courses [post]
+// @Router       /v1/courses [post]
 func (handler *CoursesHandler) CreateCourse(ctx *fiber.Ctx) error {
-	var Body struct {
-		Title       string `json:"title" validate:"required,min=3"`
-		Description string `json:"description" validate:"omitempty,min=3"`
-	}
+	span := obs.StartSpan(ctx, "courses.create")
+	defer span.End()
 
-	if err := ctx.BodyParser(&Body); err != nil {
+	Body, err := parseCourseInput(ctx)
+	if errors.Is(err, errUnsupportedMediaType) {
+		return ctx.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error":     "unsupported media type",
+			"supported": courseMediaTypes,
+		})
+	}
+	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "invalid JSON body",
+			"error": "invalid " + mediaName(ctx.Get(fiber.HeaderContentType)) + " body",
 		})
 	}
 
-	if err := validate.Struct(&Body); err != nil {
-		errs := make(map[string]string)
-		for _, err := range err.(validator.ValidationErrors) {
-			field := strings.ToLower(err.Field())
-			switch err.Tag() {
-			case "required":
-				errs[field] = "is required"
-			case "min":
-				errs[field] = "too short"
-			default:
-				errs[field] = "is invalid"
-			}
-		}
-
+	course, err := handler.courses.Create(ctx.UserContext(), Body)
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
 		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"errors": errs,
+			"errors": invalid.Fields,
 		})
-	}
-
-	title := strings.TrimSpace(Body.Title)
-	desc := strings.TrimSpace(Body.Description)
-
-	course := domain.Course{
-		ID:          uuid.NewString(),
-		Title:       title,
-		Description: desc,
-	}
-
-	if err := handler.db.Create(&course).Error; err != nil {
-		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error": "title already exists",
-			})
-		}
-
-		var me *mysql.MySQLError
-		if errors.As(err, &me) && me.Number == 1062 {
-			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error": "title already exists",
-			})
-		}
-
+	case errors.Is(err, service.ErrConflict):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
 		return httpx.InternalServerError(ctx, err)
 	}
 
-	ctx.Location("/courses/" + course.ID)
-	metrics.CoursesCreatedTotal.Inc()
+	span.SetAttributes(obs.AttrCourseID.String(course.ID))
+	ctx.Location(apiversion.URL(ctx, "/courses/"+course.ID))
 	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"courseId": course.ID,
 	})
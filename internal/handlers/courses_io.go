@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/httpx"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"github.com/guycanella/api-courses-golang/internal/tasks"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultImportAsyncBytes is the body size above which imports run as jobs.
+const defaultImportAsyncBytes = 1 << 20
+
+// defaultImportMaxBytes is the largest import body accepted.
+const defaultImportMaxBytes = 100 << 20
+
+// errImportTooLarge ends reading an import body past the limit, which rolls
+// back the job it was being saved with.
+var errImportTooLarge = errors.New("import body too large")
+
+// WithJobs runs background imports on q.
+func WithJobs(q *jobs.Queue) Option {
+	return func(handler *CoursesHandler) { handler.jobs = q }
+}
+
+// WithImportAsyncThreshold runs imports with a body larger than n bytes, or
+// of unknown length, as background jobs.
+func WithImportAsyncThreshold(n int) Option {
+	return func(handler *CoursesHandler) { handler.importAsyncBytes = n }
+}
+
+// WithImportMaxBytes rejects import bodies larger than n bytes with a 413.
+func WithImportMaxBytes(n int64) Option {
+	return func(handler *CoursesHandler) { handler.importMaxBytes = n }
+}
+
+// maxBytesReader reads R, failing with errImportTooLarge once it has read
+// more than the limit; N starts at the limit plus one.
+type maxBytesReader struct {
+	io.LimitedReader
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	n, err := r.LimitedReader.Read(p)
+	if r.N == 0 {
+		return n, errImportTooLarge
+	}
+	return n, err
+}
+
+type ImportJobResponse struct {
+	domain.CourseImportJob
+	Report *service.ImportReport `json:"report,omitempty"`
+}
+
+type ImportAcceptedResponse struct {
+	JobID  string `json:"jobId"  example:"4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"`
+	Status string `json:"status" example:"pending"`
+}
+
+// ImportCourses godoc
+// @Summary      Import courses
+// @Description  Upserts courses by title from a CSV (header with title and description columns) or NDJSON body, using the CreateCourse validation rules. Rows that fail are reported and skipped. Bodies over 1 MiB, of unknown length or with async=true run as a background job (202) to poll. Bodies over IMPORT_MAX_BYTES (100 MiB by default) are a 413.
+// @Tags         courses
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Param        format   query     string  false  "csv or ndjson; defaults to the Content-Type"  Enums(csv, ndjson)
+// @Param        dry_run  query     bool    false  "Validate and report without writing"
+// @Param        async    query     bool    false  "Run as a background job"
+// @Success      200      {object}  service.ImportReport
+// @Success      202      {object}  handlers.ImportAcceptedResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      401      {object}  handlers.ErrorResponse
+// @Failure      403      {object}  handlers.ErrorResponse
+// @Failure      413      {object}  handlers.ErrorResponse
+// @Failure      415      {object}  handlers.ErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/courses:import [post]
+func (handler *CoursesHandler) ImportCourses(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.import")
+	defer span.End()
+
+	format := formatFromQuery(ctx.Query("format"))
+	if format == "" {
+		format = formatFromMediaType(ctx.Get(fiber.HeaderContentType))
+	}
+	if format == "" {
+		return ctx.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"error": "use text/csv or application/x-ndjson",
+		})
+	}
+
+	dryRun := ctx.QueryBool("dry_run")
+	length := ctx.Request().Header.ContentLength()
+	if int64(length) > handler.importMaxBytes {
+		return importTooLarge(ctx)
+	}
+	async := ctx.QueryBool("async") || length < 0 || length > handler.importAsyncBytes
+
+	span.SetAttributes(
+		attribute.String("import.format", format),
+		attribute.Bool("import.dry_run", dryRun),
+		attribute.Bool("import.async", async),
+	)
+
+	var body io.Reader = ctx.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(ctx.Body())
+	}
+	// chunked bodies only show their size as they are read
+	body = &maxBytesReader{io.LimitedReader{R: body, N: handler.importMaxBytes + 1}}
+
+	if async {
+		return handler.startImportJob(ctx, format, dryRun, body)
+	}
+
+	rows, err := service.NewRowReader(format, body)
+	if err != nil {
+		return importError(ctx, err)
+	}
+
+	report, err := handler.courses.Import(ctx.UserContext(), rows, dryRun, nil)
+	if err != nil {
+		return importError(ctx, err)
+	}
+
+	return ctx.JSON(report)
+}
+
+func importError(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, errImportTooLarge) {
+		return importTooLarge(ctx)
+	}
+	var le *service.LayoutError
+	if errors.As(err, &le) {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": le.Error()})
+	}
+
+	return httpx.InternalServerError(ctx, err)
+}
+
+// importTooLarge closes the connection, as the rest of the body may be left
+// unread on it.
+func importTooLarge(ctx *fiber.Ctx) error {
+	ctx.Context().SetConnectionClose()
+	return ctx.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "import body too large"})
+}
+
+// startImportJob saves the body with a new job, so the request can finish,
+// and queues it for a worker.
+func (handler *CoursesHandler) startImportJob(ctx *fiber.Ctx, format string, dryRun bool, body io.Reader) error {
+	if handler.jobs == nil {
+		return httpx.InternalServerError(ctx, errors.New("async imports need a job queue"))
+	}
+
+	job, err := handler.courses.CreateImportJob(ctx.UserContext(), format, dryRun, body)
+	if err != nil {
+		return importError(ctx, err)
+	}
+	if err := tasks.EnqueueCourseImport(ctx.UserContext(), handler.jobs, job.ID); err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	ctx.Location(apiversion.URL(ctx, "/courses:import/jobs/"+job.ID))
+	return ctx.Status(fiber.StatusAccepted).JSON(ImportAcceptedResponse{JobID: job.ID, Status: job.Status})
+}
+
+// GetImportJob godoc
+// @Summary      Get import job
+// @Description  Returns the status of a background import and its report so far.
+// @Tags         courses
+// @Produce      json
+// @Param        jobId  path      string  true  "Job ID"  format(uuid)
+// @Success      200    {object}  handlers.ImportJobResponse
+// @Failure      400    {object}  handlers.ErrorResponse
+// @Failure      404    {object}  handlers.ErrorResponse
+// @Failure      429    {object}  handlers.ErrorResponse
+// @Failure      500    {object}  handlers.ErrorResponse
+// @Router       /v1/courses:import/jobs/{jobId} [get]
+func (handler *CoursesHandler) GetImportJob(ctx *fiber.Ctx) error {
+	jobId := ctx.Params("jobId")
+	if _, err := uuid.Parse(jobId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid jobId",
+		})
+	}
+
+	job, report, err := handler.courses.ImportJob(ctx.UserContext(), jobId)
+	if errors.Is(err, service.ErrNotFound) {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "import job not found",
+		})
+	}
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.JSON(ImportJobResponse{CourseImportJob: job, Report: report})
+}
+
+// ExportCourses godoc
+// @Summary      Export courses
+// @Description  Streams the whole catalog, oldest first, as CSV (id, title, description, created_at) or NDJSON.
+// @Tags         courses
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format  query  string  false  "csv or ndjson; defaults to the Accept header, then csv"  Enums(csv, ndjson)
+// @Success      200
+// @Failure      400     {object}  handlers.ErrorResponse
+// @Failure      429     {object}  handlers.ErrorResponse
+// @Failure      500     {object}  handlers.ErrorResponse
+// @Router       /v1/courses:export [get]
+func (handler *CoursesHandler) ExportCourses(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.export")
+	defer span.End()
+
+	format := formatCSV
+	if q := ctx.Query("format"); q != "" {
+		if format = formatFromQuery(q); format == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid format",
+			})
+		}
+	} else if ctx.Accepts(formatMediaTypes[formatCSV], formatMediaTypes[formatNDJSON]) == formatMediaTypes[formatNDJSON] {
+		format = formatNDJSON
+	}
+	span.SetAttributes(attribute.String("export.format", format))
+
+	courses, err := handler.courses.Export(ctx.UserContext())
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	ctx.Attachment("courses-" + time.Now().UTC().Format("20060102") + "." + format)
+	ctx.Set(fiber.HeaderContentType, formatMediaTypes[format])
+
+	// The body is written after the handler returns, one row at a time.
+	reqCtx := context.WithoutCancel(ctx.UserContext())
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer courses.Close()
+
+		logger := obs.Logger(reqCtx)
+		out, err := newRowWriter(format, w)
+		if err != nil {
+			logger.ErrorContext(reqCtx, "export failed", "error", err)
+			return
+		}
+
+		for {
+			course, err := courses.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				logger.ErrorContext(reqCtx, "export failed", "error", err)
+				return
+			}
+			if err := out.Write(course); err != nil {
+				// The client went away.
+				return
+			}
+		}
+
+		_ = out.Close()
+	})
+
+	return nil
+}
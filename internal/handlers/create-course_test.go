@@ -10,6 +10,7 @@ import (
 
 	"github.com/brianvoe/gofakeit/v7"
 	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/events"
 )
 
 func TestCreateCourse201_Created(t *testing.T) {
@@ -143,17 +144,17 @@ func TestCreateCourse422_Validation(t *testing.T) {
 		{
 			name:    "short title",
 			payload: map[string]string{"title": "ab"},
-			want:    map[string]string{"title": "too short"},
+			want:    map[string]string{"title": "must be at least 3 characters"},
 		},
 		{
 			name:    "short description",
 			payload: map[string]string{"title": "Valid " + gofakeit.Sentence(4), "description": "ab"}, // < 3
-			want:    map[string]string{"description": "too short"},
+			want:    map[string]string{"description": "must be at least 3 characters"},
 		},
 		{
 			name:    "multiple errors",
 			payload: map[string]string{"title": "", "description": "ab"},
-			want:    map[string]string{"title": "is required", "description": "too short"},
+			want:    map[string]string{"title": "is required", "description": "must be at least 3 characters"},
 		},
 	}
 
@@ -212,3 +213,33 @@ func TestCreateCourse500_InternalServerError(t *testing.T) {
 		t.Fatalf("Failed Internal Server Error: status=%d want=%d", resp.StatusCode, http.StatusInternalServerError)
 	}
 }
+
+func TestCreateCourse201_RecordsEvent(t *testing.T) {
+	app, db := setupAll(t)
+	title := "test-" + uuid.NewString()
+
+	body, _ := json.Marshal(map[string]string{"title": title, "description": "outbox"})
+	req := httptest.NewRequest("POST", "/courses", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to TestCreateCourse201_RecordsEvent request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		CourseID string `json:"courseId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	var row events.OutboxEvent
+	if err := db.Where("aggregate_type = ? AND aggregate_id = ?", events.AggregateCourse, out.CourseID).First(&row).Error; err != nil {
+		t.Fatalf("Expected an outbox event: %v", err)
+	}
+	if row.Type != events.TypeCourseCreated || !strings.Contains(row.Payload, title) {
+		t.Fatalf("Unexpected outbox event: %+v", row)
+	}
+}
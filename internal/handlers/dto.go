@@ -1,12 +1,25 @@
 package handlers
 
-import "github.com/guycanella/api-courses-golang/internal/domain"
+import (
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/service"
+)
 
+// CourseDoc documents a course; ?fields leaves out the unselected fields and
+// ?include adds the relations.
 type CourseDoc struct {
-	ID          string `json:"id" example:"4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"`
-	Title       string `json:"title" example:"Go para Iniciantes"`
-	Description string `json:"description" example:"Curso introdutório de Go"`
-	CreatedAt   string `json:"created_at" example:"2025-08-20T15:04:05Z"`
+	ID           string `json:"id" example:"4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"`
+	Title        string `json:"title" example:"Go para Iniciantes"`
+	Description  string `json:"description" example:"Curso introdutório de Go"`
+	InstructorID string `json:"instructor_id,omitempty" example:"9b2f4c1e-8d6a-4b3e-a1f0-5c7d9e2b4a61"`
+	CreatedAt    string `json:"created_at" example:"2025-08-20T15:04:05Z"`
+	Slug         string `json:"slug,omitempty" example:"go-para-iniciantes"`
+
+	EnrollmentsCount *int64                `json:"enrollments_count,omitempty" example:"12"`
+	Instructor       *domain.User          `json:"instructor,omitempty"`
+	Modules          []domain.CourseModule `json:"modules,omitempty"`
+	Categories       []domain.Category     `json:"categories,omitempty"`
+	Tags             []domain.Tag          `json:"tags,omitempty"`
 }
 
 type CreateCourseDTO struct {
@@ -15,14 +28,15 @@ type CreateCourseDTO struct {
 }
 
 type CourseResponse struct {
-	Course domain.Course `json:"course"`
+	Course CourseDoc `json:"course"`
 }
 
 type CoursesResponse struct {
-	Data  []CourseDoc `json:"data"`
-	Page  int         `json:"page"  example:"1"`
-	Limit int         `json:"limit" example:"10"`
-	Total int64       `json:"total" example:"42"`
+	Data   []CourseDoc           `json:"data"`
+	Page   int                   `json:"page"  example:"1"`
+	Limit  int                   `json:"limit" example:"10"`
+	Total  int64                 `json:"total" example:"42"`
+	Facets *service.CourseFacets `json:"facets,omitempty"`
 }
 
 type CreatedIDResponse struct {
@@ -33,6 +47,121 @@ type ErrorResponse struct {
 	Error string `json:"error" example:"internal server error"`
 }
 
+type NotAcceptableResponse struct {
+	Error     string   `json:"error"     example:"not acceptable"`
+	Supported []string `json:"supported" example:"application/json,application/xml,application/msgpack,text/csv"`
+}
+
+type UnsupportedMediaTypeResponse struct {
+	Error     string   `json:"error"     example:"unsupported media type"`
+	Supported []string `json:"supported" example:"application/json,application/xml,application/msgpack,text/csv"`
+}
+
 type ValidationErrorResponse struct {
 	Errors map[string]string `json:"errors" example:"{\"title\":\"is required\"}"`
 }
+
+type CreateWebhookDTO struct {
+	URL        string   `json:"url"         example:"https://partner.example.com/hooks/courses"`
+	EventTypes []string `json:"event_types" example:"course.created,enrollment.created"`
+	Secret     string   `json:"secret,omitempty" example:"whsec_3f1c9a..."`
+	Active     *bool    `json:"active,omitempty" example:"true"`
+}
+
+type UpdateWebhookDTO struct {
+	URL        string   `json:"url,omitempty"         example:"https://partner.example.com/hooks/v2"`
+	EventTypes []string `json:"event_types,omitempty" example:"course.created"`
+	Secret     string   `json:"secret,omitempty"      example:"whsec_new..."`
+	Active     *bool    `json:"active,omitempty"      example:"true"`
+}
+
+type WebhookResponse struct {
+	Webhook domain.WebhookSubscription `json:"webhook"`
+}
+
+type WebhookCreatedResponse struct {
+	Webhook domain.WebhookSubscription `json:"webhook"`
+	Secret  string                     `json:"secret" example:"whsec_3f1c9a..."`
+}
+
+type WebhooksResponse struct {
+	Data  []domain.WebhookSubscription `json:"data"`
+	Page  int                          `json:"page"  example:"1"`
+	Limit int                          `json:"limit" example:"10"`
+	Total int64                        `json:"total" example:"3"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Data  []domain.WebhookDelivery `json:"data"`
+	Page  int                      `json:"page"  example:"1"`
+	Limit int                      `json:"limit" example:"10"`
+	Total int64                    `json:"total" example:"12"`
+}
+
+type RedeliverResponse struct {
+	JobID string `json:"jobId" example:"9b2f4c1e-8d6a-4b3e-a1f0-5c7d9e2b4a61"`
+}
+
+type CreateCategoryDTO struct {
+	Name     string `json:"name"                example:"Backend"`
+	Slug     string `json:"slug,omitempty"      example:"backend"`
+	ParentID string `json:"parent_id,omitempty" example:"3f1a2b4c-5d6e-4f70-8a9b-0c1d2e3f4a5b"`
+}
+
+type UpdateCategoryDTO struct {
+	Name     *string `json:"name,omitempty"      example:"Back-end"`
+	Slug     *string `json:"slug,omitempty"      example:"back-end"`
+	ParentID *string `json:"parent_id,omitempty" example:""`
+}
+
+type CategoryResponse struct {
+	Category domain.Category `json:"category"`
+}
+
+type CategoriesResponse struct {
+	Data []domain.Category `json:"data"`
+}
+
+type TagDTO struct {
+	Name string `json:"name" example:"Web Dev"`
+}
+
+type TagResponse struct {
+	Tag domain.Tag `json:"tag"`
+}
+
+type TagsResponse struct {
+	Data  []domain.Tag `json:"data"`
+	Page  int          `json:"page"  example:"1"`
+	Limit int          `json:"limit" example:"10"`
+	Total int64        `json:"total" example:"25"`
+}
+
+type CourseCategoriesDTO struct {
+	CategoryIDs []string `json:"categoryIds" example:"3f1a2b4c-5d6e-4f70-8a9b-0c1d2e3f4a5b"`
+}
+
+type CourseCategoriesResponse struct {
+	Categories []domain.Category `json:"categories"`
+}
+
+type CourseTagsDTO struct {
+	Tags []string `json:"tags" example:"golang,web-dev"`
+}
+
+type CourseTagsResponse struct {
+	Tags []domain.Tag `json:"tags"`
+}
+
+type CourseTranslationDTO struct {
+	Title       string `json:"title"       example:"Go básico"`
+	Description string `json:"description" example:"Introdução à linguagem Go"`
+}
+
+type CourseTranslationResponse struct {
+	Translation domain.CourseTranslation `json:"translation"`
+}
+
+type CourseTranslationsResponse struct {
+	Data []domain.CourseTranslation `json:"data"`
+}
@@ -0,0 +1,156 @@
+package handlers_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+)
+
+// setupStream serves the event stream without a database, since it only reads
+// from the hub.
+func setupStream(t *testing.T) (*fiber.App, *events.Hub) {
+	t.Helper()
+
+	tokens, err := auth.ParseTokens("dashboard=tok1=courses:read;partner=tok2=enrollments:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	hub := events.NewHub(100, 3)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.QueryToken())
+	app.Use(auth.Authenticate(tokens))
+
+	es := handlers.NewEventsStreamHandler(hub, 50*time.Millisecond)
+	app.Get("/events/stream", auth.RequireAny(handlers.StreamScopes...), es.StreamEvents)
+
+	return app, hub
+}
+
+func TestStreamEvents401_Anonymous(t *testing.T) {
+	app, _ := setupStream(t)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events/stream", nil))
+	if err != nil {
+		t.Fatalf("Failed to TestStreamEvents401_Anonymous request: %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Failed to TestStreamEvents401_Anonymous status=%d want=%d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestStreamEvents403_TypeOutsideScopes(t *testing.T) {
+	app, _ := setupStream(t)
+
+	req := httptest.NewRequest("GET", "/events/stream?types=enrollment.created", nil)
+	req.Header.Set("Authorization", "Bearer tok1")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to TestStreamEvents403_TypeOutsideScopes request: %v", err)
+	}
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Failed to TestStreamEvents403_TypeOutsideScopes status=%d want=%d", resp.StatusCode, http.StatusForbidden)
+	}
+}
+
+// openStream connects to a running app and returns a reader of SSE lines.
+func openStream(t *testing.T, base, query, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", base+"/events/stream?"+query, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// nextEvent reads lines until a message with an event field and returns its
+// id and event.
+func nextEvent(t *testing.T, r *bufio.Reader) (id, event string) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case line == "" && event != "":
+			return id, event
+		}
+	}
+}
+
+func TestStreamEvents200_FiltersAndResumes(t *testing.T) {
+	app, hub := setupStream(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+	base := "http://" + ln.Addr().String()
+
+	courseID := "4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"
+	resp, stream := openStream(t, base, "access_token=tok1&course_id="+courseID, "")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status=%d content-type=%q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	hub.Publish(context.Background(), events.CourseCreated(domain.Course{ID: "00000000-0000-0000-0000-000000000000"}))
+	hub.Publish(context.Background(), events.EnrollmentCreated(domain.Enrollment{ID: "e1", CourseID: courseID}))
+	hub.Publish(context.Background(), events.CourseCreated(domain.Course{ID: courseID}))
+	hub.Publish(context.Background(), events.CourseUpdated(domain.Course{ID: courseID}))
+
+	// The other course and the enrollment (no enrollments:read) are filtered out.
+	first, event := nextEvent(t, stream)
+	if event != events.TypeCourseCreated {
+		t.Fatalf("first event = %q", event)
+	}
+	if _, event := nextEvent(t, stream); event != events.TypeCourseUpdated {
+		t.Fatalf("second event = %q", event)
+	}
+
+	_, resumed := openStream(t, base, "access_token=tok1&course_id="+courseID, first)
+	if _, event := nextEvent(t, resumed); event != events.TypeCourseUpdated {
+		t.Fatalf("resumed event = %q, want %q", event, events.TypeCourseUpdated)
+	}
+
+	_, reset := openStream(t, base, "access_token=tok1", "unknown-1")
+	if _, event := nextEvent(t, reset); event != "reset" {
+		t.Fatalf("stale cursor event = %q, want reset", event)
+	}
+
+	// Three streams are open, the cap of this hub.
+	over, _ := openStream(t, base, "access_token=tok1", "")
+	if over.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("status=%d want=%d", over.StatusCode, http.StatusServiceUnavailable)
+	}
+}
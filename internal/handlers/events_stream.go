@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// StreamScopes are the scopes that grant access to the event stream. Each
+// one shows the events of its aggregate.
+var StreamScopes = []string{auth.ScopeCoursesRead, auth.ScopeEnrollmentsRead}
+
+var streamScopeOf = map[string]string{
+	events.AggregateCourse:     auth.ScopeCoursesRead,
+	events.AggregateEnrollment: auth.ScopeEnrollmentsRead,
+}
+
+// missingEventScope returns the first of types whose events p may not see on
+// the stream, since subscribing to them elsewhere would leak them too.
+func missingEventScope(p auth.Principal, types []string) (string, bool) {
+	for _, t := range types {
+		aggregate, _, _ := strings.Cut(t, ".")
+		if !p.Has(streamScopeOf[aggregate]) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+type EventsStreamHandler struct {
+	hub       *events.Hub
+	heartbeat time.Duration
+}
+
+func NewEventsStreamHandler(hub *events.Hub, heartbeat time.Duration) *EventsStreamHandler {
+	return &EventsStreamHandler{hub: hub, heartbeat: heartbeat}
+}
+
+// StreamEvents godoc
+// @Summary      Stream events
+// @Description  Server-Sent Events stream of course and enrollment events as they are published. Each message has the event type as `event`, a resumable cursor as `id` and the event envelope as `data`. Reconnect with Last-Event-ID (or last_event_id) to resume from a bounded log; a `reset` message means events were missed and state should be reloaded. Comments are sent as heartbeats. Requires a token with courses:read or enrollments:read, as a bearer header or access_token; only events of granted scopes are sent.
+// @Tags         events
+// @Produce      text/event-stream
+// @Param        types          query   string  false  "Comma-separated event types"  example(course.created,enrollment.created)
+// @Param        course_id      query   string  false  "Only events of this course"   format(uuid)
+// @Param        last_event_id  query   string  false  "Resume after this cursor, when the Last-Event-ID header cannot be set"
+// @Param        Last-Event-ID  header  string  false  "Resume after this cursor"
+// @Success      200
+// @Failure      400            {object}  handlers.ErrorResponse
+// @Failure      401            {object}  handlers.ErrorResponse
+// @Failure      403            {object}  handlers.ErrorResponse
+// @Failure      429            {object}  handlers.ErrorResponse
+// @Failure      503            {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/events/stream [get]
+func (handler *EventsStreamHandler) StreamEvents(ctx *fiber.Ctx) error {
+	principal, _ := auth.FromCtx(ctx)
+
+	span := obs.StartSpan(ctx, "events.stream")
+	defer span.End()
+
+	types := map[string]bool{}
+	for _, t := range strings.Split(ctx.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		aggregate, _, _ := strings.Cut(t, ".")
+		if !isEventType(t) || streamScopeOf[aggregate] == "" {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("unknown event type %q", t),
+			})
+		}
+		if _, missing := missingEventScope(principal, []string{t}); missing {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "missing scope for " + t,
+			})
+		}
+		types[t] = true
+	}
+
+	courseID := ctx.Query("course_id")
+	if courseID != "" {
+		if _, err := uuid.Parse(courseID); err != nil {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid course_id",
+			})
+		}
+	}
+	span.SetAttributes(attribute.Int("stream.types", len(types)), obs.AttrCourseID.String(courseID))
+
+	filter := func(e events.Event) bool {
+		scope, ok := streamScopeOf[e.AggregateType]
+		if !ok || !principal.Has(scope) {
+			return false
+		}
+		if len(types) > 0 && !types[e.Type] {
+			return false
+		}
+		return courseID == "" || eventCourseID(e) == courseID
+	}
+
+	cursor := ctx.Get("Last-Event-ID", ctx.Query("last_event_id"))
+	sub, backlog, resumed, err := handler.hub.Subscribe(cursor, filter)
+	if errors.Is(err, events.ErrTooManySubscribers) {
+		ctx.Set(fiber.HeaderRetryAfter, "5")
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "too many stream subscribers",
+		})
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	// Keeps reverse proxies such as nginx from buffering the stream.
+	ctx.Set("X-Accel-Buffering", "no")
+
+	heartbeat := handler.heartbeat
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer handler.hub.Unsubscribe(sub)
+
+		fmt.Fprintf(w, "retry: %d\n\n", (3 * time.Second).Milliseconds())
+		if cursor != "" && !resumed {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, p := range backlog {
+			writeStreamEvent(w, p)
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case p, ok := <-sub.C:
+				if !ok {
+					// Dropped for falling behind; the client resumes with its cursor.
+					return
+				}
+				writeStreamEvent(w, p)
+			case <-ticker.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+			}
+			if w.Flush() != nil {
+				// The client went away.
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeStreamEvent(w *bufio.Writer, p events.Positioned) {
+	data, _ := json.Marshal(p.Event)
+	fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", p.Cursor, p.Event.Type, data)
+}
+
+// eventCourseID returns the course an event is about.
+func eventCourseID(e events.Event) string {
+	switch e.AggregateType {
+	case events.AggregateCourse:
+		return e.AggregateID
+	case events.AggregateEnrollment:
+		var data struct {
+			CourseID string `json:"course_id"`
+		}
+		_ = json.Unmarshal(e.Data, &data)
+		return data.CourseID
+	}
+	return ""
+}
@@ -0,0 +1,127 @@
+package handlers_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+)
+
+func TestExportCourses200_CSV(t *testing.T) {
+	app, db := setupAll(t)
+	course := domain.Course{Title: "export-" + uuid.NewString(), Description: "with, comma"}
+	if err := db.Create(&course).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/courses:export", nil), -1)
+	if err != nil {
+		t.Fatalf("Failed TestExportCourses200_CSV request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusOK)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("Content-Type=%q want text/csv", ct)
+	}
+
+	records, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if strings.Join(records[0], ",") != "id,title,description,created_at" {
+		t.Fatalf("Unexpected header: %v", records[0])
+	}
+
+	for _, r := range records[1:] {
+		if r[0] == course.ID && r[1] == course.Title && r[2] == course.Description {
+			return
+		}
+	}
+	t.Fatalf("Course %s not exported", course.ID)
+}
+
+func TestExportCourses200_NDJSONFromAccept(t *testing.T) {
+	app, db := setupAll(t)
+	course := domain.Course{Title: "export-" + uuid.NewString()}
+	if err := db.Create(&course).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/courses:export", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Failed TestExportCourses200_NDJSONFromAccept request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	found := false
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+
+		var got domain.Course
+		if err := json.Unmarshal(line, &got); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", line, err)
+		}
+		found = found || got.ID == course.ID
+	}
+
+	if !found {
+		t.Fatalf("Course %s not exported", course.ID)
+	}
+}
+
+func TestExportCourses400_InvalidFormat(t *testing.T) {
+	app, _ := setupAll(t)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/courses:export?format=xlsx", nil))
+	if err != nil {
+		t.Fatalf("Failed TestExportCourses400_InvalidFormat request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestExportCourses200_StreamsFromTheService(t *testing.T) {
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/courses\\:export", h.ExportCourses)
+
+	mock.ExpectQuery("SELECT \\* FROM `courses` ORDER BY created_at, id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at"}).
+			AddRow(negotiatedID, "Go basics", "Intro, with a comma", time.Date(2025, 8, 20, 15, 4, 5, 0, time.UTC)))
+
+	_, body := send(t, app, httptest.NewRequest("GET", "/courses:export?format=ndjson", nil), http.StatusOK)
+	if !strings.Contains(string(body), `"title":"Go basics"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
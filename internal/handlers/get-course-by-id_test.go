@@ -100,3 +100,49 @@ func TestGetCourseByID_500_DBError(t *testing.T) {
 		t.Errorf("Expected status code 500, got %d", resp.StatusCode)
 	}
 }
+
+func TestGetCourseByID_200_IncludeModules(t *testing.T) {
+	app, db := setupAll(t)
+	course := mustCreateCourse(t, db, "test-"+gofakeit.Sentence(4))
+
+	modules := []domain.CourseModule{
+		{CourseID: course.ID, Title: "Setup", Position: 1},
+		{CourseID: course.ID, Title: "Types", Position: 2},
+	}
+	if err := db.Create(&modules).Error; err != nil {
+		t.Fatalf("Failed to create modules: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/courses/"+course.ID+"?fields=title&include=modules,instructor", nil)
+	resp, err := app.Test(req)
+
+	if err != nil {
+		t.Fatalf("Failed to TestGetCourseByID_200_IncludeModules test request: %v", err)
+	}
+
+	if resp.StatusCode != 200 {
+		t.Fatalf("Expected status code 200, got %d", resp.StatusCode)
+	}
+
+	var out struct {
+		Course map[string]json.RawMessage `json:"course"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	var got []domain.CourseModule
+	if err := json.Unmarshal(out.Course["modules"], &got); err != nil {
+		t.Fatalf("Decode modules: %v", err)
+	}
+	if len(got) != 2 || got[0].Title != "Setup" || got[1].Title != "Types" {
+		t.Fatalf("Unexpected modules: %#v", got)
+	}
+	if string(out.Course["instructor"]) != "null" {
+		t.Fatalf("Unexpected instructor: %s", out.Course["instructor"])
+	}
+	if _, ok := out.Course["description"]; ok {
+		t.Fatalf("Unexpected description in sparse response: %#v", out.Course)
+	}
+}
@@ -112,3 +112,54 @@ func TestGetCourses500_InternalServerError(t *testing.T) {
 		t.Fatalf("expected non-empty error message")
 	}
 }
+
+func TestGetCourses200_SparseFields(t *testing.T) {
+	t.Helper()
+	app, _ := setupAll(t)
+
+	req := httptest.NewRequest("GET", "/courses?fields=id,title&include=enrollments_count", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to TestGetCourses200_SparseFields request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Failed to TestGetCourses200_SparseFields status=%d want=%d", resp.StatusCode, http.StatusOK)
+	}
+
+	var out struct {
+		Data []map[string]any `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	for _, course := range out.Data {
+		if _, ok := course["description"]; ok {
+			t.Fatalf("Unexpected description in sparse response: %#v", course)
+		}
+		for _, key := range []string{"id", "title", "enrollments_count"} {
+			if _, ok := course[key]; !ok {
+				t.Fatalf("Missing key %q in response: %#v", key, course)
+			}
+		}
+	}
+}
+
+func TestGetCourses400_UnknownField(t *testing.T) {
+	t.Helper()
+	app, _ := setupAll(t)
+
+	req := httptest.NewRequest("GET", "/courses?fields=id,password", nil)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to TestGetCourses400_UnknownField request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Failed to TestGetCourses400_UnknownField status=%d want=%d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
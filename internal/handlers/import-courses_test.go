@@ -0,0 +1,278 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"github.com/guycanella/api-courses-golang/internal/tasks"
+)
+
+func postImport(t *testing.T, app *fiber.App, url, contentType, body string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", url, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Failed import request: %v", err)
+	}
+
+	return resp
+}
+
+func decodeReport(t *testing.T, resp *http.Response) service.ImportReport {
+	t.Helper()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusOK)
+	}
+
+	var report service.ImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	return report
+}
+
+func TestImportCourses200_CSVUpsertsByTitle(t *testing.T) {
+	app, db := setupAll(t)
+	existing := domain.Course{Title: "import-" + uuid.NewString(), Description: "old description"}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	created := "import-" + uuid.NewString()
+
+	csv := "title,description\n" +
+		existing.Title + ",new description\n" +
+		created + ",\"brand new, with comma\"\n" +
+		"ab,too short title\n" +
+		created + ",duplicate in file\n"
+
+	report := decodeReport(t, postImport(t, app, "/courses:import", "text/csv", csv))
+
+	if report.Total != 4 || report.Created != 1 || report.Updated != 1 || report.Failed != 2 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if report.Errors[0].Line != 4 || report.Errors[0].Errors["title"] != "must be at least 3 characters" {
+		t.Fatalf("Unexpected row error: %+v", report.Errors[0])
+	}
+
+	var got domain.Course
+	if err := db.First(&got, "id = ?", existing.ID).Error; err != nil || got.Description != "new description" {
+		t.Fatalf("Course not updated: %+v err=%v", got, err)
+	}
+	if err := db.First(&got, "title = ?", created).Error; err != nil || got.Description != "brand new, with comma" {
+		t.Fatalf("Course not created: %+v err=%v", got, err)
+	}
+}
+
+func TestImportCourses200_DryRunWritesNothing(t *testing.T) {
+	app, db := setupAll(t)
+	title := "import-" + uuid.NewString()
+
+	report := decodeReport(t, postImport(t, app, "/courses:import?dry_run=true", "application/x-ndjson",
+		fmt.Sprintf("{\"title\":%q,\"description\":\"dry run\"}\n{not json\n", title)))
+
+	if !report.DryRun || report.Created != 1 || report.Failed != 1 || report.Errors[0].Error != "invalid JSON" {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	var n int64
+	db.Model(&domain.Course{}).Where("title = ?", title).Count(&n)
+	if n != 0 {
+		t.Fatalf("dry run created %d courses", n)
+	}
+}
+
+func TestImportCourses400_MissingTitleColumn(t *testing.T) {
+	app, _ := setupAll(t)
+	resp := postImport(t, app, "/courses:import", "text/csv", "name,description\nfoo,bar\n")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestImportCourses415_UnsupportedMediaType(t *testing.T) {
+	app, _ := setupAll(t)
+	resp := postImport(t, app, "/courses:import", "application/json", `[{"title":"x"}]`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestImportCourses202_AsyncJob(t *testing.T) {
+	app, _ := setupAll(t)
+	title := "import-" + uuid.NewString()
+
+	req := httptest.NewRequest("POST", "/courses:import?async=true", strings.NewReader("title\n"+title+"\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("Failed TestImportCourses202_AsyncJob request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	loc := resp.Header.Get("Location")
+	if !strings.HasPrefix(loc, "/courses:import/jobs/") {
+		t.Fatalf("Missing/invalid Location header: %q", loc)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := app.Test(httptest.NewRequest("GET", loc, nil))
+		if err != nil {
+			t.Fatalf("poll: %v", err)
+		}
+
+		var job handlers.ImportJobResponse
+		err = json.NewDecoder(resp.Body).Decode(&job)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+
+		if job.Status == domain.ImportJobSucceeded {
+			if job.Report == nil || job.Report.Created != 1 {
+				t.Fatalf("Unexpected report: %+v", job.Report)
+			}
+			return
+		}
+		if job.Status == domain.ImportJobFailed || time.Now().After(deadline) {
+			t.Fatalf("job did not succeed: %+v", job)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestImportCourses401And403_NeedsCoursesAdmin(t *testing.T) {
+	tokens, err := auth.ParseTokens("reader=tok2=courses:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.Authenticate(tokens))
+	app.Post("/courses\\:import", auth.RequireAny(auth.ScopeCoursesAdmin), h.ImportCourses)
+
+	// upserts by title overwrite existing courses, so importing is an admin write
+	req := httptest.NewRequest("POST", "/courses:import", strings.NewReader("title\nGo basics\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	send(t, app, req, http.StatusUnauthorized)
+
+	req = httptest.NewRequest("POST", "/courses:import", strings.NewReader("title\nGo basics\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Authorization", "Bearer tok2")
+	send(t, app, req, http.StatusForbidden)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestImportCourses413_OverTheLimit(t *testing.T) {
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db, handlers.WithJobs(jobs.New(db)), handlers.WithImportMaxBytes(8))
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Post("/courses\\:import", h.ImportCourses)
+
+	req := httptest.NewRequest("POST", "/courses:import", strings.NewReader("title\nGo basics\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	send(t, app, req, http.StatusRequestEntityTooLarge)
+
+	// a chunked body is cut off while it is saved, rolling back its job
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `course_import_jobs`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `course_import_chunks`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	req = httptest.NewRequest("POST", "/courses:import", strings.NewReader("title\nGo basics\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	req.ContentLength = -1
+	req.TransferEncoding = []string{"chunked"}
+	send(t, app, req, http.StatusRequestEntityTooLarge)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestGetImportJob200_WithReport(t *testing.T) {
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/courses\\:import/jobs/:jobId", h.GetImportJob)
+
+	mock.ExpectQuery("SELECT \\* FROM `course_import_jobs` WHERE id = \\?").
+		WithArgs(negotiatedID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "format", "report"}).
+			AddRow(negotiatedID, domain.ImportJobRunning, "csv", `{"total":500,"created":499,"failed":1,"errors":[]}`))
+	_, body := send(t, app, httptest.NewRequest("GET", "/courses:import/jobs/"+negotiatedID, nil), http.StatusOK)
+	if !strings.Contains(string(body), `"status":"running"`) || !strings.Contains(string(body), `"created":499`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+
+	mock.ExpectQuery("SELECT \\* FROM `course_import_jobs` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	send(t, app, httptest.NewRequest("GET", "/courses:import/jobs/"+negotiatedID, nil), http.StatusNotFound)
+	send(t, app, httptest.NewRequest("GET", "/courses:import/jobs/nope", nil), http.StatusBadRequest)
+}
+
+func TestImportCourses202_QueuesTheSavedJob(t *testing.T) {
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db, handlers.WithJobs(jobs.New(db)))
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Post("/courses\\:import", h.ImportCourses)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `course_import_jobs`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `course_import_chunks`").
+		WithArgs(sqlmock.AnyArg(), 0, []byte("title\nGo basics\n")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `jobs`").
+		WithArgs(sqlmock.AnyArg(), tasks.CourseImportKind, sqlmock.AnyArg(), "queued",
+			sqlmock.AnyArg(), 0, 5, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "", nil,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest("POST", "/courses:import?async=true", strings.NewReader("title\nGo basics\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	resp, body := send(t, app, req, http.StatusAccepted)
+	if !strings.Contains(string(body), `"status":"pending"`) || !strings.HasPrefix(resp.Header.Get("Location"), "/courses:import/jobs/") {
+		t.Fatalf("Unexpected response: %v %s", resp.Header, body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
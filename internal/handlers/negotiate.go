@@ -0,0 +1,458 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Media types of course reads and writes. JSON is listed first so it is
+// picked when Accept is missing or allows anything.
+const (
+	mediaJSON    = "application/json"
+	mediaXML     = "application/xml"
+	mediaMsgpack = "application/msgpack"
+	mediaCSV     = "text/csv"
+)
+
+var courseMediaTypes = []string{mediaJSON, mediaXML, mediaMsgpack, mediaCSV}
+
+// mediaAliases maps other names clients send to the types above.
+var mediaAliases = map[string]string{
+	"text/xml":                mediaXML,
+	"application/x-msgpack":   mediaMsgpack,
+	"application/vnd.msgpack": mediaMsgpack,
+	"application/csv":         mediaCSV,
+}
+
+var courseOffers = append(slices.Clone(courseMediaTypes),
+	"text/xml", "application/x-msgpack", "application/vnd.msgpack", "application/csv")
+
+// negotiate returns the media type to answer with, in the spelling the client
+// asked for, or "" after writing a 406.
+func negotiate(ctx *fiber.Ctx) string {
+	if accepted := ctx.Accepts(courseOffers...); accepted != "" {
+		return accepted
+	}
+
+	_ = ctx.Status(fiber.StatusNotAcceptable).JSON(fiber.Map{
+		"error":     "not acceptable",
+		"supported": courseMediaTypes,
+	})
+	return ""
+}
+
+// canonicalMedia resolves aliases and drops parameters.
+func canonicalMedia(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if canonical, ok := mediaAliases[mediaType]; ok {
+		return canonical
+	}
+	return mediaType
+}
+
+// field is one member of an encoded course, kept in output order.
+type field struct {
+	Name  string
+	Value any
+}
+
+// courseRecord flattens a view into its marshalled members: the course
+// fields in CourseFields order, then the included relations.
+func courseRecord(view service.CourseView) ([]field, error) {
+	b, err := json.Marshal(view)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var members map[string]any
+	if err := dec.Decode(&members); err != nil {
+		return nil, err
+	}
+
+	order := append(slices.Clone(service.CourseFields), view.Projection.Include...)
+	record := make([]field, 0, len(members))
+	for _, name := range order {
+		if value, ok := members[name]; ok {
+			record = append(record, field{Name: name, Value: plain(value)})
+		}
+	}
+	return record, nil
+}
+
+// plain turns JSON numbers back into integers or floats, so binary formats
+// keep their types.
+func plain(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for k, item := range v {
+			v[k] = plain(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = plain(item)
+		}
+	}
+	return value
+}
+
+func recordMap(record []field) map[string]any {
+	out := make(map[string]any, len(record))
+	for _, f := range record {
+		out[f.Name] = f.Value
+	}
+	return out
+}
+
+// sendCourse writes one course as mediaType.
+func sendCourse(ctx *fiber.Ctx, mediaType string, view service.CourseView) error {
+	if canonicalMedia(mediaType) == mediaJSON {
+		return ctx.JSON(fiber.Map{"course": view})
+	}
+
+	record, err := courseRecord(view)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	switch canonicalMedia(mediaType) {
+	case mediaXML:
+		err = writeXML(&body, func(enc *xml.Encoder) error {
+			return encodeXMLRecord(enc, "course", record)
+		})
+	case mediaMsgpack:
+		err = encodeMsgpack(&body, map[string]any{"course": recordMap(record)})
+	case mediaCSV:
+		err = writeCSV(&body, view.Projection, [][]field{record})
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, mediaType)
+	return ctx.Send(body.Bytes())
+}
+
+// sendCourses writes a page of courses projected with p as mediaType, with
+// its facets except in CSV. CSV is streamed after the handler returns, with
+// the page in X-Total-Count, X-Page and X-Limit.
+func sendCourses(ctx *fiber.Ctx, mediaType string, p service.CourseProjection, page service.CourseViewPage, pageNum, limit int) error {
+	if canonicalMedia(mediaType) == mediaJSON {
+		body := fiber.Map{
+			"data":  page.Data,
+			"page":  pageNum,
+			"limit": limit,
+			"total": page.Total,
+		}
+		if page.Facets != nil {
+			body["facets"] = page.Facets
+		}
+		return ctx.JSON(body)
+	}
+
+	facets, err := facetsValue(page.Facets)
+	if err != nil {
+		return err
+	}
+
+	records := make([][]field, len(page.Data))
+	for i, view := range page.Data {
+		record, err := courseRecord(view)
+		if err != nil {
+			return err
+		}
+		records[i] = record
+	}
+
+	ctx.Set(fiber.HeaderContentType, mediaType)
+
+	switch canonicalMedia(mediaType) {
+	case mediaXML:
+		start := xml.StartElement{Name: xml.Name{Local: "courses"}, Attr: []xml.Attr{
+			{Name: xml.Name{Local: "page"}, Value: strconv.Itoa(pageNum)},
+			{Name: xml.Name{Local: "limit"}, Value: strconv.Itoa(limit)},
+			{Name: xml.Name{Local: "total"}, Value: strconv.FormatInt(page.Total, 10)},
+		}}
+		var body bytes.Buffer
+		err := writeXML(&body, func(enc *xml.Encoder) error {
+			if err := enc.EncodeToken(start); err != nil {
+				return err
+			}
+			for _, record := range records {
+				if err := encodeXMLRecord(enc, "course", record); err != nil {
+					return err
+				}
+			}
+			if err := encodeXMLValue(enc, "facets", facets); err != nil {
+				return err
+			}
+			return enc.EncodeToken(start.End())
+		})
+		if err != nil {
+			return err
+		}
+		return ctx.Send(body.Bytes())
+
+	case mediaMsgpack:
+		data := make([]map[string]any, len(records))
+		for i, record := range records {
+			data[i] = recordMap(record)
+		}
+		out := map[string]any{"data": data, "page": pageNum, "limit": limit, "total": page.Total}
+		if facets != nil {
+			out["facets"] = facets
+		}
+		var body bytes.Buffer
+		if err := encodeMsgpack(&body, out); err != nil {
+			return err
+		}
+		return ctx.Send(body.Bytes())
+	}
+
+	ctx.Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+	ctx.Set("X-Page", strconv.Itoa(pageNum))
+	ctx.Set("X-Limit", strconv.Itoa(limit))
+
+	reqCtx := context.WithoutCancel(ctx.UserContext())
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeCSV(w, p, records); err != nil {
+			obs.Logger(reqCtx).ErrorContext(reqCtx, "csv response failed", "error", err)
+		}
+	})
+	return nil
+}
+
+// facetsValue flattens facets like courseRecord does courses, or returns nil
+// without them.
+func facetsValue(facets *service.CourseFacets) (any, error) {
+	if facets == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(facets)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var value map[string]any
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return plain(value), nil
+}
+
+// csvColumns are the columns of a CSV course list: the selected fields, or
+// all of them, then enrollments_count when included.
+func csvColumns(p service.CourseProjection) []string {
+	columns := p.Fields
+	if len(columns) == 0 {
+		columns = service.CourseFields
+	}
+	if slices.Contains(p.Include, service.IncludeEnrollmentsCount) {
+		columns = append(slices.Clone(columns), service.IncludeEnrollmentsCount)
+	}
+	return columns
+}
+
+// csvIncludable reports whether the relations of p fit in CSV columns.
+func csvIncludable(p service.CourseProjection) bool {
+	for _, relation := range p.Include {
+		if relation != service.IncludeEnrollmentsCount {
+			return false
+		}
+	}
+	return true
+}
+
+func writeCSV(w io.Writer, p service.CourseProjection, records [][]field) error {
+	cw := csv.NewWriter(w)
+	columns := csvColumns(p)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for _, record := range records {
+		values := recordMap(record)
+		for i, column := range columns {
+			row[i] = ""
+			if value, ok := values[column]; ok && value != nil {
+				row[i] = fmt.Sprint(value)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeXML writes the XML declaration and the document body encodes.
+func writeXML(w io.Writer, body func(*xml.Encoder) error) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	if err := body(enc); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeXMLRecord(enc *xml.Encoder, name string, record []field) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, f := range record {
+		if err := encodeXMLValue(enc, f.Name, f.Value); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// encodeXMLValue writes value as an element; nulls are left out, objects
+// become child elements by key and arrays repeat their item element.
+func encodeXMLValue(enc *xml.Encoder, name string, value any) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		record := make([]field, len(keys))
+		for i, k := range keys {
+			record[i] = field{Name: k, Value: v[k]}
+		}
+		return encodeXMLRecord(enc, name, record)
+	case []any:
+		start := xml.StartElement{Name: xml.Name{Local: name}}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := encodeXMLValue(enc, xmlItemName(name), item); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	}
+
+	return enc.EncodeElement(fmt.Sprint(value), xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// xmlItemName is the element of one item of a list: modules holds module.
+func xmlItemName(list string) string {
+	if len(list) > 1 && list[len(list)-1] == 's' {
+		return list[:len(list)-1]
+	}
+	return "item"
+}
+
+func encodeMsgpack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetSortMapKeys(true)
+	return enc.Encode(v)
+}
+
+// errUnsupportedMediaType rejects a body in a type parseCourseInput does not
+// read.
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// courseXML is the XML body of a new course.
+type courseXML struct {
+	XMLName     xml.Name `xml:"course"`
+	Title       string   `xml:"title"`
+	Description string   `xml:"description"`
+}
+
+// parseCourseInput reads a new course in the body's Content-Type: JSON (also
+// when missing), XML, MessagePack or CSV with a header and one row.
+func parseCourseInput(ctx *fiber.Ctx) (service.CourseInput, error) {
+	var in service.CourseInput
+	body := ctx.Body()
+
+	contentType := ctx.Get(fiber.HeaderContentType)
+	if contentType == "" {
+		contentType = mediaJSON
+	}
+
+	switch canonicalMedia(contentType) {
+	case mediaJSON:
+		return in, json.Unmarshal(body, &in)
+	case mediaXML:
+		var doc courseXML
+		if err := xml.Unmarshal(body, &doc); err != nil {
+			return in, err
+		}
+		return service.CourseInput{Title: doc.Title, Description: doc.Description}, nil
+	case mediaMsgpack:
+		dec := msgpack.NewDecoder(bytes.NewReader(body))
+		dec.SetCustomStructTag("json")
+		return in, dec.Decode(&in)
+	case mediaCSV:
+		rows, err := service.NewRowReader(service.FormatCSV, bytes.NewReader(body))
+		if err != nil {
+			return in, err
+		}
+		row, err := rows.Next()
+		if err != nil {
+			return in, err
+		}
+		if row.Err != "" {
+			return in, errors.New(row.Err)
+		}
+		return row.Input, nil
+	}
+
+	return in, errUnsupportedMediaType
+}
+
+// mediaName names the format of a body for error messages.
+func mediaName(contentType string) string {
+	switch canonicalMedia(contentType) {
+	case mediaXML:
+		return "XML"
+	case mediaMsgpack:
+		return "MessagePack"
+	case mediaCSV:
+		return "CSV"
+	}
+	return "JSON"
+}
@@ -0,0 +1,238 @@
+package handlers_test
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+	"github.com/vmihailenco/msgpack/v5"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	negotiatedID         = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	negotiatedCategoryID = "3f1a2b4c-5d6e-4f70-8a9b-0c1d2e3f4a5b"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+func setupMock(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/courses", h.ListCourses)
+	app.Get("/courses/:courseId", h.GetCourseByID)
+	app.Post("/courses", h.CreateCourse)
+
+	return app, mock
+}
+
+func expectCoursePage(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT \\* FROM `categories` ORDER BY name").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow(negotiatedCategoryID, "Programming", "programming", nil))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `courses`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at"}).
+			AddRow(negotiatedID, "Go basics", "Intro, with a comma", time.Date(2025, 8, 20, 15, 4, 5, 0, time.UTC)))
+	mock.ExpectQuery("SELECT course_id, category_id FROM `course_categories`").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "category_id"}).AddRow(negotiatedID, negotiatedCategoryID))
+	mock.ExpectQuery("SELECT tags.name, COUNT\\(\\*\\) AS count FROM `course_tags`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("golang", 1))
+}
+
+// expectFreeSlug expects the lookups of the slugs a new course could clash
+// with, answering that none is taken.
+func expectFreeSlug(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT `slug` FROM `courses`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+	mock.ExpectQuery("SELECT `slug` FROM `course_slugs`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+}
+
+func send(t *testing.T, app *fiber.App, req *http.Request, wantStatus int) (*http.Response, []byte) {
+	t.Helper()
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: status=%d want=%d body=%s", req.Method, req.URL, resp.StatusCode, wantStatus, body)
+	}
+	return resp, body
+}
+
+func TestListCourses_XML(t *testing.T) {
+	app, mock := setupMock(t)
+	expectCoursePage(mock)
+
+	req := httptest.NewRequest("GET", "/courses", nil)
+	req.Header.Set("Accept", "application/xml")
+	resp, body := send(t, app, req, http.StatusOK)
+
+	if got := resp.Header.Get("Content-Type"); got != "application/xml" {
+		t.Fatalf("Content-Type=%q", got)
+	}
+
+	var out struct {
+		XMLName xml.Name `xml:"courses"`
+		Total   int64    `xml:"total,attr"`
+		Courses []struct {
+			ID    string `xml:"id"`
+			Title string `xml:"title"`
+		} `xml:"course"`
+	}
+	if err := xml.Unmarshal(body, &out); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, body)
+	}
+	if out.Total != 1 || len(out.Courses) != 1 || out.Courses[0].Title != "Go basics" {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
+
+func TestListCourses_CSV(t *testing.T) {
+	app, mock := setupMock(t)
+	expectCoursePage(mock)
+
+	req := httptest.NewRequest("GET", "/courses", nil)
+	req.Header.Set("Accept", "text/csv")
+	resp, body := send(t, app, req, http.StatusOK)
+
+	if got := resp.Header.Get("X-Total-Count"); got != "1" {
+		t.Fatalf("X-Total-Count=%q", got)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if len(records) != 2 || strings.Join(records[0], ",") != "id,title,description,instructor_id,created_at,slug" {
+		t.Fatalf("Unexpected CSV: %q", records)
+	}
+	if records[1][2] != "Intro, with a comma" || records[1][4] != "2025-08-20T15:04:05Z" {
+		t.Fatalf("Unexpected row: %q", records[1])
+	}
+}
+
+func TestListCourses_CSVRejectsNestedIncludes(t *testing.T) {
+	app, _ := setupMock(t)
+
+	req := httptest.NewRequest("GET", "/courses?include=modules", nil)
+	req.Header.Set("Accept", "text/csv")
+	send(t, app, req, http.StatusBadRequest)
+}
+
+func TestListCourses_406NotAcceptable(t *testing.T) {
+	app, _ := setupMock(t)
+
+	req := httptest.NewRequest("GET", "/courses", nil)
+	req.Header.Set("Accept", "image/png")
+	_, body := send(t, app, req, http.StatusNotAcceptable)
+
+	if !strings.Contains(string(body), "application/msgpack") {
+		t.Fatalf("Expected the supported types, got %s", body)
+	}
+}
+
+func TestGetCourseByID_MessagePack(t *testing.T) {
+	app, mock := setupMock(t)
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description"}).
+			AddRow(negotiatedID, "Go basics", "Intro"))
+
+	req := httptest.NewRequest("GET", "/courses/"+negotiatedID, nil)
+	req.Header.Set("Accept", "application/x-msgpack;q=0.9, application/json;q=0.1")
+	resp, body := send(t, app, req, http.StatusOK)
+
+	if got := resp.Header.Get("Content-Type"); got != "application/x-msgpack" {
+		t.Fatalf("Content-Type=%q", got)
+	}
+
+	var out struct {
+		Course struct {
+			ID    string `msgpack:"id"`
+			Title string `msgpack:"title"`
+		} `msgpack:"course"`
+	}
+	if err := msgpack.Unmarshal(body, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.Course.ID != negotiatedID || out.Course.Title != "Go basics" {
+		t.Fatalf("Unexpected course: %+v", out.Course)
+	}
+}
+
+func TestCreateCourse_NegotiatesContentType(t *testing.T) {
+	msgpackBody, err := msgpack.Marshal(map[string]string{"title": "Go basics", "description": "Intro"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	bodies := map[string]string{
+		"application/xml":     `<course><title>Go basics</title><description>Intro</description></course>`,
+		"application/msgpack": string(msgpackBody),
+		"text/csv":            "title,description\nGo basics,Intro\n",
+	}
+
+	for contentType, body := range bodies {
+		t.Run(contentType, func(t *testing.T) {
+			app, mock := setupMock(t)
+			mock.ExpectBegin()
+			expectFreeSlug(mock)
+			mock.ExpectExec("INSERT INTO `courses`").
+				WithArgs(sqlmock.AnyArg(), "Go basics", "go-basics", "Intro", sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectCommit()
+
+			req := httptest.NewRequest("POST", "/courses", strings.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			send(t, app, req, http.StatusCreated)
+		})
+	}
+}
+
+func TestCreateCourse_415UnsupportedMediaType(t *testing.T) {
+	app, _ := setupMock(t)
+
+	req := httptest.NewRequest("POST", "/courses", strings.NewReader("title=Go"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	send(t, app, req, http.StatusUnsupportedMediaType)
+}
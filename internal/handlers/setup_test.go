@@ -1,16 +1,26 @@
 package handlers_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/guycanella/api-courses-golang/internal/auth"
 	"github.com/guycanella/api-courses-golang/internal/handlers"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
 	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"github.com/guycanella/api-courses-golang/internal/tasks"
+	"github.com/guycanella/api-courses-golang/internal/webhooks"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// adminToken holds every scope, for setupAll routes that need one.
+const adminToken = "admin-token"
+
 func setupAll(t *testing.T) (*fiber.App, *gorm.DB) {
 	t.Helper()
 
@@ -21,12 +31,39 @@ func setupAll(t *testing.T) (*fiber.App, *gorm.DB) {
 	}
 	db = db.Session(&gorm.Session{Logger: logger.Default.LogMode(logger.Silent)})
 
+	tokens, err := auth.ParseTokens("admin=" + adminToken + "=courses:read,courses:admin,enrollments:read,webhooks:admin")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	// a worker for background imports only
+	imports := jobs.New(db)
+	tasks.RegisterCourseImport(imports, service.NewCourseService(mysqlrepo.NewStore(db)))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go imports.Run(ctx, jobs.WorkerConfig{Concurrency: 1, PollInterval: 50 * time.Millisecond, JobTimeout: time.Minute, LockTimeout: 5 * time.Minute})
+
 	app := fiber.New(fiber.Config{DisableStartupMessage: true})
-	h := handlers.NewCoursesHandler(db)
+	app.Use(auth.Authenticate(tokens))
+	h := handlers.NewCoursesHandler(db, handlers.WithImportAsyncThreshold(64*1024), handlers.WithJobs(imports))
 
 	app.Post("/courses", h.CreateCourse)
 	app.Get("/courses/:courseId", h.GetCourseByID)
 	app.Get("/courses", h.ListCourses)
+	app.Post("/courses\\:import", auth.RequireAny(auth.ScopeCoursesAdmin), h.ImportCourses)
+	app.Get("/courses\\:import/jobs/:jobId", h.GetImportJob)
+	app.Get("/courses\\:export", h.ExportCourses)
+
+	hooks := webhooks.New(db, jobs.New(db), webhooks.Config{Timeout: 5 * time.Second, MaxAttempts: 3, DisableAfter: 3, AllowPrivate: true})
+	wh := handlers.NewWebhooksHandler(db, hooks)
+	admin := auth.RequireAny(auth.ScopeWebhooksAdmin)
+	app.Post("/webhooks", admin, wh.CreateWebhook)
+	app.Get("/webhooks", admin, wh.ListWebhooks)
+	app.Get("/webhooks/:webhookId", admin, wh.GetWebhook)
+	app.Patch("/webhooks/:webhookId", admin, wh.UpdateWebhook)
+	app.Delete("/webhooks/:webhookId", admin, wh.DeleteWebhook)
+	app.Get("/webhooks/:webhookId/deliveries", admin, wh.ListWebhookDeliveries)
+	app.Post("/webhooks/:webhookId/deliveries/:deliveryId/redeliver", admin, wh.RedeliverWebhook)
 
 	return app, db
 }
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/httpx"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"gorm.io/gorm"
+)
+
+type TagsHandler struct {
+	tags *service.TagService
+}
+
+// NewTagsHandler returns the handler. Writes invalidate the course lists
+// cached in c.
+func NewTagsHandler(db *gorm.DB, c *cache.Cache) *TagsHandler {
+	return &TagsHandler{tags: service.NewTagService(mysqlrepo.NewStore(db), c)}
+}
+
+// ListTags godoc
+// @Summary      List tags
+// @Tags         tags
+// @Produce      json
+// @Param        page   query     int  false  "Page (>=1)"              minimum(1) default(1)
+// @Param        limit  query     int  false  "Items per page [1..100]" minimum(1) maximum(100) default(10)
+// @Success      200    {object}  handlers.TagsResponse
+// @Failure      400    {object}  handlers.ErrorResponse
+// @Failure      429    {object}  handlers.ErrorResponse
+// @Failure      500    {object}  handlers.ErrorResponse
+// @Router       /v1/tags [get]
+func (handler *TagsHandler) ListTags(ctx *fiber.Ctx) error {
+	page, limit, err := pagination(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	result, err := handler.tags.List(ctx.UserContext(), service.Page{Page: page, Limit: limit})
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data":  result.Data,
+		"page":  page,
+		"limit": limit,
+		"total": result.Total,
+	})
+}
+
+// GetTag godoc
+// @Summary      Get tag
+// @Description  Finds the tag by name in any form: "Web Dev" finds web-dev.
+// @Tags         tags
+// @Produce      json
+// @Param        tag  path      string  true  "Tag name"
+// @Success      200  {object}  handlers.TagResponse
+// @Failure      404  {object}  handlers.ErrorResponse
+// @Failure      429  {object}  handlers.ErrorResponse
+// @Failure      500  {object}  handlers.ErrorResponse
+// @Router       /v1/tags/{tag} [get]
+func (handler *TagsHandler) GetTag(ctx *fiber.Ctx) error {
+	tag, err := handler.tags.Get(ctx.UserContext(), tagParam(ctx))
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"tag": tag})
+}
+
+// CreateTag godoc
+// @Summary      Create tag
+// @Description  Creates a tag. The name is stored lowercase in slug form: "Web Dev" is web-dev.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      handlers.TagDTO  true  "New tag"
+// @Success      201      {object}  handlers.TagResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      401      {object}  handlers.ErrorResponse
+// @Failure      403      {object}  handlers.ErrorResponse
+// @Failure      409      {object}  handlers.ErrorResponse
+// @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/tags [post]
+func (handler *TagsHandler) CreateTag(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "tags.create")
+	defer span.End()
+
+	var Body service.TagInput
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	tag, err := handler.tags.Create(ctx.UserContext(), Body)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	ctx.Location(apiversion.URL(ctx, "/tags/"+tag.Name))
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{"tag": tag})
+}
+
+// RenameTag godoc
+// @Summary      Rename tag
+// @Description  Renames the tag, keeping its courses.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        tag      path      string           true  "Tag name"
+// @Param        payload  body      handlers.TagDTO  true  "New name"
+// @Success      200      {object}  handlers.TagResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      401      {object}  handlers.ErrorResponse
+// @Failure      403      {object}  handlers.ErrorResponse
+// @Failure      404      {object}  handlers.ErrorResponse
+// @Failure      409      {object}  handlers.ErrorResponse
+// @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/tags/{tag} [patch]
+func (handler *TagsHandler) RenameTag(ctx *fiber.Ctx) error {
+	var Body service.TagInput
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	tag, err := handler.tags.Rename(ctx.UserContext(), tagParam(ctx), Body)
+	if err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{"tag": tag})
+}
+
+// DeleteTag godoc
+// @Summary      Delete tag
+// @Description  Removes the tag from its courses and deletes it.
+// @Tags         tags
+// @Param        tag  path  string  true  "Tag name"
+// @Success      204
+// @Failure      401  {object}  handlers.ErrorResponse
+// @Failure      403  {object}  handlers.ErrorResponse
+// @Failure      404  {object}  handlers.ErrorResponse
+// @Failure      429  {object}  handlers.ErrorResponse
+// @Failure      500  {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/tags/{tag} [delete]
+func (handler *TagsHandler) DeleteTag(ctx *fiber.Ctx) error {
+	if _, err := handler.tags.Delete(ctx.UserContext(), tagParam(ctx)); err != nil {
+		return taxonomyError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// tagParam returns the unescaped tag path parameter.
+func tagParam(ctx *fiber.Ctx) string {
+	name := ctx.Params("tag")
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+	return name
+}
@@ -0,0 +1,174 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+)
+
+const childCategoryID = "5b6c7d8e-9f00-4112-a334-556677889900"
+
+// setupTaxonomy serves the category and tag routes, with the writes behind
+// the scope check of cmd/api, over a mocked database.
+func setupTaxonomy(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+
+	tokens, err := auth.ParseTokens("admin=tok1=courses:admin;reader=tok2=courses:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	db, mock := openMockDB(t)
+	h := handlers.NewCoursesHandler(db)
+	ch := handlers.NewCategoriesHandler(db, nil)
+	th := handlers.NewTagsHandler(db, nil)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.Authenticate(tokens))
+	admin := auth.RequireAny(auth.ScopeCoursesAdmin)
+	app.Get("/courses", h.ListCourses)
+	app.Put("/courses/:courseId/categories", admin, h.SetCourseCategories)
+	app.Put("/courses/:courseId/tags", admin, h.SetCourseTags)
+	app.Post("/categories", admin, ch.CreateCategory)
+	app.Patch("/categories/:categoryId", admin, ch.UpdateCategory)
+	app.Delete("/categories/:categoryId", admin, ch.DeleteCategory)
+	app.Post("/tags", admin, th.CreateTag)
+	app.Patch("/tags/:tag", admin, th.RenameTag)
+	app.Delete("/tags/:tag", admin, th.DeleteTag)
+
+	return app, mock
+}
+
+var taxonomyWriteRoutes = []struct{ method, path string }{
+	{"PUT", "/courses/" + negotiatedID + "/categories"},
+	{"PUT", "/courses/" + negotiatedID + "/tags"},
+	{"POST", "/categories"},
+	{"PATCH", "/categories/" + negotiatedCategoryID},
+	{"DELETE", "/categories/" + negotiatedCategoryID},
+	{"POST", "/tags"},
+	{"PATCH", "/tags/golang"},
+	{"DELETE", "/tags/golang"},
+}
+
+func TestTaxonomyWrites401_Anonymous(t *testing.T) {
+	app, mock := setupTaxonomy(t)
+
+	for _, route := range taxonomyWriteRoutes {
+		resp, _ := send(t, app, httptest.NewRequest(route.method, route.path, nil), http.StatusUnauthorized)
+		if resp.Header.Get("WWW-Authenticate") != "Bearer" {
+			t.Fatalf("%s %s: WWW-Authenticate=%q", route.method, route.path, resp.Header.Get("WWW-Authenticate"))
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestTaxonomyWrites403_WithoutAdminScope(t *testing.T) {
+	app, mock := setupTaxonomy(t)
+
+	for _, route := range taxonomyWriteRoutes {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer tok2")
+		send(t, app, req, http.StatusForbidden)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestListCourses_FiltersByCategoryAndTagWithFacets(t *testing.T) {
+	app, mock := setupTaxonomy(t)
+	mock.ExpectQuery("SELECT \\* FROM `categories` ORDER BY name").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow(negotiatedCategoryID, "Programming", "programming", nil).
+			AddRow(childCategoryID, "Backend", "backend", negotiatedCategoryID))
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses`").
+		WithArgs(negotiatedCategoryID, childCategoryID, "golang").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `courses`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(negotiatedID, "Go basics"))
+	mock.ExpectQuery("SELECT course_id, category_id FROM `course_categories`").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "category_id"}).AddRow(negotiatedID, childCategoryID))
+	mock.ExpectQuery("SELECT tags.name, COUNT\\(\\*\\) AS count FROM `course_tags`").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("golang", 1).AddRow("beginner", 1))
+
+	req := httptest.NewRequest("GET", "/courses?category=programming&tag=Golang", nil)
+	_, body := send(t, app, req, http.StatusOK)
+
+	var out handlers.CoursesResponse
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("Unmarshal: %v\n%s", err, body)
+	}
+	if out.Total != 1 || out.Facets == nil {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+	if len(out.Facets.Categories) != 2 || out.Facets.Categories[0].Count != 1 || out.Facets.Categories[1].Count != 1 {
+		t.Fatalf("Unexpected category facets: %+v", out.Facets.Categories)
+	}
+	if len(out.Facets.Tags) != 2 || out.Facets.Tags[0].Name != "beginner" {
+		t.Fatalf("Unexpected tag facets: %+v", out.Facets.Tags)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestCreateTag201_StoresSlugForm(t *testing.T) {
+	app, mock := setupTaxonomy(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `tags`").
+		WithArgs(sqlmock.AnyArg(), "web-dev", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest("POST", "/tags", strings.NewReader(`{"name":"  Web Dev "}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer tok1")
+	resp, body := send(t, app, req, http.StatusCreated)
+
+	if got := resp.Header.Get("Location"); got != "/tags/web-dev" {
+		t.Fatalf("Location=%q", got)
+	}
+	if !strings.Contains(string(body), `"name":"web-dev"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
+
+func TestDeleteCategory409_HasSubcategories(t *testing.T) {
+	app, mock := setupTaxonomy(t)
+	mock.ExpectQuery("SELECT \\* FROM `categories` ORDER BY name").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow(negotiatedCategoryID, "Programming", "programming", nil).
+			AddRow(childCategoryID, "Backend", "backend", negotiatedCategoryID))
+
+	req := httptest.NewRequest("DELETE", "/categories/"+negotiatedCategoryID, nil)
+	req.Header.Set("Authorization", "Bearer tok1")
+	_, body := send(t, app, req, http.StatusConflict)
+	if !strings.Contains(string(body), "category has subcategories") {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+
+	req = httptest.NewRequest("DELETE", "/categories/nope", nil)
+	req.Header.Set("Authorization", "Bearer tok1")
+	send(t, app, req, http.StatusBadRequest)
+}
+
+func TestSetCourseTags422_InvalidTag(t *testing.T) {
+	app, _ := setupTaxonomy(t)
+
+	req := httptest.NewRequest("PUT", "/courses/"+negotiatedID+"/tags", strings.NewReader(`{"tags":["go","!!"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer tok1")
+	_, body := send(t, app, req, http.StatusUnprocessableEntity)
+	if !strings.Contains(string(body), `invalid tag \"!!\"`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
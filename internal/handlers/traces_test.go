@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/obs/obstest"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestCreateCourse422_RecordsValidationEvents(t *testing.T) {
+	spans := obstest.Spans(t)
+	app, _ := setupAll(t)
+
+	req := httptest.NewRequest("POST", "/courses", bytes.NewReader([]byte(`{"title":"ab"}`)))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed TestCreateCourse422_RecordsValidationEvents request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	span := obstest.Find(t, spans, "courses.create")
+	if len(span.Events) != 1 || span.Events[0].Name != "validation.failed" {
+		t.Fatalf("Unexpected events: %+v", span.Events)
+	}
+	for _, kv := range span.Events[0].Attributes {
+		if kv.Key == obs.AttrValidationField && kv.Value.AsString() != "title" {
+			t.Fatalf("validation.field=%q want=title", kv.Value.AsString())
+		}
+	}
+}
+
+func TestGetCourseByID_SpanCarriesCourseID(t *testing.T) {
+	spans := obstest.Spans(t)
+	app, _ := setupAll(t)
+
+	id := uuid.NewString()
+	resp, err := app.Test(httptest.NewRequest("GET", "/courses/"+id, nil))
+	if err != nil {
+		t.Fatalf("Failed TestGetCourseByID_SpanCarriesCourseID request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	span := obstest.Find(t, spans, "courses.get")
+	if v, ok := obstest.Attr(span, obs.AttrCourseID); !ok || v.AsString() != id {
+		t.Fatalf("course.id=%v want=%s", v.AsString(), id)
+	}
+}
+
+func TestCreateCourse500_SetsErrorStatus(t *testing.T) {
+	spans := obstest.Spans(t)
+	app, db := setupAll(t)
+
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	body := []byte(`{"title":"ok-` + uuid.NewString() + `"}`)
+	req := httptest.NewRequest("POST", "/courses", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed TestCreateCourse500_SetsErrorStatus request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if span := obstest.Find(t, spans, "courses.create"); span.Status.Code != codes.Error {
+		t.Fatalf("status=%v want=Error", span.Status)
+	}
+}
@@ -0,0 +1,490 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/httpx"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/validation"
+	"github.com/guycanella/api-courses-golang/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+type WebhooksHandler struct {
+	db       *gorm.DB
+	webhooks *webhooks.Dispatcher
+}
+
+func NewWebhooksHandler(db *gorm.DB, d *webhooks.Dispatcher) *WebhooksHandler {
+	return &WebhooksHandler{db: db, webhooks: d}
+}
+
+type webhookInput struct {
+	URL        *string   `json:"url"         validate:"omitempty,max=2048"`
+	EventTypes *[]string `json:"event_types" validate:"omitempty,min=1"`
+	Secret     *string   `json:"secret"      validate:"omitempty,min=16,max=128"`
+	Active     *bool     `json:"active"`
+}
+
+// validateWebhook checks the fields present in in. With create, url and
+// event_types are required.
+func validateWebhook(ctx context.Context, in *webhookInput, create bool) map[string]string {
+	errs := make(map[string]string)
+	fail := func(field, rule, msg string) {
+		metrics.ValidationFailuresTotal.WithLabelValues("webhook", field).Inc()
+		obs.ValidationFailed(ctx, field, rule)
+		errs[field] = msg
+	}
+
+	for _, failure := range validation.Struct(ctx, in) {
+		fail(failure.Field, failure.Rule, failure.Message)
+	}
+
+	switch {
+	case in.URL == nil && create:
+		fail("url", "required", i18n.T(ctx, "is required"))
+	case in.URL != nil && errs["url"] == "":
+		if err := webhooks.ValidateURL(*in.URL); err != nil {
+			fail("url", "url", err.Error())
+		}
+	}
+
+	switch {
+	case in.EventTypes == nil && create:
+		fail("event_types", "required", i18n.T(ctx, "is required"))
+	case in.EventTypes != nil && errs["event_types"] == "":
+		for _, t := range *in.EventTypes {
+			if !isEventType(t) {
+				fail("event_types", "oneof", fmt.Sprintf("unknown event type %q", t))
+				break
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func isEventType(t string) bool {
+	for _, known := range events.Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEventScopes answers 403 when the principal could not read events of
+// one of types on the stream, as a subscription and its delivery log carry
+// them.
+func checkEventScopes(ctx *fiber.Ctx, types *[]string) (done bool, err error) {
+	if types == nil {
+		return false, nil
+	}
+
+	principal, _ := auth.FromCtx(ctx)
+	if t, missing := missingEventScope(principal, *types); missing {
+		return true, ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "missing scope for " + t,
+		})
+	}
+	return false, nil
+}
+
+// CreateWebhook godoc
+// @Summary      Create webhook subscription
+// @Description  Subscribes a URL to event types. Deliveries are signed with the secret (generated when omitted), which is only returned here. Requires webhooks:admin, plus the scope that shows each event type on the stream (courses:read, enrollments:read).
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      handlers.CreateWebhookDTO  true  "New subscription"
+// @Success      201      {object}  handlers.WebhookCreatedResponse
+// @Failure      400      {object}  handlers.ErrorResponse
+// @Failure      401      {object}  handlers.ErrorResponse
+// @Failure      403      {object}  handlers.ErrorResponse
+// @Failure      422      {object}  handlers.ValidationErrorResponse
+// @Failure      429      {object}  handlers.ErrorResponse
+// @Failure      500      {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks [post]
+func (handler *WebhooksHandler) CreateWebhook(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "webhooks.create")
+	defer span.End()
+
+	var Body webhookInput
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	if errs := validateWebhook(ctx.UserContext(), &Body, true); errs != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"errors": errs,
+		})
+	}
+	if done, err := checkEventScopes(ctx, Body.EventTypes); done {
+		return err
+	}
+
+	sub := domain.WebhookSubscription{
+		URL:        strings.TrimSpace(*Body.URL),
+		EventTypes: *Body.EventTypes,
+		Secret:     webhooks.NewSecret(),
+		Active:     true,
+	}
+	if Body.Secret != nil {
+		sub.Secret = *Body.Secret
+	}
+	if Body.Active != nil {
+		sub.Active = *Body.Active
+	}
+
+	if err := handler.db.WithContext(ctx.UserContext()).Create(&sub).Error; err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	ctx.Location(apiversion.URL(ctx, "/webhooks/"+sub.ID))
+	return ctx.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"webhook": sub,
+		"secret":  sub.Secret,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary      List webhook subscriptions
+// @Tags         webhooks
+// @Produce      json
+// @Param        page   query     int  false  "Page (>=1)"              minimum(1) default(1)
+// @Param        limit  query     int  false  "Items per page [1..100]" minimum(1) maximum(100) default(10)
+// @Success      200    {object}  handlers.WebhooksResponse
+// @Failure      400    {object}  handlers.ErrorResponse
+// @Failure      401    {object}  handlers.ErrorResponse
+// @Failure      403    {object}  handlers.ErrorResponse
+// @Failure      429    {object}  handlers.ErrorResponse
+// @Failure      500    {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks [get]
+func (handler *WebhooksHandler) ListWebhooks(ctx *fiber.Ctx) error {
+	page, limit, err := pagination(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var total int64
+	var subs []domain.WebhookSubscription
+	tx := handler.db.WithContext(ctx.UserContext()).Model(&domain.WebhookSubscription{})
+	if err := tx.Count(&total).Error; err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+	if err := tx.Order("created_at desc").Limit(limit).Offset((page - 1) * limit).Find(&subs).Error; err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data":  subs,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// GetWebhook godoc
+// @Summary      Get webhook subscription
+// @Tags         webhooks
+// @Produce      json
+// @Param        webhookId  path      string  true  "Subscription ID"  format(uuid)
+// @Success      200        {object}  handlers.WebhookResponse
+// @Failure      400        {object}  handlers.ErrorResponse
+// @Failure      401        {object}  handlers.ErrorResponse
+// @Failure      403        {object}  handlers.ErrorResponse
+// @Failure      404        {object}  handlers.ErrorResponse
+// @Failure      429        {object}  handlers.ErrorResponse
+// @Failure      500        {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks/{webhookId} [get]
+func (handler *WebhooksHandler) GetWebhook(ctx *fiber.Ctx) error {
+	sub, done, err := handler.find(ctx)
+	if done {
+		return err
+	}
+
+	return ctx.JSON(fiber.Map{"webhook": sub})
+}
+
+// UpdateWebhook godoc
+// @Summary      Update webhook subscription
+// @Description  Changes the URL, event types, secret or active flag. Setting active to true re-enables a disabled subscription and resets its failure count.
+// @Tags         webhooks
+// @Accept       json
+// @Produce      json
+// @Param        webhookId  path      string                     true  "Subscription ID"  format(uuid)
+// @Param        payload    body      handlers.UpdateWebhookDTO  true  "Fields to change"
+// @Success      200        {object}  handlers.WebhookResponse
+// @Failure      400        {object}  handlers.ErrorResponse
+// @Failure      401        {object}  handlers.ErrorResponse
+// @Failure      403        {object}  handlers.ErrorResponse
+// @Failure      404        {object}  handlers.ErrorResponse
+// @Failure      422        {object}  handlers.ValidationErrorResponse
+// @Failure      429        {object}  handlers.ErrorResponse
+// @Failure      500        {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks/{webhookId} [patch]
+func (handler *WebhooksHandler) UpdateWebhook(ctx *fiber.Ctx) error {
+	sub, done, err := handler.find(ctx)
+	if done {
+		return err
+	}
+	if done, err := checkEventScopes(ctx, &sub.EventTypes); done {
+		return err
+	}
+
+	var Body webhookInput
+	if err := ctx.BodyParser(&Body); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid JSON body",
+		})
+	}
+
+	if errs := validateWebhook(ctx.UserContext(), &Body, false); errs != nil {
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"errors": errs,
+		})
+	}
+	if done, err := checkEventScopes(ctx, Body.EventTypes); done {
+		return err
+	}
+
+	fields := map[string]any{}
+	if Body.URL != nil {
+		sub.URL = strings.TrimSpace(*Body.URL)
+		fields["url"] = sub.URL
+	}
+	if Body.EventTypes != nil {
+		sub.EventTypes = *Body.EventTypes
+		b, _ := json.Marshal(sub.EventTypes)
+		fields["event_types"] = string(b)
+	}
+	if Body.Secret != nil {
+		fields["secret"] = *Body.Secret
+	}
+	if Body.Active != nil {
+		sub.Active = *Body.Active
+		fields["active"] = sub.Active
+		if sub.Active {
+			sub.FailureCount, sub.DisabledAt, sub.DisabledReason = 0, nil, ""
+			fields["failure_count"], fields["disabled_at"], fields["disabled_reason"] = 0, nil, ""
+		}
+	}
+
+	if len(fields) > 0 {
+		if err := handler.db.WithContext(ctx.UserContext()).Model(&sub).Updates(fields).Error; err != nil {
+			return httpx.InternalServerError(ctx, err)
+		}
+	}
+
+	return ctx.JSON(fiber.Map{"webhook": sub})
+}
+
+// DeleteWebhook godoc
+// @Summary      Delete webhook subscription
+// @Description  Deletes the subscription and its delivery log. Pending deliveries are dropped.
+// @Tags         webhooks
+// @Param        webhookId  path  string  true  "Subscription ID"  format(uuid)
+// @Success      204
+// @Failure      400        {object}  handlers.ErrorResponse
+// @Failure      401        {object}  handlers.ErrorResponse
+// @Failure      403        {object}  handlers.ErrorResponse
+// @Failure      404        {object}  handlers.ErrorResponse
+// @Failure      429        {object}  handlers.ErrorResponse
+// @Failure      500        {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks/{webhookId} [delete]
+func (handler *WebhooksHandler) DeleteWebhook(ctx *fiber.Ctx) error {
+	sub, done, err := handler.find(ctx)
+	if done {
+		return err
+	}
+
+	err = handler.db.WithContext(ctx.UserContext()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", sub.ID).Delete(&domain.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&sub).Error
+	})
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// ListWebhookDeliveries godoc
+// @Summary      List webhook deliveries
+// @Description  Returns the delivery log of a subscription, newest first, with request and response snapshots.
+// @Tags         webhooks
+// @Produce      json
+// @Param        webhookId  path      string  true  "Subscription ID"  format(uuid)
+// @Param        page       query     int     false "Page (>=1)"              minimum(1) default(1)
+// @Param        limit      query     int     false "Items per page [1..100]" minimum(1) maximum(100) default(10)
+// @Success      200        {object}  handlers.WebhookDeliveriesResponse
+// @Failure      400        {object}  handlers.ErrorResponse
+// @Failure      401        {object}  handlers.ErrorResponse
+// @Failure      403        {object}  handlers.ErrorResponse
+// @Failure      404        {object}  handlers.ErrorResponse
+// @Failure      429        {object}  handlers.ErrorResponse
+// @Failure      500        {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks/{webhookId}/deliveries [get]
+func (handler *WebhooksHandler) ListWebhookDeliveries(ctx *fiber.Ctx) error {
+	sub, done, err := handler.find(ctx)
+	if done {
+		return err
+	}
+	if done, err := checkEventScopes(ctx, &sub.EventTypes); done {
+		return err
+	}
+
+	page, limit, err := pagination(ctx)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var total int64
+	var deliveries []domain.WebhookDelivery
+	tx := handler.db.WithContext(ctx.UserContext()).Model(&domain.WebhookDelivery{}).Where("subscription_id = ?", sub.ID)
+	if err := tx.Count(&total).Error; err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+	if err := tx.Order("created_at desc").Limit(limit).Offset((page - 1) * limit).Find(&deliveries).Error; err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"data":  deliveries,
+		"page":  page,
+		"limit": limit,
+		"total": total,
+	})
+}
+
+// RedeliverWebhook godoc
+// @Summary      Redeliver webhook
+// @Description  Sends the event of a logged delivery again, as a new delivery with its own retries.
+// @Tags         webhooks
+// @Produce      json
+// @Param        webhookId   path      string  true  "Subscription ID"  format(uuid)
+// @Param        deliveryId  path      string  true  "Delivery ID"      format(uuid)
+// @Success      202         {object}  handlers.RedeliverResponse
+// @Failure      400         {object}  handlers.ErrorResponse
+// @Failure      401         {object}  handlers.ErrorResponse
+// @Failure      403         {object}  handlers.ErrorResponse
+// @Failure      404         {object}  handlers.ErrorResponse
+// @Failure      409         {object}  handlers.ErrorResponse
+// @Failure      429         {object}  handlers.ErrorResponse
+// @Failure      500         {object}  handlers.ErrorResponse
+// @Security     BearerAuth
+// @Router       /v1/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (handler *WebhooksHandler) RedeliverWebhook(ctx *fiber.Ctx) error {
+	sub, done, err := handler.find(ctx)
+	if done {
+		return err
+	}
+	if done, err := checkEventScopes(ctx, &sub.EventTypes); done {
+		return err
+	}
+
+	deliveryId := ctx.Params("deliveryId")
+	if _, err := uuid.Parse(deliveryId); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid deliveryId",
+		})
+	}
+
+	var delivery domain.WebhookDelivery
+	err = handler.db.WithContext(ctx.UserContext()).First(&delivery, "id = ? AND subscription_id = ?", deliveryId, sub.ID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "delivery not found",
+			})
+		}
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	if !sub.Active {
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "webhook is disabled",
+		})
+	}
+
+	var e events.Event
+	if err := json.Unmarshal([]byte(delivery.RequestBody), &e); err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	job, err := handler.webhooks.Enqueue(ctx.UserContext(), sub.ID, e)
+	if err != nil {
+		return httpx.InternalServerError(ctx, err)
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(fiber.Map{"jobId": job.ID})
+}
+
+// find loads the subscription named by the webhookId parameter. When done is
+// true the response has been written and err must be returned.
+func (handler *WebhooksHandler) find(ctx *fiber.Ctx) (sub domain.WebhookSubscription, done bool, err error) {
+	webhookId := ctx.Params("webhookId")
+	if _, err := uuid.Parse(webhookId); err != nil {
+		return sub, true, ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid webhookId",
+		})
+	}
+
+	if err := handler.db.WithContext(ctx.UserContext()).First(&sub, "id = ?", webhookId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return sub, true, ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "webhook not found",
+			})
+		}
+		return sub, true, httpx.InternalServerError(ctx, err)
+	}
+
+	return sub, false, nil
+}
+
+// pagination reads page and limit like ListCourses.
+func pagination(ctx *fiber.Ctx) (page, limit int, err error) {
+	page, err = strconv.Atoi(ctx.Query("page", "1"))
+	if err != nil {
+		return 0, 0, errors.New("Invalid page")
+	}
+	limit, err = strconv.Atoi(ctx.Query("limit", "10"))
+	if err != nil {
+		return 0, 0, errors.New("Invalid limit")
+	}
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	return page, limit, nil
+}
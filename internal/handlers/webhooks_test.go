@@ -0,0 +1,238 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/handlers"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/webhooks"
+)
+
+// setupWebhooksAuth serves the webhook routes behind the scope check of
+// cmd/api, over a mocked database.
+func setupWebhooksAuth(t *testing.T) (*fiber.App, sqlmock.Sqlmock) {
+	t.Helper()
+
+	tokens, err := auth.ParseTokens("admin=tok1=webhooks:admin,courses:read;dashboard=tok2=courses:read,enrollments:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	db, mock := openMockDB(t)
+	hooks := webhooks.New(db, jobs.New(db), webhooks.Config{Timeout: 5 * time.Second, MaxAttempts: 3, DisableAfter: 3})
+	wh := handlers.NewWebhooksHandler(db, hooks)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(auth.Authenticate(tokens))
+	admin := auth.RequireAny(auth.ScopeWebhooksAdmin)
+	app.Post("/webhooks", admin, wh.CreateWebhook)
+	app.Get("/webhooks", admin, wh.ListWebhooks)
+	app.Get("/webhooks/:webhookId", admin, wh.GetWebhook)
+	app.Patch("/webhooks/:webhookId", admin, wh.UpdateWebhook)
+	app.Delete("/webhooks/:webhookId", admin, wh.DeleteWebhook)
+	app.Get("/webhooks/:webhookId/deliveries", admin, wh.ListWebhookDeliveries)
+	app.Post("/webhooks/:webhookId/deliveries/:deliveryId/redeliver", admin, wh.RedeliverWebhook)
+
+	return app, mock
+}
+
+var webhookRoutes = []struct{ method, path string }{
+	{"POST", "/webhooks"},
+	{"GET", "/webhooks"},
+	{"GET", "/webhooks/" + negotiatedID},
+	{"PATCH", "/webhooks/" + negotiatedID},
+	{"DELETE", "/webhooks/" + negotiatedID},
+	{"GET", "/webhooks/" + negotiatedID + "/deliveries"},
+	{"POST", "/webhooks/" + negotiatedID + "/deliveries/" + negotiatedID + "/redeliver"},
+}
+
+func TestWebhooks401_Anonymous(t *testing.T) {
+	app, mock := setupWebhooksAuth(t)
+
+	for _, route := range webhookRoutes {
+		resp, _ := send(t, app, httptest.NewRequest(route.method, route.path, nil), http.StatusUnauthorized)
+		if resp.Header.Get("WWW-Authenticate") != "Bearer" {
+			t.Fatalf("%s %s: WWW-Authenticate=%q", route.method, route.path, resp.Header.Get("WWW-Authenticate"))
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestWebhooks403_WithoutAdminScope(t *testing.T) {
+	app, mock := setupWebhooksAuth(t)
+
+	// Reading the stream does not grant reading what partners subscribed to.
+	for _, route := range webhookRoutes {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer tok2")
+		send(t, app, req, http.StatusForbidden)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestListWebhooks200_WithAdminScope(t *testing.T) {
+	app, mock := setupWebhooksAuth(t)
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `webhook_subscriptions`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT \\* FROM `webhook_subscriptions`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url"}))
+
+	req := httptest.NewRequest("GET", "/webhooks", nil)
+	req.Header.Set("Authorization", "Bearer tok1")
+	_, body := send(t, app, req, http.StatusOK)
+	if !strings.Contains(string(body), `"total":0`) {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+}
+
+func createWebhook(t *testing.T, body string) *http.Response {
+	t.Helper()
+	app, _ := setupAll(t)
+
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to create webhook request: %v", err)
+	}
+	return resp
+}
+
+func TestCreateWebhook201_ReturnsSecretOnce(t *testing.T) {
+	resp := createWebhook(t, `{"url":"https://partner.example.com/hooks","event_types":["course.created"]}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Failed to TestCreateWebhook201_ReturnsSecretOnce status=%d want=%d", resp.StatusCode, http.StatusCreated)
+	}
+
+	var out struct {
+		Webhook map[string]any `json:"webhook"`
+		Secret  string         `json:"secret"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !strings.HasPrefix(out.Secret, "whsec_") {
+		t.Fatalf("Expected a generated secret, got %q", out.Secret)
+	}
+	if _, ok := out.Webhook["secret"]; ok {
+		t.Fatal("The subscription must not expose its secret")
+	}
+	if out.Webhook["active"] != true {
+		t.Fatalf("Expected an active subscription: %v", out.Webhook)
+	}
+}
+
+func TestCreateWebhook422_Invalid(t *testing.T) {
+	resp := createWebhook(t, `{"url":"ftp://partner.example.com","event_types":["course.deleted"]}`)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Failed to TestCreateWebhook422_Invalid status=%d want=%d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	var out struct {
+		Errors map[string]string `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if out.Errors["url"] == "" || out.Errors["event_types"] == "" {
+		t.Fatalf("Expected url and event_types errors, got %v", out.Errors)
+	}
+}
+
+func TestRedeliverWebhook202_EnqueuesDelivery(t *testing.T) {
+	app, db := setupAll(t)
+
+	sub := domain.WebhookSubscription{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{events.TypeCourseCreated},
+		Secret:     "whsec_test",
+		Active:     true,
+	}
+	if err := db.Create(&sub).Error; err != nil {
+		t.Fatalf("Create subscription: %v", err)
+	}
+	e := events.CourseCreated(domain.Course{ID: "4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18", Title: "Go"})
+	body, _ := json.Marshal(e)
+	delivery := domain.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        e.ID,
+		EventType:      e.Type,
+		Attempt:        1,
+		RequestURL:     sub.URL,
+		RequestBody:    string(body),
+		ResponseStatus: http.StatusInternalServerError,
+	}
+	if err := db.Create(&delivery).Error; err != nil {
+		t.Fatalf("Create delivery: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/webhooks/"+sub.ID+"/deliveries/"+delivery.ID+"/redeliver", bytes.NewReader(nil))
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Failed to TestRedeliverWebhook202_EnqueuesDelivery request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Failed to TestRedeliverWebhook202_EnqueuesDelivery status=%d want=%d", resp.StatusCode, http.StatusAccepted)
+	}
+
+	var out struct {
+		JobID string `json:"jobId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.JobID == "" {
+		t.Fatalf("Expected a job id: %v", err)
+	}
+}
+
+func TestCreateWebhook403_EventTypeOutsideScopes(t *testing.T) {
+	app, mock := setupWebhooksAuth(t)
+
+	req := httptest.NewRequest("POST", "/webhooks", strings.NewReader(`{"url":"https://partner.example.com/hooks","event_types":["course.created","enrollment.created"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer tok1")
+	_, body := send(t, app, req, http.StatusForbidden)
+	if !strings.Contains(string(body), "missing scope for enrollment.created") {
+		t.Fatalf("Unexpected body: %s", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestListWebhookDeliveries403_SubscriptionOutsideScopes(t *testing.T) {
+	app, mock := setupWebhooksAuth(t)
+	mock.ExpectQuery("SELECT \\* FROM `webhook_subscriptions` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "event_types"}).
+			AddRow(negotiatedID, "https://partner.example.com/hooks", `["enrollment.created"]`))
+
+	// The log holds enrollment payloads, which this admin may not read.
+	req := httptest.NewRequest("GET", "/webhooks/"+negotiatedID+"/deliveries", nil)
+	req.Header.Set("Authorization", "Bearer tok1")
+	send(t, app, req, http.StatusForbidden)
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
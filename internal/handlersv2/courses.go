@@ -0,0 +1,194 @@
+// Package handlersv2 serves version 2 of the REST API. Every response has the
+// same envelope: {"data": ...} for a resource, {"data": [...], "meta": {...}}
+// for a page and {"error": {"code", "message", "fields"}} for failures.
+//
+// @title       Go API Courses
+// @version     2.0
+// @description API to manage courses, users and enrollments, with uniform response envelopes
+// @BasePath    /
+// @schemes     http
+// @host        localhost:3333
+//
+// @tag.name        courses
+// @tag.description Operations on courses
+//
+// @securityDefinitions.apikey BearerAuth
+// @in                         header
+// @name                       Authorization
+// @description                "Bearer <token>", with a token from AUTH_TOKENS
+package handlersv2
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/service"
+)
+
+// Error codes.
+const (
+	CodeInvalidInput = "invalid_input"
+	CodeNotFound     = "not_found"
+	CodeConflict     = "conflict"
+	CodeInternal     = "internal"
+)
+
+type CoursesHandler struct {
+	courses *service.CourseService
+}
+
+func NewCoursesHandler(courses *service.CourseService) *CoursesHandler {
+	return &CoursesHandler{courses: courses}
+}
+
+// ListCourses godoc
+// @Summary      Get courses
+// @Description  Returns paginated courses, with optional search by title.
+// @Tags         courses
+// @Produce      json
+// @Param        page   query     int    false  "Page (>=1)"              minimum(1) default(1)
+// @Param        limit  query     int    false  "Items per page [1..100]" minimum(1) maximum(100) default(10)
+// @Param        q      query     string false  "Title fragment"
+// @Success      200    {object}  handlersv2.CoursesResponse
+// @Failure      400    {object}  handlersv2.ErrorResponse
+// @Failure      429    {object}  handlersv2.ErrorResponse
+// @Failure      500    {object}  handlersv2.ErrorResponse
+// @Router       /v2/courses [get]
+func (handler *CoursesHandler) ListCourses(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.list")
+	defer span.End()
+
+	page, err := strconv.Atoi(ctx.Query("page", "1"))
+	if err != nil {
+		return invalid(ctx, fiber.StatusBadRequest, map[string]string{"page": "is invalid"})
+	}
+	limit, err := strconv.Atoi(ctx.Query("limit", "10"))
+	if err != nil {
+		return invalid(ctx, fiber.StatusBadRequest, map[string]string{"limit": "is invalid"})
+	}
+
+	in := service.ListCourses{Page: service.Page{Page: page, Limit: limit}, Query: ctx.Query("q")}
+	result, err := handler.courses.List(ctx.UserContext(), in)
+	if err != nil {
+		return fail(ctx, err)
+	}
+
+	// The service falls back to the defaults for out of range values.
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	out := CoursesResponse{
+		Data: make([]Course, 0, len(result.Data)),
+		Meta: PageMeta{Page: page, Limit: limit, Total: result.Total},
+	}
+	for _, course := range result.Data {
+		out.Data = append(out.Data, toCourse(course))
+	}
+	return ctx.JSON(out)
+}
+
+// GetCourseByID godoc
+// @Summary      Get course by ID or slug
+// @Description  A former slug of the course is redirected to the current one with 301.
+// @Tags         courses
+// @Produce      json
+// @Param        courseId  path      string  true  "Course ID or slug"
+// @Success      200       {object}  handlersv2.CourseResponse
+// @Success      301       {string}  string  "Location is the URL of the current slug"
+// @Failure      400       {object}  handlersv2.ErrorResponse
+// @Failure      404       {object}  handlersv2.ErrorResponse
+// @Failure      429       {object}  handlersv2.ErrorResponse
+// @Failure      500       {object}  handlersv2.ErrorResponse
+// @Router       /v2/courses/{courseId} [get]
+func (handler *CoursesHandler) GetCourseByID(ctx *fiber.Ctx) error {
+	courseId := ctx.Params("courseId")
+
+	span := obs.StartSpan(ctx, "courses.get", obs.AttrCourseID.String(courseId))
+	defer span.End()
+
+	id, moved, err := handler.courses.Resolve(ctx.UserContext(), courseId)
+	var invalidErr *service.ValidationError
+	switch {
+	case errors.As(err, &invalidErr):
+		return invalid(ctx, fiber.StatusBadRequest, map[string]string{"courseId": "is invalid"})
+	case err != nil:
+		return fail(ctx, err)
+	case moved != "":
+		return ctx.Redirect(apiversion.URL(ctx, "/courses/"+moved), fiber.StatusMovedPermanently)
+	}
+
+	course, err := handler.courses.Get(ctx.UserContext(), id)
+	if err != nil {
+		return fail(ctx, err)
+	}
+
+	return ctx.JSON(CourseResponse{Data: toCourse(course)})
+}
+
+// CreateCourse godoc
+// @Summary      Create course
+// @Description  Creates a course and returns it with the Location header.
+// @Tags         courses
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      handlersv2.CreateCourseDTO  true  "New course"
+// @Success      201      {object}  handlersv2.CourseResponse
+// @Failure      400      {object}  handlersv2.ErrorResponse
+// @Failure      409      {object}  handlersv2.ErrorResponse
+// @Failure      422      {object}  handlersv2.ErrorResponse
+// @Failure      429      {object}  handlersv2.ErrorResponse
+// @Failure      500      {object}  handlersv2.ErrorResponse
+// @Router       /v2/courses [post]
+func (handler *CoursesHandler) CreateCourse(ctx *fiber.Ctx) error {
+	span := obs.StartSpan(ctx, "courses.create")
+	defer span.End()
+
+	var body CreateCourseDTO
+	if err := ctx.BodyParser(&body); err != nil {
+		return reply(ctx, fiber.StatusBadRequest, Error{Code: CodeInvalidInput, Message: "invalid JSON body"})
+	}
+
+	course, err := handler.courses.Create(ctx.UserContext(), service.CourseInput{
+		Title:       body.Title,
+		Description: body.Description,
+	})
+	if err != nil {
+		return fail(ctx, err)
+	}
+
+	span.SetAttributes(obs.AttrCourseID.String(course.ID))
+	ctx.Location(apiversion.URL(ctx, "/courses/"+course.ID))
+	return ctx.Status(fiber.StatusCreated).JSON(CourseResponse{Data: toCourse(course)})
+}
+
+// fail maps a service error to its status and code.
+func fail(ctx *fiber.Ctx, err error) error {
+	var invalidErr *service.ValidationError
+	switch {
+	case errors.As(err, &invalidErr):
+		return invalid(ctx, fiber.StatusUnprocessableEntity, invalidErr.Fields)
+	case errors.Is(err, service.ErrNotFound):
+		return reply(ctx, fiber.StatusNotFound, Error{Code: CodeNotFound, Message: err.Error()})
+	case errors.Is(err, service.ErrConflict):
+		return reply(ctx, fiber.StatusConflict, Error{Code: CodeConflict, Message: err.Error()})
+	}
+
+	obs.Logger(ctx.UserContext()).ErrorContext(ctx.UserContext(), "500 internal error", "error", err)
+	obs.SpanError(ctx.UserContext(), err)
+	return reply(ctx, fiber.StatusInternalServerError, Error{Code: CodeInternal, Message: "internal server error"})
+}
+
+func invalid(ctx *fiber.Ctx, status int, fields map[string]string) error {
+	return reply(ctx, status, Error{Code: CodeInvalidInput, Message: "invalid input", Fields: fields})
+}
+
+func reply(ctx *fiber.Ctx, status int, e Error) error {
+	return ctx.Status(status).JSON(ErrorResponse{Error: e})
+}
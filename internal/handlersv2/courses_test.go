@@ -0,0 +1,180 @@
+package handlersv2_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/apiversion"
+	"github.com/guycanella/api-courses-golang/internal/handlersv2"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+// expectFreeSlug expects the lookups of the slugs a new course could clash
+// with, answering that none is taken.
+func expectFreeSlug(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT `slug` FROM `courses`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+	mock.ExpectQuery("SELECT `slug` FROM `course_slugs`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+}
+
+func testApp(db *gorm.DB) *fiber.App {
+	h := handlersv2.NewCoursesHandler(service.NewCourseService(mysqlrepo.NewStore(db)))
+
+	app := fiber.New()
+	apiversion.Mount(app, apiversion.Version{Name: "v2", Routes: func(r fiber.Router) {
+		r.Get("/courses", h.ListCourses)
+		r.Get("/courses/:courseId", h.GetCourseByID)
+		r.Post("/courses", h.CreateCourse)
+	}})
+	return app
+}
+
+func decode[T any](t *testing.T, app *fiber.App, method, path, body string, wantStatus int) (T, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != wantStatus {
+		t.Fatalf("%s %s: expected %d, got %d", method, path, wantStatus, resp.StatusCode)
+	}
+
+	var out T
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return out, resp.Header.Get("Location")
+}
+
+func TestGetCourseByID_InvalidID(t *testing.T) {
+	db, _ := openMockDB(t)
+
+	out, _ := decode[handlersv2.ErrorResponse](t, testApp(db), "GET", "/v2/courses/no_pe", "", fiber.StatusBadRequest)
+	if out.Error.Code != handlersv2.CodeInvalidInput || out.Error.Fields["courseId"] != "is invalid" {
+		t.Fatalf("Unexpected error: %#v", out.Error)
+	}
+}
+
+func TestGetCourseByID_NotFound(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at"}))
+
+	out, _ := decode[handlersv2.ErrorResponse](t, testApp(db), "GET", "/v2/courses/7c9e6679-7425-40de-944b-e07fc1f90ae7", "", fiber.StatusNotFound)
+	if out.Error.Code != handlersv2.CodeNotFound || out.Error.Message != "course not found" {
+		t.Fatalf("Unexpected error: %#v", out.Error)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestGetCourseByID_FormerSlugRedirects(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	mock.ExpectQuery("SELECT `id`,`slug` FROM `courses` WHERE slug = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}))
+	mock.ExpectQuery("SELECT courses.id, courses.slug FROM `courses` JOIN course_slugs").
+		WithArgs("go-basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow("7c9e6679-7425-40de-944b-e07fc1f90ae7", "go-fundamentals"))
+
+	resp, err := testApp(db).Test(httptest.NewRequest("GET", "/v2/courses/go-basics", nil))
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusMovedPermanently || resp.Header.Get("Location") != "/v2/courses/go-fundamentals" {
+		t.Fatalf("status=%d Location=%q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+}
+
+func TestListCourses_Envelope(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses`").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `courses`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description"}).
+			AddRow("7c9e6679-7425-40de-944b-e07fc1f90ae7", "Go basics", "Intro"))
+
+	out, _ := decode[handlersv2.CoursesResponse](t, testApp(db), "GET", "/v2/courses?limit=5", "", fiber.StatusOK)
+	if len(out.Data) != 1 || out.Data[0].Title != "Go basics" {
+		t.Fatalf("Unexpected data: %#v", out.Data)
+	}
+	if out.Meta != (handlersv2.PageMeta{Page: 1, Limit: 5, Total: 1}) {
+		t.Fatalf("Unexpected meta: %#v", out.Meta)
+	}
+}
+
+func TestCreateCourse_ValidationFields(t *testing.T) {
+	db, _ := openMockDB(t)
+
+	out, _ := decode[handlersv2.ErrorResponse](t, testApp(db), "POST", "/v2/courses", `{"title":"Go"}`, fiber.StatusUnprocessableEntity)
+	if out.Error.Code != handlersv2.CodeInvalidInput || out.Error.Fields["title"] != "must be at least 3 characters" {
+		t.Fatalf("Unexpected error: %#v", out.Error)
+	}
+}
+
+func TestCreateCourse_Conflict(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	mock.ExpectBegin()
+	expectFreeSlug(mock)
+	mock.ExpectExec("INSERT INTO `courses`").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	out, _ := decode[handlersv2.ErrorResponse](t, testApp(db), "POST", "/v2/courses", `{"title":"Go basics"}`, fiber.StatusConflict)
+	if out.Error.Code != handlersv2.CodeConflict {
+		t.Fatalf("Unexpected error: %#v", out.Error)
+	}
+}
+
+func TestCreateCourse_Location(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	mock.ExpectBegin()
+	expectFreeSlug(mock)
+	mock.ExpectExec("INSERT INTO `courses`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `outbox_events`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	out, location := decode[handlersv2.CourseResponse](t, testApp(db), "POST", "/v2/courses", `{"title":"Go basics"}`, fiber.StatusCreated)
+	if out.Data.ID == "" || out.Data.Title != "Go basics" {
+		t.Fatalf("Unexpected data: %#v", out.Data)
+	}
+	if location != "/v2/courses/"+out.Data.ID {
+		t.Fatalf("Unexpected Location: %q", location)
+	}
+}
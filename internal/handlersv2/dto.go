@@ -0,0 +1,56 @@
+package handlersv2
+
+import (
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+)
+
+type Course struct {
+	ID          string    `json:"id"          example:"4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"`
+	Title       string    `json:"title"       example:"Go para Iniciantes"`
+	Description string    `json:"description" example:"Curso introdutório de Go"`
+	Slug        string    `json:"slug,omitempty" example:"go-para-iniciantes"`
+	CreatedAt   time.Time `json:"created_at"  example:"2025-08-20T15:04:05Z"`
+}
+
+func toCourse(c domain.Course) Course {
+	course := Course{ID: c.ID, Title: c.Title, Description: c.Description, CreatedAt: c.CreatedAt}
+	if c.Slug != nil {
+		course.Slug = *c.Slug
+	}
+	return course
+}
+
+type CreateCourseDTO struct {
+	Title       string `json:"title"       example:"Curso de React"`
+	Description string `json:"description" example:"Alguma descrição"`
+}
+
+// CourseResponse is the envelope of a single resource.
+type CourseResponse struct {
+	Data Course `json:"data"`
+}
+
+// CoursesResponse is the envelope of a page of resources.
+type CoursesResponse struct {
+	Data []Course `json:"data"`
+	Meta PageMeta `json:"meta"`
+}
+
+type PageMeta struct {
+	Page  int   `json:"page"  example:"1"`
+	Limit int   `json:"limit" example:"10"`
+	Total int64 `json:"total" example:"42"`
+}
+
+type Error struct {
+	Code    string            `json:"code"             example:"not_found"`
+	Message string            `json:"message"          example:"course not found"`
+	Fields  map[string]string `json:"fields,omitempty" example:"{\"title\":\"must be at least 3 characters\"}"`
+}
+
+// ErrorResponse is the envelope of every error.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
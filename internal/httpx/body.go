@@ -0,0 +1,45 @@
+package httpx
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LimitBody answers 413 for request bodies over limit bytes. An app that
+// streams request bodies is no longer bounded by its BodyLimit, and Body()
+// reads the whole stream, so this reads bodies of up to limit bytes before
+// any handler does. Routes for which next returns true keep the stream and
+// bound it themselves.
+func LimitBody(limit int, next func(*fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if next != nil && next(c) {
+			return c.Next()
+		}
+		if c.Request().Header.ContentLength() > limit {
+			return bodyTooLarge(c)
+		}
+
+		stream := c.Context().RequestBodyStream()
+		if stream == nil {
+			return c.Next()
+		}
+		body, err := io.ReadAll(io.LimitReader(stream, int64(limit)+1))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot read request body"})
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c)
+		}
+		c.Request().SetBody(body)
+
+		return c.Next()
+	}
+}
+
+// bodyTooLarge closes the connection, as the rest of the body is left
+// unread on it.
+func bodyTooLarge(c *fiber.Ctx) error {
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "request body too large"})
+}
@@ -0,0 +1,74 @@
+package httpx_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/httpx"
+)
+
+func setupLimitBody(t *testing.T) *fiber.App {
+	t.Helper()
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true, StreamRequestBody: true})
+	app.Use(httpx.LimitBody(8, func(c *fiber.Ctx) bool { return c.Path() == "/stream" }))
+	app.Post("/echo", func(c *fiber.Ctx) error { return c.Send(c.Body()) })
+	app.Post("/stream", func(c *fiber.Ctx) error {
+		body, err := io.ReadAll(c.Context().RequestBodyStream())
+		if err != nil {
+			return err
+		}
+		return c.Send(body)
+	})
+
+	return app
+}
+
+func post(t *testing.T, app *fiber.App, path, body string, chunked bool) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if chunked {
+		req.ContentLength = -1
+		req.TransferEncoding = []string{"chunked"}
+	}
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	defer resp.Body.Close()
+
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestLimitBody(t *testing.T) {
+	app := setupLimitBody(t)
+
+	cases := []struct {
+		name    string
+		path    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"within the limit", "/echo", "12345678", false, http.StatusOK},
+		{"chunked within the limit", "/echo", "12345678", true, http.StatusOK},
+		{"over the limit", "/echo", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"chunked over the limit", "/echo", strings.Repeat("x", 64<<10), true, http.StatusRequestEntityTooLarge},
+		{"skipped route", "/stream", strings.Repeat("x", 64<<10), true, http.StatusOK},
+	}
+	for _, c := range cases {
+		status, body := post(t, app, c.path, c.body, c.chunked)
+		if status != c.status {
+			t.Fatalf("%s: status=%d want=%d body=%s", c.name, status, c.status, body)
+		}
+		if status == http.StatusOK && body != c.body {
+			t.Fatalf("%s: body=%q", c.name, body)
+		}
+	}
+}
@@ -1,9 +1,8 @@
 package httpx
 
 import (
-	"log"
-
 	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/obs"
 )
 
 var debug bool
@@ -12,7 +11,8 @@ func SetDebug(b bool) { debug = b }
 
 func InternalServerError(c *fiber.Ctx, err error) error {
 	if err != nil {
-		log.Printf("500 internal error: %v", err)
+		obs.Logger(c.UserContext()).ErrorContext(c.UserContext(), "500 internal error", "error", err)
+		obs.SpanError(c.UserContext(), err)
 	}
 
 	msg := "internal server error"
@@ -0,0 +1,143 @@
+// Package i18n negotiates the locales of a request from Accept-Language and
+// translates the API's messages into them.
+//
+// The base text of a course is in the default locale; the other supported
+// locales are served from its translations. A request gets a fallback chain:
+// the supported locales it accepts, most preferred first, ending in the
+// default one.
+package i18n
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/language"
+)
+
+type Config struct {
+	// Default is the locale of the base course text.
+	Default string
+	// Supported are the locales served, Default included.
+	Supported []string
+}
+
+// ConfigFromEnv reads I18N_DEFAULT_LOCALE (en) and the comma-separated
+// I18N_LOCALES (en,pt-BR).
+func ConfigFromEnv() Config {
+	cfg := Config{Default: strings.TrimSpace(os.Getenv("I18N_DEFAULT_LOCALE"))}
+	if cfg.Default == "" {
+		cfg.Default = "en"
+	}
+	for _, locale := range strings.Split(os.Getenv("I18N_LOCALES"), ",") {
+		if locale = strings.TrimSpace(locale); locale != "" {
+			cfg.Supported = append(cfg.Supported, locale)
+		}
+	}
+	if len(cfg.Supported) == 0 {
+		cfg.Supported = []string{"en", "pt-BR"}
+	}
+	return cfg
+}
+
+type locales struct {
+	names   []string
+	matcher language.Matcher
+}
+
+// current is replaced by SetConfig at startup only.
+var current = build(Config{Default: "en", Supported: []string{"en", "pt-BR"}})
+
+// SetConfig sets the locales served. Call it before serving requests.
+func SetConfig(cfg Config) {
+	current = build(cfg)
+}
+
+// build puts the default locale first, where the matcher falls back to.
+func build(cfg Config) locales {
+	names := []string{language.Make(cfg.Default).String()}
+	for _, locale := range cfg.Supported {
+		if name := language.Make(locale).String(); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	tags := make([]language.Tag, len(names))
+	for i, name := range names {
+		tags[i] = language.Make(name)
+	}
+	return locales{names: names, matcher: language.NewMatcher(tags)}
+}
+
+// Default returns the locale of the base course text.
+func Default() string { return current.names[0] }
+
+// Supported returns the canonical form of locale, such as pt-BR for pt-br,
+// and whether it is served.
+func Supported(locale string) (string, bool) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", false
+	}
+	name := tag.String()
+	return name, slices.Contains(current.names, name)
+}
+
+// Chain returns the fallback chain of an Accept-Language header. A language
+// is matched to the closest supported locale, so en-US falls back to en and
+// pt to pt-BR.
+func Chain(acceptLanguage string) []string {
+	var chain []string
+	tags, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	for _, tag := range tags {
+		_, i, confidence := current.matcher.Match(tag)
+		if confidence == language.No {
+			continue
+		}
+		if name := current.names[i]; !slices.Contains(chain, name) {
+			chain = append(chain, name)
+		}
+		if i == 0 {
+			// the base text has every course
+			return chain
+		}
+	}
+	return append(chain, Default())
+}
+
+type localesKey struct{}
+
+// WithLocales returns ctx with the fallback chain.
+func WithLocales(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, localesKey{}, chain)
+}
+
+// Locales returns the fallback chain of ctx, or nil when none was negotiated.
+func Locales(ctx context.Context) []string {
+	chain, _ := ctx.Value(localesKey{}).([]string)
+	return chain
+}
+
+// Translated returns the locales of the chain of ctx that are served from
+// translations, that is all but the default one.
+func Translated(ctx context.Context) []string {
+	chain := Locales(ctx)
+	if len(chain) < 2 {
+		return nil
+	}
+	return chain[:len(chain)-1]
+}
+
+// Middleware negotiates the fallback chain of each request into its user
+// context and answers in its first locale.
+func Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		chain := Chain(c.Get(fiber.HeaderAcceptLanguage))
+		c.SetUserContext(WithLocales(c.UserContext(), chain))
+		c.Vary(fiber.HeaderAcceptLanguage)
+		c.Set(fiber.HeaderContentLanguage, chain[0])
+		return c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package i18n_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+)
+
+func TestChain(t *testing.T) {
+	i18n.SetConfig(i18n.Config{Default: "en", Supported: []string{"en", "pt-BR"}})
+
+	cases := map[string][]string{
+		"":                             {"en"},
+		"pt-BR":                        {"pt-BR", "en"},
+		"pt":                           {"pt-BR", "en"},
+		"fr-CA, pt-br;q=0.8, en;q=0.5": {"pt-BR", "en"},
+		"en-US, pt-BR;q=0.9":           {"en"},
+		"de":                           {"en"},
+		"not a header!":                {"en"},
+	}
+	for header, want := range cases {
+		if got := i18n.Chain(header); !slices.Equal(got, want) {
+			t.Fatalf("Chain(%q)=%v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestChain_DefaultLocaleOtherThanEnglish(t *testing.T) {
+	i18n.SetConfig(i18n.Config{Default: "pt-BR", Supported: []string{"en"}})
+	t.Cleanup(func() { i18n.SetConfig(i18n.Config{Default: "en", Supported: []string{"en", "pt-BR"}}) })
+
+	if got := i18n.Chain("en-GB"); !slices.Equal(got, []string{"en", "pt-BR"}) {
+		t.Fatalf("Chain=%v", got)
+	}
+	if got := i18n.Chain(""); !slices.Equal(got, []string{"pt-BR"}) {
+		t.Fatalf("Chain=%v", got)
+	}
+	if i18n.Default() != "pt-BR" {
+		t.Fatalf("Default=%q", i18n.Default())
+	}
+}
+
+func TestSupported(t *testing.T) {
+	if locale, ok := i18n.Supported("pt-br"); !ok || locale != "pt-BR" {
+		t.Fatalf("Supported=%q,%t", locale, ok)
+	}
+	if _, ok := i18n.Supported("fr"); ok {
+		t.Fatalf("fr is supported")
+	}
+	if _, ok := i18n.Supported("!!"); ok {
+		t.Fatalf("!! is supported")
+	}
+}
+
+func TestT(t *testing.T) {
+	ctx := context.Background()
+	if got := i18n.T(ctx, "is required"); got != "is required" {
+		t.Fatalf("T=%q", got)
+	}
+	if got := i18n.T(i18n.WithLocales(ctx, []string{"pt-BR", "en"}), "is required"); got != "é obrigatório" {
+		t.Fatalf("T=%q", got)
+	}
+	if got := i18n.T(i18n.WithLocales(ctx, []string{"pt-BR", "en"}), "unknown message"); got != "unknown message" {
+		t.Fatalf("T=%q", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Use(i18n.Middleware())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(i18n.T(c.UserContext(), "is required"))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Language", "pt-BR,pt;q=0.9")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test: %v", err)
+	}
+	if resp.Header.Get("Content-Language") != "pt-BR" || resp.Header.Get("Vary") != "Accept-Language" {
+		t.Fatalf("headers=%v", resp.Header)
+	}
+}
@@ -0,0 +1,57 @@
+package i18n
+
+import (
+	"context"
+	"fmt"
+)
+
+// source is the locale the messages are written in.
+const source = "en"
+
+// catalog holds the translations of the messages per locale.
+var catalog = map[string]map[string]string{
+	"pt-BR": {
+		"is required":                      "é obrigatório",
+		"is invalid":                       "é inválido",
+		"must be at least %s characters":   "deve ter pelo menos %s caracteres",
+		"must be at most %s characters":    "deve ter no máximo %s caracteres",
+		"must be exactly %s characters":    "deve ter exatamente %s caracteres",
+		"must contain at least %s items":   "deve conter pelo menos %s itens",
+		"must contain at most %s items":    "deve conter no máximo %s itens",
+		"must contain exactly %s items":    "deve conter exatamente %s itens",
+		"must be at least %s":              "deve ser no mínimo %s",
+		"must be at most %s":               "deve ser no máximo %s",
+		"must be exactly %s":               "deve ser exatamente %s",
+		"must be a valid email address":    "deve ser um endereço de e-mail válido",
+		"must be a UUID":                   "deve ser um UUID",
+		"must be one of %s":                "deve ser um de %s",
+		"must not contain HTML":            "não pode conter HTML",
+		"must not contain offensive words": "não pode conter palavras ofensivas",
+		"must contain only UUIDs":          "deve conter apenas UUIDs",
+		"is not supported":                 "não é suportado",
+		"is the default":                   "é o padrão",
+		"title already exists":             "título já existe",
+	},
+}
+
+// T translates msg into the first locale of the chain of ctx that has a
+// catalog, leaving it in English when none has.
+func T(ctx context.Context, msg string) string {
+	for _, locale := range Locales(ctx) {
+		if locale == source {
+			return msg
+		}
+		if messages, ok := catalog[locale]; ok {
+			if translated, ok := messages[msg]; ok {
+				return translated
+			}
+			return msg
+		}
+	}
+	return msg
+}
+
+// Tf translates format with T and fills it in with args.
+func Tf(ctx context.Context, format string, args ...any) string {
+	return fmt.Sprintf(T(ctx, format), args...)
+}
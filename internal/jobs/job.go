@@ -0,0 +1,94 @@
+// Package jobs is a persistent background job queue stored in MySQL.
+//
+// Jobs are enqueued with a kind and a JSON payload and claimed by workers
+// with SELECT ... FOR UPDATE SKIP LOCKED, so any number of API or worker
+// processes can share the queue. Failed attempts are retried with
+// exponential backoff until MaxAttempts, after which the job is dead.
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead"
+	StatusCancelled = "cancelled"
+)
+
+// Job is a row of the jobs table. A job waiting for a retry is queued with
+// Attempts > 0 and LastError set.
+type Job struct {
+	ID          string          `json:"id"                   gorm:"type:char(36);primaryKey"`
+	Kind        string          `json:"kind"                 gorm:"type:varchar(64);not null;index"`
+	Payload     json.RawMessage `json:"payload"              gorm:"type:mediumtext;not null"`
+	Status      string          `json:"status"               gorm:"type:varchar(16);not null;index:idx_jobs_claim,priority:1"`
+	RunAt       time.Time       `json:"run_at"               gorm:"not null;index:idx_jobs_claim,priority:2"`
+	Attempts    int             `json:"attempts"             gorm:"not null"`
+	MaxAttempts int             `json:"max_attempts"         gorm:"not null"`
+	UniqueKey   *string         `json:"unique_key,omitempty" gorm:"type:varchar(191);index"`
+	// ActiveKey mirrors UniqueKey while the job is queued or running and is
+	// cleared when it finishes, so the unique index only covers live jobs.
+	ActiveKey  *string    `json:"-"                     gorm:"type:varchar(191);uniqueIndex"`
+	LastError  string     `json:"last_error,omitempty"  gorm:"type:text"`
+	LockedBy   string     `json:"locked_by,omitempty"   gorm:"type:varchar(128)"`
+	LockedAt   *time.Time `json:"locked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func (job *Job) BeforeCreate(tx *gorm.DB) (err error) {
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+
+	return nil
+}
+
+type EnqueueOption func(*Job)
+
+// At schedules the job to run no earlier than t.
+func At(t time.Time) EnqueueOption {
+	return func(job *Job) { job.RunAt = t }
+}
+
+// After schedules the job to run once d has passed.
+func After(d time.Duration) EnqueueOption {
+	return func(job *Job) { job.RunAt = time.Now().Add(d) }
+}
+
+// MaxAttempts overrides the number of attempts before the job is dead.
+func MaxAttempts(n int) EnqueueOption {
+	return func(job *Job) { job.MaxAttempts = n }
+}
+
+// Unique rejects the job with ErrDuplicate while another queued or running
+// job has the same key.
+func Unique(key string) EnqueueOption {
+	return func(job *Job) {
+		job.UniqueKey = &key
+		job.ActiveKey = &key
+	}
+}
+
+// permanentError marks a failure that retrying will not fix.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job goes straight to dead instead of retrying.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err}
+}
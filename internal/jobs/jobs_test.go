@@ -0,0 +1,161 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestBackoff_GrowsAndCaps(t *testing.T) {
+	cases := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{20, time.Hour},
+	}
+
+	for _, c := range cases {
+		for range 50 {
+			d := jobs.Backoff(c.attempt)
+			lo, hi := time.Duration(float64(c.base)*0.9), time.Duration(float64(c.base)*1.1)
+			if d < lo || d > hi {
+				t.Fatalf("Backoff(%d) = %v, want within [%v, %v]", c.attempt, d, lo, hi)
+			}
+		}
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if jobs.Permanent(nil) != nil {
+		t.Fatalf("Permanent(nil) should be nil")
+	}
+
+	cause := errors.New("bad payload")
+	if err := jobs.Permanent(cause); !errors.Is(err, cause) || err.Error() != cause.Error() {
+		t.Fatalf("Permanent should wrap %v, got %v", cause, err)
+	}
+}
+
+func TestEnqueue_Inserts(t *testing.T) {
+	db, mock := openMockDB(t)
+	q := jobs.New(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `jobs`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	job, err := q.Enqueue(context.Background(), "mail.send", map[string]string{"to": "a@example.com"}, jobs.MaxAttempts(3))
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if job.ID == "" || job.Status != jobs.StatusQueued || job.MaxAttempts != 3 {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+	if string(job.Payload) != `{"to":"a@example.com"}` {
+		t.Fatalf("payload = %s", job.Payload)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestEnqueue_UniqueReturnsLiveJob(t *testing.T) {
+	db, mock := openMockDB(t)
+	q := jobs.New(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `jobs`").WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE active_key = \\?").
+		WithArgs("report:1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "kind", "status"}).AddRow("existing", "report", jobs.StatusRunning))
+
+	job, err := q.Enqueue(context.Background(), "report", nil, jobs.Unique("report:1"))
+	if !errors.Is(err, jobs.ErrDuplicate) {
+		t.Fatalf("err = %v, want ErrDuplicate", err)
+	}
+	if job == nil || job.ID != "existing" {
+		t.Fatalf("want the live job, got %+v", job)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestCancel_RunningJobIsInvalidState(t *testing.T) {
+	db, mock := openMockDB(t)
+	q := jobs.New(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `jobs` SET").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery("SELECT \\* FROM `jobs` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow("j1", jobs.StatusRunning))
+
+	job, err := q.Cancel(context.Background(), "j1")
+	if !errors.Is(err, jobs.ErrInvalidState) {
+		t.Fatalf("err = %v, want ErrInvalidState", err)
+	}
+	if job == nil || job.Status != jobs.StatusRunning {
+		t.Fatalf("want the current job, got %+v", job)
+	}
+}
+
+func TestGet_NotFound(t *testing.T) {
+	db, mock := openMockDB(t)
+	q := jobs.New(db)
+
+	mock.ExpectQuery("SELECT \\* FROM `jobs`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, err := q.Get(context.Background(), "missing"); !errors.Is(err, jobs.ErrNotFound) {
+		t.Fatalf("err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestRun_ZeroConcurrencyReturns(t *testing.T) {
+	db, _ := openMockDB(t)
+
+	done := make(chan struct{})
+	go func() {
+		jobs.New(db).Run(context.Background(), jobs.WorkerConfig{})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Run with no workers should return")
+	}
+}
@@ -0,0 +1,19 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+const PurgeKind = "jobs.purge"
+
+// RegisterPurge deletes succeeded and cancelled jobs older than retention,
+// once an hour. Dead jobs are kept until they are retried or removed by hand.
+func (q *Queue) RegisterPurge(retention time.Duration) {
+	Handle(q, PurgeKind, func(ctx context.Context, _ struct{}) error {
+		return q.db.WithContext(ctx).
+			Where("status IN ? AND finished_at < ?", []string{StatusSucceeded, StatusCancelled}, time.Now().Add(-retention)).
+			Delete(&Job{}).Error
+	})
+	q.Every(PurgeKind, time.Hour, struct{}{})
+}
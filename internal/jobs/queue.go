@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrDuplicate    = errors.New("jobs: a live job with this unique key exists")
+	ErrNotFound     = errors.New("jobs: job not found")
+	ErrInvalidState = errors.New("jobs: job cannot change state")
+)
+
+const defaultMaxAttempts = 5
+
+// Handler runs one attempt of a job. Returning an error schedules a retry,
+// unless it is wrapped with Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// HandlerOption configures how the jobs of a registered kind run.
+type HandlerOption func(*registration)
+
+// Timeout bounds each attempt of the kind instead of WorkerConfig.JobTimeout.
+// Zero leaves attempts unbounded; the heartbeat still keeps their lock.
+func Timeout(d time.Duration) HandlerOption {
+	return func(r *registration) {
+		r.timeout = d
+		r.hasTimeout = true
+	}
+}
+
+type registration struct {
+	handler    Handler
+	timeout    time.Duration
+	hasTimeout bool
+}
+
+type periodic struct {
+	kind     string
+	interval time.Duration
+	payload  any
+}
+
+type Queue struct {
+	db *gorm.DB
+
+	mu       sync.RWMutex
+	handlers map[string]registration
+	periodic []periodic
+}
+
+func New(db *gorm.DB) *Queue {
+	return &Queue{db: db, handlers: map[string]registration{}}
+}
+
+// Register sets the handler of kind. Workers only claim registered kinds.
+func (q *Queue) Register(kind string, h Handler, opts ...HandlerOption) {
+	r := registration{handler: h}
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[kind] = r
+}
+
+// Handle registers a typed handler whose payload is decoded into T. A payload
+// that does not decode is a permanent failure.
+func Handle[T any](q *Queue, kind string, fn func(ctx context.Context, payload T) error, opts ...HandlerOption) {
+	q.Register(kind, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode %s payload: %w", kind, err))
+		}
+
+		return fn(ctx, payload)
+	}, opts...)
+}
+
+// Every enqueues kind once per interval, at the start of each slot. Every
+// worker schedules it; the unique key per slot keeps a single job.
+func (q *Queue) Every(kind string, interval time.Duration, payload any) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.periodic = append(q.periodic, periodic{kind: kind, interval: interval, payload: payload})
+}
+
+func (q *Queue) handler(kind string) (Handler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	r, ok := q.handlers[kind]
+	return r.handler, ok
+}
+
+// timeout is the attempt limit of kind, or def when it has none of its own.
+func (q *Queue) timeout(kind string, def time.Duration) time.Duration {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if r := q.handlers[kind]; r.hasTimeout {
+		return r.timeout
+	}
+	return def
+}
+
+func (q *Queue) kinds() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// Enqueue stores a job to run now, or as set by opts. With Unique, the live
+// job holding the key is returned along with ErrDuplicate.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts ...EnqueueOption) (*Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &Job{
+		Kind:        kind,
+		Payload:     raw,
+		Status:      StatusQueued,
+		RunAt:       time.Now(),
+		MaxAttempts: defaultMaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err := q.db.WithContext(ctx).Create(job).Error; err != nil {
+		if job.ActiveKey != nil && isDuplicateKey(err) {
+			var existing Job
+			if err := q.db.WithContext(ctx).First(&existing, "active_key = ?", *job.ActiveKey).Error; err != nil {
+				return nil, err
+			}
+			return &existing, ErrDuplicate
+		}
+
+		return nil, err
+	}
+
+	metrics.JobsEnqueuedTotal.WithLabelValues(kind).Inc()
+	return job, nil
+}
+
+type Filter struct {
+	Status string
+	Kind   string
+	// Limit defaults to 50 and is capped at 500.
+	Limit int
+}
+
+// List returns jobs matching f, newest first.
+func (q *Queue) List(ctx context.Context, f Filter) ([]Job, error) {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	f.Limit = min(f.Limit, 500)
+
+	tx := q.db.WithContext(ctx).Order("created_at desc").Limit(f.Limit)
+	if f.Status != "" {
+		tx = tx.Where("status = ?", f.Status)
+	}
+	if f.Kind != "" {
+		tx = tx.Where("kind = ?", f.Kind)
+	}
+
+	var jobs []Job
+	return jobs, tx.Find(&jobs).Error
+}
+
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := q.db.WithContext(ctx).First(&job, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// Retry requeues a dead or cancelled job with a fresh set of attempts.
+func (q *Queue) Retry(ctx context.Context, id string) (*Job, error) {
+	return q.transition(ctx, id, []string{StatusDead, StatusCancelled}, map[string]any{
+		"status":      StatusQueued,
+		"run_at":      time.Now(),
+		"attempts":    0,
+		"last_error":  "",
+		"finished_at": nil,
+		"active_key":  gorm.Expr("unique_key"),
+	})
+}
+
+// Cancel stops a queued job, including one waiting for a retry. Running jobs
+// cannot be cancelled.
+func (q *Queue) Cancel(ctx context.Context, id string) (*Job, error) {
+	return q.transition(ctx, id, []string{StatusQueued}, map[string]any{
+		"status":      StatusCancelled,
+		"finished_at": time.Now(),
+		"active_key":  nil,
+	})
+}
+
+func (q *Queue) transition(ctx context.Context, id string, from []string, fields map[string]any) (*Job, error) {
+	res := q.db.WithContext(ctx).Model(&Job{}).Where("id = ? AND status IN ?", id, from).Updates(fields)
+	if res.Error != nil {
+		if isDuplicateKey(res.Error) {
+			return nil, ErrDuplicate
+		}
+		return nil, res.Error
+	}
+
+	job, err := q.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected == 0 {
+		return job, ErrInvalidState
+	}
+
+	return job, nil
+}
+
+func isDuplicateKey(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
+}
@@ -0,0 +1,371 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkerConfig struct {
+	// Concurrency is the number of jobs run at once; zero disables the workers.
+	Concurrency int
+	// PollInterval is how long an idle worker waits before looking again.
+	PollInterval time.Duration
+	// JobTimeout bounds a single attempt of kinds registered without Timeout.
+	JobTimeout time.Duration
+	// LockTimeout is how long a running job may go without a heartbeat
+	// before it is considered abandoned by a crashed worker and requeued.
+	// Workers renew the lock of their jobs every third of it.
+	LockTimeout time.Duration
+}
+
+// WorkerConfigFromEnv reads JOBS_WORKERS (default concurrency),
+// JOBS_POLL_INTERVAL (1s), JOBS_TIMEOUT (5m) and JOBS_LOCK_TIMEOUT (15m).
+func WorkerConfigFromEnv(concurrency int) WorkerConfig {
+	cfg := WorkerConfig{
+		Concurrency:  concurrency,
+		PollInterval: getdur("JOBS_POLL_INTERVAL", time.Second),
+		JobTimeout:   getdur("JOBS_TIMEOUT", 5*time.Minute),
+		LockTimeout:  getdur("JOBS_LOCK_TIMEOUT", 15*time.Minute),
+	}
+	if n, err := strconv.Atoi(os.Getenv("JOBS_WORKERS")); err == nil && n >= 0 {
+		cfg.Concurrency = n
+	}
+
+	return cfg
+}
+
+// maintenanceInterval paces requeueing abandoned jobs, refreshing JobsGauge
+// and scheduling periodic jobs. Every intervals shorter than this skip slots.
+const maintenanceInterval = 10 * time.Second
+
+// Backoff is the delay before retrying after the given failed attempt:
+// 10s doubling up to an hour, with ±10% jitter so retries spread out.
+func Backoff(attempt int) time.Duration {
+	d := 10 * time.Second
+	for i := 1; i < attempt && d < time.Hour; i++ {
+		d *= 2
+	}
+	d = min(d, time.Hour)
+
+	return time.Duration(float64(d) * (0.9 + 0.2*rand.Float64()))
+}
+
+// Run claims and runs jobs with cfg.Concurrency workers until ctx is done,
+// then waits for the jobs in flight.
+func (q *Queue) Run(ctx context.Context, cfg WorkerConfig) {
+	if cfg.Concurrency <= 0 {
+		return
+	}
+
+	host, _ := os.Hostname()
+	workerID := fmt.Sprintf("%s/%d/%s", host, os.Getpid(), uuid.NewString()[:8])
+	slog.InfoContext(ctx, "job workers started", "worker", workerID, "concurrency", cfg.Concurrency, "kinds", q.kinds())
+
+	var wg sync.WaitGroup
+	wg.Add(cfg.Concurrency + 1)
+
+	go func() {
+		defer wg.Done()
+		q.maintain(ctx, cfg)
+	}()
+
+	for range cfg.Concurrency {
+		go func() {
+			defer wg.Done()
+			q.work(ctx, cfg, workerID)
+		}()
+	}
+
+	wg.Wait()
+	slog.InfoContext(context.WithoutCancel(ctx), "job workers stopped", "worker", workerID)
+}
+
+func (q *Queue) work(ctx context.Context, cfg WorkerConfig, workerID string) {
+	for ctx.Err() == nil {
+		job, err := q.claim(ctx, workerID)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "claim job failed", "error", err)
+		}
+		if job != nil {
+			q.process(ctx, cfg, job)
+			continue
+		}
+
+		// Jitter keeps idle workers of all processes from polling in lockstep.
+		wait := time.Duration(float64(cfg.PollInterval) * (0.5 + rand.Float64()))
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+	}
+}
+
+// claim locks the next due job of a registered kind and marks it running.
+// It returns nil when there is none.
+func (q *Queue) claim(ctx context.Context, workerID string) (*Job, error) {
+	kinds := q.kinds()
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	var job Job
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ? AND kind IN ?", StatusQueued, time.Now(), kinds).
+			Order("run_at").
+			Take(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status, job.Attempts, job.LockedBy, job.LockedAt = StatusRunning, job.Attempts+1, workerID, &now
+
+		return tx.Model(&job).Updates(map[string]any{
+			"status":    job.Status,
+			"attempts":  job.Attempts,
+			"locked_by": job.LockedBy,
+			"locked_at": job.LockedAt,
+		}).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (q *Queue) process(ctx context.Context, cfg WorkerConfig, job *Job) {
+	jobCtx, cancel := q.attemptContext(ctx, cfg, job.Kind)
+	defer cancel()
+
+	jobCtx, span := otel.Tracer("github.com/guycanella/api-courses-golang/internal/jobs").Start(jobCtx, "job "+job.Kind,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.kind", job.Kind),
+			attribute.Int("job.attempt", job.Attempts),
+		),
+	)
+	defer span.End()
+
+	logger := slog.Default().With("job_id", job.ID, "job_kind", job.Kind, "attempt", job.Attempts)
+	if sc := span.SpanContext(); sc.IsValid() {
+		logger = logger.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
+	}
+	jobCtx = obs.WithLogger(jobCtx, logger)
+
+	start := time.Now()
+	stop := q.heartbeat(jobCtx, job, cfg.LockTimeout/3)
+	err := q.runHandler(jobCtx, job)
+	stop()
+	metrics.JobDuration.WithLabelValues(job.Kind).Observe(time.Since(start).Seconds())
+
+	obs.SpanError(jobCtx, err)
+
+	if err := q.finish(context.WithoutCancel(jobCtx), job, err); err != nil {
+		logger.ErrorContext(jobCtx, "record job result failed", "error", err)
+	}
+}
+
+// attemptContext bounds one attempt of kind by its Timeout, or by
+// cfg.JobTimeout. Jobs in flight finish after shutdown starts, within that
+// bound.
+func (q *Queue) attemptContext(ctx context.Context, cfg WorkerConfig, kind string) (context.Context, context.CancelFunc) {
+	ctx = context.WithoutCancel(ctx)
+	if d := q.timeout(kind, cfg.JobTimeout); d > 0 {
+		return context.WithTimeout(ctx, d)
+	}
+	return context.WithCancel(ctx)
+}
+
+// heartbeat renews the lock of job every interval until stop is called, so
+// requeueAbandoned only takes jobs whose worker is gone. Like finish, it is
+// fenced on locked_by.
+func (q *Queue) heartbeat(ctx context.Context, job *Job, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			err := q.db.WithContext(context.WithoutCancel(ctx)).Model(&Job{}).
+				Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+				Update("locked_at", time.Now()).Error
+			if err != nil {
+				obs.Logger(ctx).WarnContext(ctx, "renew job lock failed", "error", err)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (q *Queue) runHandler(ctx context.Context, job *Job) (err error) {
+	h, ok := q.handler(job.Kind)
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for %q", job.Kind))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return h(ctx, job)
+}
+
+// finish records the outcome of an attempt. Updates are fenced on
+// locked_by so a worker whose lock expired cannot overwrite a newer attempt.
+func (q *Queue) finish(ctx context.Context, job *Job, runErr error) error {
+	logger := obs.Logger(ctx)
+	now := time.Now()
+	fields := map[string]any{"locked_by": "", "locked_at": nil}
+
+	var permanent *permanentError
+	switch {
+	case runErr == nil:
+		fields["status"], fields["finished_at"], fields["active_key"] = StatusSucceeded, now, nil
+		metrics.JobsProcessedTotal.WithLabelValues(job.Kind, metrics.JobSucceeded).Inc()
+	case errors.As(runErr, &permanent) || job.Attempts >= job.MaxAttempts:
+		fields["status"], fields["finished_at"], fields["active_key"] = StatusDead, now, nil
+		fields["last_error"] = runErr.Error()
+		metrics.JobsProcessedTotal.WithLabelValues(job.Kind, metrics.JobDead).Inc()
+		logger.ErrorContext(ctx, "job dead", "error", runErr)
+	default:
+		retryAt := now.Add(Backoff(job.Attempts))
+		fields["status"], fields["run_at"] = StatusQueued, retryAt
+		fields["last_error"] = runErr.Error()
+		metrics.JobsProcessedTotal.WithLabelValues(job.Kind, metrics.JobRetried).Inc()
+		logger.WarnContext(ctx, "job failed, retrying", "error", runErr, "retry_at", retryAt)
+	}
+
+	return q.db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND locked_by = ?", job.ID, job.LockedBy).
+		Updates(fields).Error
+}
+
+func (q *Queue) maintain(ctx context.Context, cfg WorkerConfig) {
+	ticker := time.NewTicker(maintenanceInterval)
+	defer ticker.Stop()
+
+	for {
+		q.schedulePeriodic(ctx)
+		if err := q.requeueAbandoned(ctx, cfg.LockTimeout); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "requeue abandoned jobs failed", "error", err)
+		}
+		if err := q.refreshGauge(ctx); err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "refresh jobs gauge failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// requeueAbandoned returns jobs whose worker stopped renewing their lock,
+// by heartbeat, to the queue, or marks them dead once they are out of
+// attempts.
+func (q *Queue) requeueAbandoned(ctx context.Context, lockTimeout time.Duration) error {
+	expired := func() *gorm.DB {
+		return q.db.WithContext(ctx).Model(&Job{}).
+			Where("status = ? AND locked_at < ?", StatusRunning, time.Now().Add(-lockTimeout))
+	}
+
+	err := expired().Where("attempts >= max_attempts").Updates(map[string]any{
+		"status": StatusDead, "finished_at": time.Now(), "active_key": nil,
+		"locked_by": "", "locked_at": nil, "last_error": "lock expired",
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	return expired().Updates(map[string]any{
+		"status": StatusQueued, "run_at": time.Now(),
+		"locked_by": "", "locked_at": nil, "last_error": "lock expired",
+	}).Error
+}
+
+func (q *Queue) refreshGauge(ctx context.Context) error {
+	var rows []struct {
+		Status string
+		N      int64
+	}
+	if err := q.db.WithContext(ctx).Model(&Job{}).Select("status, COUNT(*) AS n").Group("status").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	for _, status := range []string{StatusQueued, StatusRunning, StatusSucceeded, StatusDead, StatusCancelled} {
+		metrics.JobsGauge.WithLabelValues(status).Set(0)
+	}
+	for _, row := range rows {
+		metrics.JobsGauge.WithLabelValues(row.Status).Set(float64(row.N))
+	}
+
+	return nil
+}
+
+// schedulePeriodic enqueues the next slot of every periodic job. The slot
+// job can only run once its slot has started, so by the time it finishes
+// the next call computes a later slot and key.
+func (q *Queue) schedulePeriodic(ctx context.Context) {
+	q.mu.RLock()
+	periodic := append([]periodic(nil), q.periodic...)
+	q.mu.RUnlock()
+
+	for _, p := range periodic {
+		slot := time.Now().Truncate(p.interval).Add(p.interval)
+		key := fmt.Sprintf("every:%s:%d", p.kind, slot.Unix())
+
+		_, err := q.Enqueue(ctx, p.kind, p.payload, At(slot), Unique(key))
+		if err != nil && !errors.Is(err, ErrDuplicate) && ctx.Err() == nil {
+			slog.WarnContext(ctx, "schedule periodic job failed", "kind", p.kind, "error", err)
+		}
+	}
+}
+
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
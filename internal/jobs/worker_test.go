@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestAttemptContext_UsesKindTimeout(t *testing.T) {
+	q := New(nil)
+	noop := func(context.Context, *Job) error { return nil }
+	q.Register("default", noop)
+	q.Register("long", noop, Timeout(time.Hour))
+	q.Register("unbounded", noop, Timeout(0))
+
+	cfg := WorkerConfig{JobTimeout: time.Minute}
+	cases := []struct {
+		kind string
+		want time.Duration
+	}{
+		{"default", time.Minute},
+		{"long", time.Hour},
+		{"unbounded", 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.kind, func(t *testing.T) {
+			ctx, cancel := q.attemptContext(context.Background(), cfg, tc.kind)
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if tc.want == 0 {
+				if ok {
+					t.Fatalf("unexpected deadline in %v", time.Until(deadline))
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("no deadline, want about %v", tc.want)
+			}
+			if left := time.Until(deadline); left > tc.want || left < tc.want-time.Second {
+				t.Fatalf("deadline in %v, want about %v", left, tc.want)
+			}
+		})
+	}
+}
+
+func TestAttemptContext_OutlivesShutdown(t *testing.T) {
+	q := New(nil)
+	q.Register("unbounded", func(context.Context, *Job) error { return nil }, Timeout(0))
+
+	parent, stop := context.WithCancel(context.Background())
+	ctx, cancel := q.attemptContext(parent, WorkerConfig{JobTimeout: time.Minute}, "unbounded")
+	defer cancel()
+
+	stop()
+	if ctx.Err() != nil {
+		t.Fatalf("attempt cancelled with its worker: %v", ctx.Err())
+	}
+}
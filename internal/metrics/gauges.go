@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshCatalogGauges recounts courses, users and enrollments. An
+// enrollment is active as long as its row exists; cancelling deletes it.
+func RefreshCatalogGauges(ctx context.Context, db *gorm.DB) error {
+	for _, gauge := range []struct {
+		table string
+		set   func(float64)
+	}{
+		{"courses", CoursesGauge.Set},
+		{"users", UsersGauge.Set},
+		{"enrollments", ActiveEnrollmentsGauge.Set},
+	} {
+		var n int64
+		if err := db.WithContext(ctx).Table(gauge.table).Count(&n).Error; err != nil {
+			return err
+		}
+
+		gauge.set(float64(n))
+	}
+
+	return nil
+}
+
+// StartCatalogGauges refreshes the catalog gauges every interval until ctx is done.
+func StartCatalogGauges(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	refresh := func() {
+		if err := RefreshCatalogGauges(ctx, db); err != nil {
+			slog.WarnContext(ctx, "refresh catalog gauges failed", "error", err)
+		}
+	}
+
+	refresh()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
@@ -1,3 +1,9 @@
+// Package metrics is the catalog of application metrics exposed at /metrics.
+//
+// Every label takes values from a closed set (route groups, cache names,
+// struct fields, conflict reasons, GORM operations, table names, job kinds,
+// event sinks, event types and GraphQL loaders) so the number of series stays
+// bounded. Never label with ids, titles or user input.
 package metrics
 
 import (
@@ -5,7 +11,220 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// CoursesCreatedTotal counts courses created successfully.
 var CoursesCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
 	Name: "total_created_courses",
 	Help: "Total number of courses created successfully",
 })
+
+// EnrollmentsCreatedTotal counts users enrolled into a course.
+var EnrollmentsCreatedTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "enrollments_created_total",
+	Help: "Total number of enrollments created successfully",
+})
+
+// EnrollmentsCancelledTotal counts enrollments removed from a course.
+var EnrollmentsCancelledTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "enrollments_cancelled_total",
+	Help: "Total number of enrollments cancelled",
+})
+
+// ValidationFailuresTotal counts rejected request fields, labelled by resource
+// (course, user, enrollment) and the JSON name of the invalid field.
+var ValidationFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "validation_failures_total",
+	Help: "Total number of validation failures by resource and field",
+}, []string{"resource", "field"})
+
+// Conflict reasons used as the reason label of ConflictsTotal.
+const (
+	ConflictCourseTitleTaken    = "course_title_taken"
+	ConflictUserEmailTaken      = "user_email_taken"
+	ConflictAlreadyEnrolled     = "already_enrolled"
+	ConflictCategorySlugTaken   = "category_slug_taken"
+	ConflictCategoryHasChildren = "category_has_children"
+	ConflictTagTaken            = "tag_taken"
+)
+
+// ConflictsTotal counts 409 responses by reason.
+var ConflictsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "conflicts_total",
+	Help: "Total number of 409 conflicts by reason",
+}, []string{"reason"})
+
+// DBQueryDuration observes GORM statements by operation (create, query,
+// update, delete, row, raw) and table. It is fed by GormPlugin.
+var DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "db_query_duration_seconds",
+	Help:    "Duration of database queries by operation and table",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation", "table"})
+
+// CoursesGauge, UsersGauge and ActiveEnrollmentsGauge hold the catalog size.
+// They are refreshed periodically by StartCatalogGauges.
+var (
+	CoursesGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "courses",
+		Help: "Current number of courses",
+	})
+	UsersGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "users",
+		Help: "Current number of users",
+	})
+	ActiveEnrollmentsGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "active_enrollments",
+		Help: "Current number of active enrollments",
+	})
+)
+
+// CacheHitsTotal and CacheMissesTotal count cache lookups by cache name.
+var CacheHitsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_hits_total",
+	Help: "Total number of cache hits by cache name",
+}, []string{"cache"})
+
+var CacheMissesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cache_misses_total",
+	Help: "Total number of cache misses by cache name",
+}, []string{"cache"})
+
+// RateLimitRejectionsTotal counts 429 responses by route group.
+var RateLimitRejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "rate_limit_rejections_total",
+	Help: "Total number of requests rejected by the rate limiter by route group",
+}, []string{"route_group"})
+
+// Job outcomes used as the outcome label of JobsProcessedTotal.
+const (
+	JobSucceeded = "succeeded"
+	JobRetried   = "retried"
+	JobDead      = "dead"
+)
+
+// JobsEnqueuedTotal counts jobs added to the queue by kind.
+var JobsEnqueuedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "jobs_enqueued_total",
+	Help: "Total number of background jobs enqueued by kind",
+}, []string{"kind"})
+
+// JobsProcessedTotal counts job attempts by kind and outcome.
+var JobsProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "jobs_processed_total",
+	Help: "Total number of background job attempts by kind and outcome",
+}, []string{"kind", "outcome"})
+
+// JobDuration observes how long each job attempt ran, by kind.
+var JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "job_duration_seconds",
+	Help:    "Duration of background job attempts by kind",
+	Buckets: []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+}, []string{"kind"})
+
+// JobsGauge holds the number of jobs per status. Workers refresh it.
+var JobsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "jobs",
+	Help: "Current number of background jobs by status",
+}, []string{"status"})
+
+// Event publish outcomes used as the outcome label of EventsPublishedTotal.
+const (
+	EventPublished = "published"
+	EventFailed    = "failed"
+)
+
+// EventsPublishedTotal counts outbox event deliveries by sink (bus, webhook,
+// redis) and outcome.
+var EventsPublishedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "events_published_total",
+	Help: "Total number of domain event deliveries by sink and outcome",
+}, []string{"sink", "outcome"})
+
+// OutboxPendingGauge and OutboxLagSeconds hold the number of unpublished
+// events and the age of the oldest one. The relay refreshes them.
+var (
+	OutboxPendingGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_events",
+		Help: "Current number of domain events waiting to be published",
+	})
+	OutboxLagSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_lag_seconds",
+		Help: "Age of the oldest domain event waiting to be published",
+	})
+)
+
+// OutboxDeadEventsTotal counts events the relay gave up on after
+// EVENTS_RELAY_MAX_ATTEMPTS failures.
+var OutboxDeadEventsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "outbox_dead_events_total",
+	Help: "Total number of domain events dead-lettered by the outbox relay",
+})
+
+// Webhook delivery outcomes used as the outcome label of
+// WebhookDeliveriesTotal.
+const (
+	WebhookSucceeded = "succeeded"
+	WebhookFailed    = "failed"
+)
+
+// WebhookDeliveriesTotal counts webhook delivery attempts by event type and
+// outcome.
+var WebhookDeliveriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "webhook_deliveries_total",
+	Help: "Total number of webhook delivery attempts by event type and outcome",
+}, []string{"event_type", "outcome"})
+
+// WebhookDeliveryDuration observes how long partner endpoints take to answer.
+var WebhookDeliveryDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "webhook_delivery_duration_seconds",
+	Help:    "Duration of webhook delivery attempts",
+	Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10},
+})
+
+// WebhooksDisabledTotal counts subscriptions disabled after failing.
+var WebhooksDisabledTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "webhooks_disabled_total",
+	Help: "Total number of webhook subscriptions disabled after repeated failures",
+})
+
+// EventStreamSubscribersGauge holds the number of open /events/stream
+// connections on this instance.
+var EventStreamSubscribersGauge = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "event_stream_subscribers",
+	Help: "Current number of event stream subscribers",
+})
+
+// EventStreamDisconnectsTotal counts stream subscribers dropped for falling
+// behind.
+var EventStreamDisconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "event_stream_slow_disconnects_total",
+	Help: "Total number of event stream subscribers disconnected for falling behind",
+})
+
+// GraphQL request outcomes used as the outcome label of GraphQLRequestsTotal.
+const (
+	GraphQLOK       = "ok"
+	GraphQLErrors   = "errors"
+	GraphQLRejected = "rejected"
+)
+
+// GraphQLRequestsTotal counts /graphql requests by outcome: ok, errors (the
+// response carries errors) or rejected (over the complexity limit).
+var GraphQLRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "graphql_requests_total",
+	Help: "Total number of GraphQL requests by outcome",
+}, []string{"outcome"})
+
+// GraphQLComplexity observes the estimated cost of executed GraphQL queries.
+var GraphQLComplexity = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "graphql_query_complexity",
+	Help:    "Estimated complexity of executed GraphQL queries",
+	Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+})
+
+// GraphQLBatchSize observes how many keys each GraphQL loader fetch carries,
+// by loader.
+var GraphQLBatchSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "graphql_loader_batch_size",
+	Help:    "Number of keys per GraphQL loader fetch",
+	Buckets: []float64{1, 2, 5, 10, 25, 50, 100, 250, 500},
+}, []string{"loader"})
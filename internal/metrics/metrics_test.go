@@ -0,0 +1,164 @@
+package metrics_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+func sampleCount(t *testing.T, h *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+
+	observer, err := h.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		t.Fatalf("GetMetricWithLabelValues: %v", err)
+	}
+
+	var m dto.Metric
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestCatalog_Lint(t *testing.T) {
+	for _, c := range []prometheus.Collector{
+		metrics.EnrollmentsCreatedTotal,
+		metrics.EnrollmentsCancelledTotal,
+		metrics.ValidationFailuresTotal,
+		metrics.ConflictsTotal,
+		metrics.DBQueryDuration,
+		metrics.CoursesGauge,
+		metrics.UsersGauge,
+		metrics.ActiveEnrollmentsGauge,
+		metrics.CacheHitsTotal,
+		metrics.CacheMissesTotal,
+		metrics.RateLimitRejectionsTotal,
+		metrics.JobsEnqueuedTotal,
+		metrics.JobsProcessedTotal,
+		metrics.JobDuration,
+		metrics.JobsGauge,
+		metrics.EventsPublishedTotal,
+		metrics.OutboxPendingGauge,
+		metrics.OutboxLagSeconds,
+		metrics.OutboxDeadEventsTotal,
+		metrics.WebhookDeliveriesTotal,
+		metrics.WebhookDeliveryDuration,
+		metrics.WebhooksDisabledTotal,
+		metrics.EventStreamSubscribersGauge,
+		metrics.EventStreamDisconnectsTotal,
+		metrics.GraphQLRequestsTotal,
+		metrics.GraphQLComplexity,
+		metrics.GraphQLBatchSize,
+	} {
+		problems, err := testutil.CollectAndLint(c)
+		if err != nil {
+			t.Fatalf("CollectAndLint: %v", err)
+		}
+		for _, p := range problems {
+			t.Errorf("%s: %s", p.Metric, p.Text)
+		}
+	}
+}
+
+func TestCounters_ByLabel(t *testing.T) {
+	before := testutil.ToFloat64(metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCourseTitleTaken))
+	metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCourseTitleTaken).Inc()
+
+	if got := testutil.ToFloat64(metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCourseTitleTaken)); got != before+1 {
+		t.Fatalf("conflicts_total=%v want=%v", got, before+1)
+	}
+
+	metrics.ValidationFailuresTotal.WithLabelValues("course", "title").Inc()
+	if got := testutil.ToFloat64(metrics.ValidationFailuresTotal.WithLabelValues("course", "title")); got < 1 {
+		t.Fatalf("validation_failures_total=%v want>=1", got)
+	}
+}
+
+func TestGormPlugin_ObservesByOperationAndTable(t *testing.T) {
+	db, mock := openMockDB(t)
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+
+	before := sampleCount(t, metrics.DBQueryDuration, "query", "courses")
+
+	mock.ExpectQuery("SELECT \\* FROM `courses`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("1", "Go"))
+
+	var rows []struct {
+		ID    string
+		Title string
+	}
+	if err := db.Table("courses").Find(&rows).Error; err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	if got := sampleCount(t, metrics.DBQueryDuration, "query", "courses"); got != before+1 {
+		t.Fatalf("sample count=%d want=%d", got, before+1)
+	}
+}
+
+func TestRefreshCatalogGauges(t *testing.T) {
+	db, mock := openMockDB(t)
+
+	for table, n := range map[string]int{"courses": 7, "users": 3, "enrollments": 5} {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM `" + table + "`").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(n))
+	}
+	mock.MatchExpectationsInOrder(false)
+
+	if err := metrics.RefreshCatalogGauges(context.Background(), db); err != nil {
+		t.Fatalf("RefreshCatalogGauges: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		gauge prometheus.Gauge
+		want  float64
+	}{
+		{"courses", metrics.CoursesGauge, 7},
+		{"users", metrics.UsersGauge, 3},
+		{"active_enrollments", metrics.ActiveEnrollmentsGauge, 5},
+	} {
+		if got := testutil.ToFloat64(tc.gauge); got != tc.want {
+			t.Fatalf("%s=%v want=%v", tc.name, got, tc.want)
+		}
+	}
+
+	expected := "# HELP courses Current number of courses\n# TYPE courses gauge\ncourses 7\n"
+	if err := testutil.CollectAndCompare(metrics.CoursesGauge, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
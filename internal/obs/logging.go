@@ -0,0 +1,222 @@
+package obs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogLevel is the level of the default logger. It can be changed at runtime.
+var LogLevel = new(slog.LevelVar)
+
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"authorization": true,
+	"api_key":       true,
+	"x-api-key":     true,
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// redact masks sensitive attributes before they are written.
+func redact(_ []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+
+	switch key {
+	case "email", "sql", "error":
+		// Emails may also show up inside SQL statements and driver errors.
+		return slog.String(a.Key, emailPattern.ReplaceAllStringFunc(a.Value.String(), maskEmail))
+	}
+
+	if sensitiveKeys[key] {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+
+	return a
+}
+
+func maskEmail(email string) string {
+	local, domain, _ := strings.Cut(email, "@")
+	if len(local) > 1 {
+		local = local[:1]
+	}
+
+	return local + "***@" + domain
+}
+
+// ParseLevel maps LOG_LEVEL values (debug, info, warn, error) to slog levels.
+func ParseLevel(s string, def slog.Level) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return def
+	}
+
+	return level
+}
+
+// NewLogger returns a JSON logger writing to w with redaction applied.
+func NewLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redact,
+	}))
+}
+
+// InitLogger installs the JSON logger as the slog and log default. The level
+// comes from LOG_LEVEL, and defaults to debug when debug is true.
+func InitLogger(debug bool) *slog.Logger {
+	def := slog.LevelInfo
+	if debug {
+		def = slog.LevelDebug
+	}
+	LogLevel.Set(ParseLevel(os.Getenv("LOG_LEVEL"), def))
+
+	logger := NewLogger(os.Stdout, LogLevel)
+	slog.SetDefault(logger)
+
+	return logger
+}
+
+type loggerKey struct{}
+
+// WithLogger stores logger in ctx.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the request logger stored in ctx, or the default logger.
+func Logger(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+
+	return slog.Default()
+}
+
+// requestScope resolves the route and user lazily, since both are only known
+// once Fiber has matched the route and auth has run. They are frozen when the
+// request ends so loggers outliving it never read a recycled *fiber.Ctx.
+type requestScope struct {
+	mu    sync.Mutex
+	c     *fiber.Ctx
+	route string
+	user  string
+}
+
+func (s *requestScope) freeze() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.route, s.user = routeOf(s.c), userOf(s.c)
+	s.c = nil
+}
+
+func (s *requestScope) attrs() (route, user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.c != nil {
+		return routeOf(s.c), userOf(s.c)
+	}
+
+	return s.route, s.user
+}
+
+func routeOf(c *fiber.Ctx) string {
+	if route := c.Route(); route != nil {
+		return route.Path
+	}
+
+	return ""
+}
+
+func userOf(c *fiber.Ctx) string {
+	user, _ := c.Locals("userId").(string)
+	return user
+}
+
+// scopeHandler adds the route and user of the request to every record. They
+// are resolved when the record is handled rather than when the logger is built.
+type scopeHandler struct {
+	slog.Handler
+	scope *requestScope
+}
+
+func (h *scopeHandler) Handle(ctx context.Context, r slog.Record) error {
+	route, user := h.scope.attrs()
+	r.AddAttrs(slog.String("route", route))
+	if user != "" {
+		r.AddAttrs(slog.String("user", user))
+	}
+
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *scopeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &scopeHandler{Handler: h.Handler.WithAttrs(attrs), scope: h.scope}
+}
+
+func (h *scopeHandler) WithGroup(name string) slog.Handler {
+	return &scopeHandler{Handler: h.Handler.WithGroup(name), scope: h.scope}
+}
+
+// RequestLogger stores a per-request logger in the user context carrying the
+// request id, trace and span ids, route and user, then writes one access log
+// line per request. It must run after the requestid and otelfiber middlewares.
+func RequestLogger() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		ctx := c.UserContext()
+		scope := &requestScope{c: c}
+
+		attrs := []any{slog.Any("request_id", c.Locals("requestid"))}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			attrs = append(attrs,
+				slog.String("trace_id", sc.TraceID().String()),
+				slog.String("span_id", sc.SpanID().String()),
+			)
+		}
+
+		logger := slog.New(&scopeHandler{Handler: slog.Default().Handler(), scope: scope}).With(attrs...)
+		c.SetUserContext(WithLogger(ctx, logger))
+
+		err := c.Next()
+		if err != nil {
+			if herr := c.App().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		logger.LogAttrs(ctx, level, "request",
+			slog.String("ip", c.IP()),
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+		scope.freeze()
+
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package obs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/guycanella/api-courses-golang/internal/obs"
+
+	"github.com/gofiber/contrib/otelfiber"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("Invalid JSON log line %q: %v", line, err)
+		}
+		lines = append(lines, m)
+	}
+
+	return lines
+}
+
+func TestLogger_RedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := obs.NewLogger(&buf, slog.LevelDebug)
+
+	logger.Info("user created",
+		"email", "jane.doe@example.com",
+		"password", "hunter2",
+		"error", errors.New("duplicate entry 'jane.doe@example.com' for key 'email'"),
+	)
+
+	line := decodeLines(t, &buf)[0]
+	if line["password"] != "[REDACTED]" {
+		t.Fatalf("password=%v want [REDACTED]", line["password"])
+	}
+	if line["email"] != "j***@example.com" {
+		t.Fatalf("email=%v want masked", line["email"])
+	}
+	if strings.Contains(line["error"].(string), "jane.doe") {
+		t.Fatalf("Expected email inside error to be masked: %v", line["error"])
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if got := obs.ParseLevel("warn", slog.LevelInfo); got != slog.LevelWarn {
+		t.Fatalf("ParseLevel(warn)=%v", got)
+	}
+	if got := obs.ParseLevel("nope", slog.LevelInfo); got != slog.LevelInfo {
+		t.Fatalf("ParseLevel(nope)=%v", got)
+	}
+}
+
+func TestRequestLogger_CorrelatesRequest(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(obs.NewLogger(&buf, slog.LevelDebug))
+	defer slog.SetDefault(prev)
+
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(requestid.New())
+	app.Use(otelfiber.Middleware(otelfiber.WithTracerProvider(sdktrace.NewTracerProvider())))
+	app.Use(obs.RequestLogger())
+	app.Get("/courses/:courseId", func(c *fiber.Ctx) error {
+		obs.Logger(c.UserContext()).Info("in handler")
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/courses/42", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	if _, err := app.Test(req); err != nil {
+		t.Fatalf("Failed request: %v", err)
+	}
+
+	lines := decodeLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("Expected handler and access log lines, got %d", len(lines))
+	}
+
+	for _, line := range lines {
+		if line["request_id"] != "req-1" {
+			t.Fatalf("request_id=%v want req-1", line["request_id"])
+		}
+		if line["route"] != "/courses/:courseId" {
+			t.Fatalf("route=%v want /courses/:courseId", line["route"])
+		}
+		if id, _ := line["trace_id"].(string); len(id) != 32 {
+			t.Fatalf("Expected trace_id, got %v", line["trace_id"])
+		}
+	}
+
+	if lines[1]["status"] != float64(fiber.StatusNoContent) {
+		t.Fatalf("status=%v want 204", lines[1]["status"])
+	}
+}
@@ -0,0 +1,169 @@
+package obs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploggrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"google.golang.org/grpc/credentials"
+)
+
+// InitLogs bridges slog to an OTel logger provider when cfg.LogsExporter is
+// "otlp", so log records reach the backend with their trace and span ids.
+// The stdout JSON logs installed by InitLogger are kept. It returns a nil
+// provider when the bridge is disabled.
+func InitLogs(ctx context.Context, cfg Config) (*sdklog.LoggerProvider, error) {
+	if cfg.LogsExporter != "otlp" {
+		return nil, nil
+	}
+
+	exp, err := newLogExporter(ctx, cfg)
+	if err != nil {
+		slog.WarnContext(ctx, "log exporter disabled", "exporter", cfg.LogsExporter, "error", err)
+		return nil, nil
+	}
+
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithResource(newResource(ctx, cfg)),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exp)),
+	)
+
+	bridge := otelslog.NewHandler(cfg.ServiceName, otelslog.WithLoggerProvider(lp))
+	slog.SetDefault(slog.New(fanoutHandler{
+		slog.Default().Handler(),
+		&redactHandler{Handler: bridge},
+	}))
+
+	return lp, nil
+}
+
+func newLogExporter(ctx context.Context, cfg Config) (sdklog.Exporter, error) {
+	tlsConfig, err := exporterTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Protocol {
+	case "grpc":
+		opts := []otlploggrpc.Option{otlploggrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlploggrpc.WithEndpointURL(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlploggrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlploggrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		}
+
+		return otlploggrpc.New(ctx, opts...)
+	case "http/protobuf", "http", "":
+		opts := []otlploghttp.Option{otlploghttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlploghttp.WithEndpointURL(trimSlash(cfg.Endpoint)+"/v1/logs"))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlploghttp.WithInsecure())
+		} else {
+			opts = append(opts, otlploghttp.WithTLSClientConfig(tlsConfig))
+		}
+
+		return otlploghttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q", cfg.Protocol)
+	}
+}
+
+// fanoutHandler sends every record to all of its handlers.
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithAttrs(attrs)
+	}
+
+	return out
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithGroup(name)
+	}
+
+	return out
+}
+
+// redactHandler applies the stdout redaction rules and LogLevel to handlers
+// that do not support ReplaceAttr, such as the OTel bridge.
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= LogLevel.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+
+	return h.Handler.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+
+	return &redactHandler{Handler: h.Handler.WithAttrs(redacted)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		return redact(nil, a)
+	}
+
+	group := a.Value.Group()
+	redacted := make([]slog.Attr, len(group))
+	for i, ga := range group {
+		redacted[i] = redactAttr(ga)
+	}
+
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(redacted...)}
+}
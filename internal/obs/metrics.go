@@ -0,0 +1,114 @@
+package obs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"google.golang.org/grpc/credentials"
+)
+
+// InitMeter installs the global meter provider. OTel metrics are always
+// bridged to reg, which backs /metrics, and are also pushed over OTLP when
+// cfg.MetricsExporter is "otlp". Histograms recorded with a sampled span in
+// the context carry the trace id as an exemplar.
+func InitMeter(ctx context.Context, cfg Config, reg prometheus.Registerer) (*sdkmetric.MeterProvider, error) {
+	bridge, err := otelprom.New(otelprom.WithRegisterer(reg))
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []sdkmetric.Option{
+		sdkmetric.WithResource(newResource(ctx, cfg)),
+		sdkmetric.WithReader(bridge),
+	}
+
+	if cfg.MetricsExporter == "otlp" {
+		exp, err := newMetricExporter(ctx, cfg)
+		if err != nil {
+			slog.WarnContext(ctx, "metric exporter disabled", "exporter", cfg.MetricsExporter, "error", err)
+		} else {
+			opts = append(opts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp, sdkmetric.WithInterval(15*time.Second))))
+		}
+	}
+
+	mp := sdkmetric.NewMeterProvider(opts...)
+	otel.SetMeterProvider(mp)
+
+	return mp, nil
+}
+
+func newMetricExporter(ctx context.Context, cfg Config) (sdkmetric.Exporter, error) {
+	tlsConfig, err := exporterTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Protocol {
+	case "grpc":
+		opts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetricgrpc.WithEndpointURL(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetricgrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlpmetricgrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		}
+
+		return otlpmetricgrpc.New(ctx, opts...)
+	case "http/protobuf", "http", "":
+		opts := []otlpmetrichttp.Option{otlpmetrichttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlpmetrichttp.WithEndpointURL(trimSlash(cfg.Endpoint)+"/v1/metrics"))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlpmetrichttp.WithInsecure())
+		} else {
+			opts = append(opts, otlpmetrichttp.WithTLSClientConfig(tlsConfig))
+		}
+
+		return otlpmetrichttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q", cfg.Protocol)
+	}
+}
+
+// RequestMetrics records http.server.request.duration for every request. It
+// must run after otelfiber so the request span becomes the exemplar.
+func RequestMetrics() fiber.Handler {
+	duration, err := otel.Meter(instrumentationName).Float64Histogram(
+		"http.server.request.duration",
+		metric.WithUnit("s"),
+		metric.WithDescription("Duration of HTTP server requests"),
+		metric.WithExplicitBucketBoundaries(.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10),
+	)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		if duration != nil {
+			duration.Record(c.UserContext(), time.Since(start).Seconds(), metric.WithAttributes(
+				attribute.String("http.request.method", c.Method()),
+				attribute.String("http.route", routeOf(c)),
+				attribute.Int("http.response.status_code", c.Response().StatusCode()),
+			))
+		}
+
+		return err
+	}
+}
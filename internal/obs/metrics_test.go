@@ -0,0 +1,66 @@
+package obs_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestInitMeter_BridgesRequestDurationToPrometheus(t *testing.T) {
+	previous := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(previous) })
+
+	reg := prometheus.NewRegistry()
+	cfg := obs.ConfigFromEnv("test")
+	cfg.MetricsExporter = "none"
+
+	mp, err := obs.InitMeter(context.Background(), cfg, reg)
+	if err != nil {
+		t.Fatalf("InitMeter: %v", err)
+	}
+	defer func() { _ = mp.Shutdown(context.Background()) }()
+
+	app := fiber.New()
+	app.Use(obs.RequestMetrics())
+	app.Get("/courses/:id", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/courses/42", nil)); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	for _, family := range families {
+		// The bridge keeps the OTel names, with the unit as suffix.
+		if family.GetName() != "http.server.request.duration_seconds" {
+			continue
+		}
+
+		for _, m := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, l := range m.GetLabel() {
+				labels[l.GetName()] = l.GetValue()
+			}
+
+			if labels["http.route"] == "/courses/:id" && labels["http.response.status_code"] == "204" {
+				if got := m.GetHistogram().GetSampleCount(); got != 1 {
+					t.Fatalf("sample count=%d want=1", got)
+				}
+				return
+			}
+		}
+
+		t.Fatalf("No sample for /courses/:id in %v", family)
+	}
+
+	t.Fatalf("http.server.request.duration_seconds not exposed")
+}
@@ -0,0 +1,59 @@
+// Package obstest records spans in memory so tests can assert on traces.
+package obstest
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// Spans installs a global tracer provider that samples everything into an
+// in-memory exporter, restoring the previous provider when the test ends.
+// Spans are exported synchronously as soon as they end.
+func Spans(t testing.TB) *tracetest.InMemoryExporter {
+	t.Helper()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithSyncer(exp),
+	)
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	return exp
+}
+
+// Find returns the first ended span called name, failing the test if there is none.
+func Find(t testing.TB, exp *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+
+	for _, span := range exp.GetSpans() {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	t.Fatalf("No span %q in %d recorded spans", name, len(exp.GetSpans()))
+	return tracetest.SpanStub{}
+}
+
+// Attr returns the value of key on span, and whether it was set.
+func Attr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
@@ -0,0 +1,69 @@
+package obs
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/guycanella/api-courses-golang/internal/obs"
+
+// Attribute keys describing what a handler span worked on.
+const (
+	AttrCourseID          = attribute.Key("course.id")
+	AttrUserID            = attribute.Key("user.id")
+	AttrSearchQueryLength = attribute.Key("search.query_length")
+	AttrPaginationPage    = attribute.Key("pagination.page")
+	AttrPaginationLimit   = attribute.Key("pagination.limit")
+	AttrConflictReason    = attribute.Key("conflict.reason")
+	AttrValidationField   = attribute.Key("validation.field")
+	AttrValidationRule    = attribute.Key("validation.rule")
+)
+
+// StartSpan starts a child of the request span and makes it the request's
+// user context, so database spans and logs nest under it. The caller ends it.
+func StartSpan(c *fiber.Ctx, name string, attrs ...attribute.KeyValue) trace.Span {
+	if user := userOf(c); user != "" {
+		attrs = append(attrs, AttrUserID.String(user))
+	}
+
+	ctx, span := otel.Tracer(instrumentationName).Start(c.UserContext(), name, trace.WithAttributes(attrs...))
+	c.SetUserContext(ctx)
+
+	return span
+}
+
+// ValidationFailed adds a "validation.failed" event to the current span.
+func ValidationFailed(ctx context.Context, field, rule string) {
+	trace.SpanFromContext(ctx).AddEvent("validation.failed", trace.WithAttributes(
+		AttrValidationField.String(field),
+		AttrValidationRule.String(rule),
+	))
+}
+
+// Conflict records which conflict branch the current span ended in.
+func Conflict(ctx context.Context, reason string) {
+	trace.SpanFromContext(ctx).SetAttributes(AttrConflictReason.String(reason))
+}
+
+// SpanError records err on the current span and marks it as failed. Emails
+// are masked in the status description, as in the logs.
+func SpanError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	msg := emailPattern.ReplaceAllStringFunc(err.Error(), maskEmail)
+	span.AddEvent(semconv.ExceptionEventName, trace.WithAttributes(
+		semconv.ExceptionType(fmt.Sprintf("%T", err)),
+		semconv.ExceptionMessage(msg),
+	))
+	span.SetStatus(codes.Error, msg)
+}
@@ -0,0 +1,54 @@
+package obs_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/obs/obstest"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpan_NestsUnderRequestAndRecordsError(t *testing.T) {
+	spans := obstest.Spans(t)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userId", "u-1")
+		return c.Next()
+	})
+	app.Get("/courses/:id", func(c *fiber.Ctx) error {
+		span := obs.StartSpan(c, "courses.get", obs.AttrCourseID.String(c.Params("id")))
+		defer span.End()
+
+		if trace.SpanFromContext(c.UserContext()) != span {
+			t.Fatalf("StartSpan did not replace the user context")
+		}
+
+		obs.ValidationFailed(c.UserContext(), "title", "min")
+		obs.SpanError(c.UserContext(), errors.New("lookup failed for jane@example.com"))
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/courses/42", nil)); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+
+	span := obstest.Find(t, spans, "courses.get")
+	if v, _ := obstest.Attr(span, obs.AttrCourseID); v.AsString() != "42" {
+		t.Fatalf("course.id=%q want=42", v.AsString())
+	}
+	if v, _ := obstest.Attr(span, obs.AttrUserID); v.AsString() != "u-1" {
+		t.Fatalf("user.id=%q want=u-1", v.AsString())
+	}
+	if span.Status.Code != codes.Error || span.Status.Description != "lookup failed for j***@example.com" {
+		t.Fatalf("Unexpected status: %+v", span.Status)
+	}
+	if len(span.Events) != 2 || span.Events[0].Name != "validation.failed" || span.Events[1].Name != "exception" {
+		t.Fatalf("Unexpected events: %+v", span.Events)
+	}
+}
@@ -2,42 +2,215 @@ package obs
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"google.golang.org/grpc/credentials"
 )
 
+// Config configures the trace, metric and log providers. ConfigFromEnv fills
+// it from the standard OTEL_* variables plus APP_VERSION and APP_ENV.
+type Config struct {
+	ServiceName string
+	Version     string
+	Environment string
+
+	// TracesExporter is one of "otlp", "stdout" or "none".
+	TracesExporter string
+	// MetricsExporter is "otlp" or "none". Metrics are always exposed at /metrics.
+	MetricsExporter string
+	// LogsExporter is "otlp" or "none". Logs are always written to stdout.
+	LogsExporter string
+
+	// Protocol is "http/protobuf" or "grpc" for the OTLP exporters.
+	Protocol string
+	Endpoint string
+	Headers  map[string]string
+	Insecure bool
+	// CACertFile verifies the collector certificate when not Insecure.
+	CACertFile string
+
+	// SampleRatio is the fraction of new traces sampled. Traces started
+	// upstream keep the sampling decision of their parent.
+	SampleRatio float64
+}
+
+func ConfigFromEnv(serviceName string) Config {
+	cfg := Config{
+		ServiceName:     getenv("OTEL_SERVICE_NAME", serviceName),
+		Version:         getenv("APP_VERSION", "dev"),
+		Environment:     getenv("APP_ENV", "development"),
+		TracesExporter:  strings.ToLower(getenv("OTEL_TRACES_EXPORTER", "otlp")),
+		MetricsExporter: strings.ToLower(getenv("OTEL_METRICS_EXPORTER", "none")),
+		LogsExporter:    strings.ToLower(getenv("OTEL_LOGS_EXPORTER", "none")),
+		Protocol:        strings.ToLower(getenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")),
+		Endpoint:        os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		Headers:         parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		Insecure:        getenv("OTEL_EXPORTER_OTLP_INSECURE", "true") == "true",
+		CACertFile:      os.Getenv("OTEL_EXPORTER_OTLP_CERTIFICATE"),
+		SampleRatio:     1,
+	}
+
+	if ratio, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64); err == nil && ratio >= 0 && ratio <= 1 {
+		cfg.SampleRatio = ratio
+	}
+
+	return cfg
+}
+
+// parseHeaders parses "k1=v1,k2=v2" as used by OTEL_EXPORTER_OTLP_HEADERS.
+func parseHeaders(s string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			headers[k] = strings.TrimSpace(v)
+		}
+	}
+
+	return headers
+}
+
 func InitTracer(ctx context.Context, serviceName string) (*sdktrace.TracerProvider, error) {
-	// OTLP HTTP endpoint (Jaeger/Tempo/OTel collector)
-	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
-	exp, err := otlptracehttp.New(ctx,
-		otlptracehttp.WithEndpointURL(endpoint+"/v1/traces"),
-		otlptracehttp.WithInsecure(),
-	)
+	return InitTracerWithConfig(ctx, ConfigFromEnv(serviceName))
+}
 
+// InitTracerWithConfig installs the global tracer provider and the W3C
+// TraceContext and Baggage propagators. An exporter that cannot be built is
+// logged and dropped so the API still starts; spans are then only used for
+// log correlation.
+func InitTracerWithConfig(ctx context.Context, cfg Config) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(newResource(ctx, cfg)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+
+	exp, err := newSpanExporter(ctx, cfg)
 	if err != nil {
-		return nil, err
+		slog.WarnContext(ctx, "trace exporter disabled", "exporter", cfg.TracesExporter, "error", err)
+	} else if exp != nil {
+		opts = append(opts, sdktrace.WithBatcher(exp, sdktrace.WithBatchTimeout(200*time.Millisecond)))
 	}
 
-	res, _ := resource.Merge(
-		resource.Default(),
-		resource.NewWithAttributes(
-			semconv.SchemaURL,
-			semconv.ServiceName(serviceName),
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return tp, nil
+}
+
+// newResource describes this service for every signal.
+func newResource(ctx context.Context, cfg Config) *resource.Resource {
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithHost(),
+		resource.WithSchemaURL(semconv.SchemaURL),
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(cfg.Version),
+			semconv.DeploymentEnvironmentName(cfg.Environment),
 		),
 	)
+	if err != nil {
+		// resource.New still returns the attributes it could detect.
+		slog.WarnContext(ctx, "resource detection incomplete", "error", err)
+	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exp, sdktrace.WithBatchTimeout(200*time.Millisecond)),
-		sdktrace.WithResource(res),
-	)
+	return res
+}
 
-	otel.SetTracerProvider(tp)
+func newSpanExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.TracesExporter {
+	case "none":
+		return nil, nil
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp", "":
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.TracesExporter)
+	}
 
-	return tp, nil
+	tlsConfig, err := exporterTLS(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch cfg.Protocol {
+	case "grpc":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpointURL(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		} else {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)))
+		}
+
+		return otlptracegrpc.New(ctx, opts...)
+	case "http/protobuf", "http", "":
+		opts := []otlptracehttp.Option{otlptracehttp.WithHeaders(cfg.Headers)}
+		if cfg.Endpoint != "" {
+			// OTLP HTTP endpoint (Jaeger/Tempo/OTel collector)
+			opts = append(opts, otlptracehttp.WithEndpointURL(trimSlash(cfg.Endpoint)+"/v1/traces"))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		} else {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		}
+
+		return otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol %q", cfg.Protocol)
+	}
+}
+
+func exporterTLS(cfg Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.Insecure || cfg.CACertFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.CACertFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.CACertFile)
+	}
+	tlsConfig.RootCAs = pool
+
+	return tlsConfig, nil
+}
+
+func trimSlash(endpoint string) string {
+	return strings.TrimSuffix(endpoint, "/")
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
 }
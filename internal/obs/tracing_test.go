@@ -0,0 +1,78 @@
+package obs_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/guycanella/api-courses-golang/internal/obs"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.25")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer abc, x-tenant=courses")
+	t.Setenv("APP_ENV", "production")
+
+	cfg := obs.ConfigFromEnv("api-courses-golang")
+
+	if cfg.SampleRatio != 0.25 {
+		t.Fatalf("SampleRatio=%v want=0.25", cfg.SampleRatio)
+	}
+	if cfg.Protocol != "grpc" {
+		t.Fatalf("Protocol=%q want=grpc", cfg.Protocol)
+	}
+	if cfg.Headers["authorization"] != "Bearer abc" || cfg.Headers["x-tenant"] != "courses" {
+		t.Fatalf("Unexpected headers: %v", cfg.Headers)
+	}
+	if cfg.Environment != "production" || cfg.ServiceName != "api-courses-golang" {
+		t.Fatalf("Unexpected resource config: %+v", cfg)
+	}
+}
+
+func TestInitTracer_UnreachableCollectorDoesNotFail(t *testing.T) {
+	for _, protocol := range []string{"http/protobuf", "grpc"} {
+		t.Run(protocol, func(t *testing.T) {
+			cfg := obs.ConfigFromEnv("test")
+			cfg.Protocol = protocol
+			cfg.Endpoint = "http://127.0.0.1:1"
+
+			tp, err := obs.InitTracerWithConfig(context.Background(), cfg)
+			if err != nil {
+				t.Fatalf("InitTracerWithConfig: %v", err)
+			}
+			_ = tp.Shutdown(context.Background())
+		})
+	}
+}
+
+func TestInitTracer_HonoursUpstreamTraceparent(t *testing.T) {
+	cfg := obs.ConfigFromEnv("test")
+	cfg.TracesExporter = "none"
+	cfg.SampleRatio = 0
+
+	tp, err := obs.InitTracerWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("InitTracerWithConfig: %v", err)
+	}
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set("baggage", "tenant=courses")
+
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), propagation.HeaderCarrier(header))
+	_, span := tp.Tracer("test").Start(ctx, "child")
+	defer span.End()
+
+	sc := span.SpanContext()
+	if sc.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("Expected upstream trace id, got %s", sc.TraceID())
+	}
+	if !sc.IsSampled() {
+		t.Fatal("Expected the sampled parent decision to be kept despite ratio 0")
+	}
+}
@@ -0,0 +1,162 @@
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/redis/go-redis/v9"
+)
+
+// Rule allows Limit requests per Period, refilled continuously, with bursts of
+// up to Limit requests.
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParseRule parses rules written as "<limit>/<period>", e.g. "100/1m".
+func ParseRule(s string) (Rule, error) {
+	limit, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("invalid rate limit rule %q", s)
+	}
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 {
+		return Rule{}, fmt.Errorf("invalid rate limit %q", limit)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Rule{}, fmt.Errorf("invalid rate limit period %q", period)
+	}
+
+	return Rule{Limit: n, Period: d}, nil
+}
+
+// RuleFromEnv reads the rule of a route group from RATE_LIMIT_<GROUP>.
+func RuleFromEnv(group string, def Rule) Rule {
+	name := "RATE_LIMIT_" + strings.ToUpper(group)
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+
+	rule, err := ParseRule(raw)
+	if err != nil {
+		slog.Warn("invalid rate limit rule, using default", "env", name, "error", err, "limit", def.Limit, "period", def.Period)
+		return def
+	}
+
+	return rule
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+func (rule Rule) result(allowed bool, tokens float64) Result {
+	rate := rateOf(rule)
+	res := Result{
+		Allowed:   allowed,
+		Limit:     rule.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(rule.Limit) - tokens) / rate * float64(time.Second)),
+	}
+
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+
+	return res
+}
+
+type Config struct {
+	// Group names the route group; buckets and metrics are kept per group.
+	Group string
+	Rule  Rule
+	Store Store
+	// Key identifies the client. Defaults to ClientKey.
+	Key func(*fiber.Ctx) string
+	// Next skips the limiter when it returns true.
+	Next func(*fiber.Ctx) bool
+}
+
+// ClientKey identifies the caller by authenticated user, then IP. Only the
+// principal set by auth.Authenticate counts; client-supplied headers do not.
+func ClientKey(c *fiber.Ctx) string {
+	if user, ok := c.Locals("userId").(string); ok && user != "" {
+		return "user:" + user
+	}
+
+	return "ip:" + c.IP()
+}
+
+// New returns a token-bucket middleware. Store failures let requests through.
+func New(cfg Config) fiber.Handler {
+	if cfg.Store == nil {
+		cfg.Store = NewMemory()
+	}
+	if cfg.Key == nil {
+		cfg.Key = ClientKey
+	}
+
+	policy := fmt.Sprintf("%d;w=%d", cfg.Rule.Limit, int(cfg.Rule.Period.Seconds()))
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		res, err := cfg.Store.Take(c.UserContext(), cfg.Group+":"+cfg.Key(c), cfg.Rule, time.Now())
+		if err != nil {
+			obs.Logger(c.UserContext()).WarnContext(c.UserContext(), "rate limit store failed", "route_group", cfg.Group, "error", err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Policy", policy)
+		c.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			metrics.RateLimitRejectionsTotal.WithLabelValues(cfg.Group).Inc()
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "too many requests",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// StoreFromEnv returns a Redis store when RATE_LIMIT_REDIS_ADDR is set, and an
+// in-memory store otherwise.
+func StoreFromEnv() Store {
+	addr := os.Getenv("RATE_LIMIT_REDIS_ADDR")
+	if addr == "" {
+		return NewMemory()
+	}
+
+	return NewRedis(redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("RATE_LIMIT_REDIS_PASSWORD"),
+	}), "api-courses:ratelimit:")
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
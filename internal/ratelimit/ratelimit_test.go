@@ -0,0 +1,167 @@
+package ratelimit_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/ratelimit"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ratelimit.ParseRule("100/1m")
+	if err != nil {
+		t.Fatalf("ParseRule: %v", err)
+	}
+	if rule.Limit != 100 || rule.Period != time.Minute {
+		t.Fatalf("Unexpected rule: %#v", rule)
+	}
+
+	for _, in := range []string{"", "100", "0/1m", "x/1m", "10/forever"} {
+		if _, err := ratelimit.ParseRule(in); err == nil {
+			t.Fatalf("Expected error for %q", in)
+		}
+	}
+}
+
+func testStores(t *testing.T) map[string]ratelimit.Store {
+	srv := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+
+	return map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemory(),
+		"redis":  ratelimit.NewRedis(client, "test:"),
+	}
+}
+
+func TestStore_TokenBucket(t *testing.T) {
+	rule := ratelimit.Rule{Limit: 2, Period: time.Second}
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			for i := 0; i < 2; i++ {
+				res, err := store.Take(ctx, "client", rule, now)
+				if err != nil {
+					t.Fatalf("Take: %v", err)
+				}
+				if !res.Allowed {
+					t.Fatalf("Expected request %d to be allowed", i+1)
+				}
+			}
+
+			res, _ := store.Take(ctx, "client", rule, now)
+			if res.Allowed {
+				t.Fatal("Expected burst to be exhausted")
+			}
+			if res.RetryAfter <= 0 || res.RetryAfter > 500*time.Millisecond {
+				t.Fatalf("Unexpected RetryAfter %v", res.RetryAfter)
+			}
+
+			res, _ = store.Take(ctx, "client", rule, now.Add(600*time.Millisecond))
+			if !res.Allowed {
+				t.Fatal("Expected a token to be refilled")
+			}
+
+			res, _ = store.Take(ctx, "other", rule, now)
+			if !res.Allowed {
+				t.Fatal("Expected buckets to be per key")
+			}
+		})
+	}
+}
+
+func TestMiddleware_429WithHeaders(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/courses", ratelimit.New(ratelimit.Config{
+		Group: "test",
+		Rule:  ratelimit.Rule{Limit: 1, Period: time.Minute},
+	}), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/courses", nil))
+	if err != nil {
+		t.Fatalf("Failed first request: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("RateLimit-Remaining"); got != "0" {
+		t.Fatalf("RateLimit-Remaining=%q want=0", got)
+	}
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/courses", nil))
+	if err != nil {
+		t.Fatalf("Failed second request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status=%d want=%d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if resp.Header.Get("Retry-After") == "" || resp.Header.Get("RateLimit-Limit") != "1" {
+		t.Fatalf("Missing rate limit headers: %v", resp.Header)
+	}
+
+	var out struct {
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.Error == "" {
+		t.Fatalf("Expected error body, got %+v (%v)", out, err)
+	}
+}
+
+func TestMiddleware_IgnoresAPIKeyHeader(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Post("/courses", ratelimit.New(ratelimit.Config{
+		Group: "test",
+		Rule:  ratelimit.Rule{Limit: 1, Period: time.Minute},
+	}), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	want := []int{http.StatusCreated, http.StatusTooManyRequests}
+	for i, key := range []string{"a", "b"} {
+		req := httptest.NewRequest("POST", "/courses", nil)
+		req.Header.Set("X-API-Key", key)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed request: %v", err)
+		}
+		if resp.StatusCode != want[i] {
+			t.Fatalf("key %q status=%d want=%d", key, resp.StatusCode, want[i])
+		}
+	}
+}
+
+func TestMiddleware_KeyedByUser(t *testing.T) {
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("userId", c.Get("X-Test-User"))
+		return c.Next()
+	})
+	app.Post("/courses", ratelimit.New(ratelimit.Config{
+		Group: "test",
+		Rule:  ratelimit.Rule{Limit: 1, Period: time.Minute},
+	}), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	for _, user := range []string{"alice", "bob"} {
+		req := httptest.NewRequest("POST", "/courses", nil)
+		req.Header.Set("X-Test-User", user)
+
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("Failed request: %v", err)
+		}
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("user %q status=%d want=%d", user, resp.StatusCode, http.StatusCreated)
+		}
+	}
+}
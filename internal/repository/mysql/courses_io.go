@@ -0,0 +1,133 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+func (s *Store) ScanCourses(ctx context.Context) (service.CourseCursor, error) {
+	rows, err := s.db.WithContext(ctx).Model(&domain.Course{}).Order("created_at, id").Rows()
+	if err != nil {
+		return nil, err
+	}
+	return &courseCursor{db: s.db, rows: rows}, nil
+}
+
+type courseCursor struct {
+	db   *gorm.DB
+	rows *sql.Rows
+}
+
+func (c *courseCursor) Next() (domain.Course, error) {
+	if !c.rows.Next() {
+		if err := c.rows.Err(); err != nil {
+			return domain.Course{}, err
+		}
+		return domain.Course{}, io.EOF
+	}
+
+	var course domain.Course
+	err := c.db.ScanRows(c.rows, &course)
+	return course, err
+}
+
+func (c *courseCursor) Close() error { return c.rows.Close() }
+
+// importChunkBytes is the size of the chunks an import body is saved in.
+const importChunkBytes = 1 << 20
+
+func (s *Store) CreateImportJob(ctx context.Context, job *domain.CourseImportJob, body io.Reader) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+
+		buf := make([]byte, importChunkBytes)
+		for seq := 0; ; seq++ {
+			n, err := io.ReadFull(body, buf)
+			if n > 0 {
+				chunk := domain.CourseImportChunk{JobID: job.ID, Seq: seq, Data: buf[:n]}
+				if err := tx.Create(&chunk).Error; err != nil {
+					return err
+				}
+			}
+			switch {
+			case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+				return nil
+			case err != nil:
+				return err
+			}
+		}
+	})
+	return storeError(err)
+}
+
+func (s *Store) ImportUpload(ctx context.Context, id string) io.Reader {
+	return &uploadReader{ctx: ctx, db: s.db, jobID: id}
+}
+
+// uploadReader reads the chunks of an import body one at a time.
+type uploadReader struct {
+	ctx   context.Context
+	db    *gorm.DB
+	jobID string
+	seq   int
+	buf   []byte
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		var chunk domain.CourseImportChunk
+		// A replica that lags behind would end the body early.
+		err := r.db.Clauses(dbresolver.Write).WithContext(r.ctx).Take(&chunk, "job_id = ? AND seq = ?", r.jobID, r.seq).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.buf, r.seq = chunk.Data, r.seq+1
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (s *Store) DeleteImportUpload(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).Delete(&domain.CourseImportChunk{}, "job_id = ?", id).Error
+}
+
+func (s *Store) GetImportJob(ctx context.Context, id string) (domain.CourseImportJob, error) {
+	// The primary has the job as soon as it is created and updated.
+	var job domain.CourseImportJob
+	err := s.db.Clauses(dbresolver.Write).WithContext(ctx).First(&job, "id = ?", id).Error
+	return job, storeError(err)
+}
+
+func (s *Store) SaveImportReport(ctx context.Context, id, report string) error {
+	return s.updateImportJob(ctx, id, map[string]any{"report": report})
+}
+
+func (s *Store) SetImportJobStatus(ctx context.Context, id, status, message string) error {
+	fields := map[string]any{"status": status, "error": message}
+	switch status {
+	case domain.ImportJobRunning:
+		fields["started_at"] = time.Now()
+	case domain.ImportJobSucceeded, domain.ImportJobFailed:
+		fields["finished_at"] = time.Now()
+	}
+	return s.updateImportJob(ctx, id, fields)
+}
+
+func (s *Store) updateImportJob(ctx context.Context, id string, fields map[string]any) error {
+	return s.db.WithContext(ctx).Model(&domain.CourseImportJob{}).Where("id = ?", id).Updates(fields).Error
+}
@@ -3,7 +3,9 @@ package mysql
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/guycanella/api-courses-golang/internal/obs"
 	gormmysql "gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -35,8 +37,28 @@ func getenv(k, def string) string {
 	return def
 }
 
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
+
 func open(mode string) (*gorm.DB, error) {
-	return gorm.Open(gormmysql.Open(dsnFromEnv(mode)), &gorm.Config{})
+	db, err := gorm.Open(gormmysql.Open(dsnFromEnv(mode)), &gorm.Config{
+		Logger: obs.NewGormLogger(getdur("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if dsns := replicaDSNsFromEnv(); mode != "test" && len(dsns) > 0 {
+		if err := useReplicas(db, dsns); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
 }
 
 type Env struct{ E *string }
@@ -0,0 +1,221 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/service"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type replica struct {
+	dsn     string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaPolicy spreads reads over the healthy replicas in round-robin order
+// and falls back to the primary once every replica has been ejected.
+type replicaPolicy struct {
+	primary  gorm.ConnPool
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+func (p *replicaPolicy) Resolve(_ []gorm.ConnPool) gorm.ConnPool {
+	n := uint64(len(p.replicas))
+	start := p.next.Add(1)
+
+	for i := uint64(0); i < n; i++ {
+		if r := p.replicas[(start+i)%n]; r.healthy.Load() {
+			return r.db
+		}
+	}
+
+	return p.primary
+}
+
+func (p *replicaPolicy) check(ctx context.Context, timeout time.Duration) {
+	for _, r := range p.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := r.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if r.healthy.Swap(healthy) != healthy {
+			if healthy {
+				slog.Info("db replica is back, routing reads to it", "replica", redactDSN(r.dsn))
+			} else {
+				slog.Warn("db replica ejected", "replica", redactDSN(r.dsn), "error", err)
+			}
+		}
+	}
+}
+
+func (p *replicaPolicy) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		p.check(context.Background(), interval)
+	}
+}
+
+func replicaDSNsFromEnv() []string {
+	var dsns []string
+	for _, dsn := range strings.Split(getenv("DB_REPLICA_DSNS", ""), ",") {
+		if dsn = strings.TrimSpace(dsn); dsn != "" {
+			dsns = append(dsns, dsn)
+		}
+	}
+
+	return dsns
+}
+
+// useReplicas routes reads to the replicas listed in DB_REPLICA_DSNS while
+// writes and transactions stay on the primary.
+func useReplicas(db *gorm.DB, dsns []string) error {
+	primary, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	policy := &replicaPolicy{primary: primary}
+	// The primary is registered as the last replica so the policy is always
+	// consulted, even with a single replica, and can fall back to it.
+	dialectors := make([]gorm.Dialector, 0, len(dsns)+1)
+
+	for _, dsn := range dsns {
+		sqlDB, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return err
+		}
+
+		policy.replicas = append(policy.replicas, &replica{dsn: dsn, db: sqlDB})
+		dialectors = append(dialectors, gormmysql.New(gormmysql.Config{
+			Conn:                      sqlDB,
+			SkipInitializeWithVersion: true,
+		}))
+	}
+
+	dialectors = append(dialectors, gormmysql.New(gormmysql.Config{
+		Conn:                      primary,
+		SkipInitializeWithVersion: true,
+	}))
+
+	interval := getdur("DB_REPLICA_HEALTH_INTERVAL", 5*time.Second)
+	policy.check(context.Background(), interval)
+	go policy.watch(interval)
+
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   policy,
+	})); err != nil {
+		return err
+	}
+
+	return db.Use(newReadYourWrites(getdur("DB_READ_YOUR_WRITES_WINDOW", 5*time.Second)))
+}
+
+// readYourWrites remembers recently written keys so that reads for them can be
+// pinned to the primary while the replicas catch up.
+type readYourWrites struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	written map[string]time.Time
+}
+
+func newReadYourWrites(window time.Duration) *readYourWrites {
+	return &readYourWrites{window: window, written: map[string]time.Time{}}
+}
+
+const readYourWritesName = "api:read_your_writes"
+
+func (*readYourWrites) Name() string { return readYourWritesName }
+
+func (*readYourWrites) Initialize(*gorm.DB) error { return nil }
+
+func (rw *readYourWrites) mark(key string, now time.Time) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	for k, at := range rw.written {
+		if now.Sub(at) > rw.window {
+			delete(rw.written, k)
+		}
+	}
+
+	rw.written[key] = now
+}
+
+func (rw *readYourWrites) recent(key string, now time.Time) bool {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	at, ok := rw.written[key]
+	return ok && now.Sub(at) <= rw.window
+}
+
+func readYourWritesOf(db *gorm.DB) *readYourWrites {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+
+	rw, _ := db.Config.Plugins[readYourWritesName].(*readYourWrites)
+	if rw == nil || rw.window <= 0 {
+		return nil
+	}
+
+	return rw
+}
+
+// MarkWritten records that key was just written on the primary.
+// It is a no-op when no replicas are configured.
+func MarkWritten(db *gorm.DB, key string) {
+	if rw := readYourWritesOf(db); rw != nil {
+		rw.mark(key, time.Now())
+	}
+}
+
+// ForRead returns db pinned to the primary when key was written within the
+// read-your-writes window, and db unchanged otherwise.
+func ForRead(db *gorm.DB, key string) *gorm.DB {
+	if rw := readYourWritesOf(db); rw != nil && rw.recent(key, time.Now()) {
+		return db.Clauses(dbresolver.Write)
+	}
+
+	return db
+}
+
+// readFor returns db bound to ctx, pinned to the primary when ctx was marked
+// by service.FromPrimary.
+func readFor(ctx context.Context, db *gorm.DB) *gorm.DB {
+	db = db.WithContext(ctx)
+	if service.ReadsPrimary(ctx) {
+		return db.Clauses(dbresolver.Write)
+	}
+
+	return db
+}
+
+func redactDSN(dsn string) string {
+	at := strings.LastIndex(dsn, "@")
+	if at < 0 {
+		return dsn
+	}
+
+	creds := dsn[:at]
+	if colon := strings.Index(creds, ":"); colon >= 0 {
+		creds = creds[:colon] + ":***"
+	}
+
+	return creds + dsn[at:]
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func newTestPolicy(t *testing.T, n int) *replicaPolicy {
+	t.Helper()
+
+	primary, _ := sql.Open("mysql", "primary@tcp(127.0.0.1:1)/db")
+	policy := &replicaPolicy{primary: primary}
+
+	for i := 0; i < n; i++ {
+		db, _ := sql.Open("mysql", "replica@tcp(127.0.0.1:1)/db")
+		r := &replica{db: db}
+		r.healthy.Store(true)
+		policy.replicas = append(policy.replicas, r)
+	}
+
+	return policy
+}
+
+func TestReplicaPolicy_RoundRobin(t *testing.T) {
+	policy := newTestPolicy(t, 2)
+
+	first := policy.Resolve(nil)
+	second := policy.Resolve(nil)
+	third := policy.Resolve(nil)
+
+	if first == second {
+		t.Fatalf("Expected consecutive reads on different replicas")
+	}
+	if first != third {
+		t.Fatalf("Expected reads to wrap around the replicas")
+	}
+}
+
+func TestReplicaPolicy_SkipsEjectedReplica(t *testing.T) {
+	policy := newTestPolicy(t, 2)
+	policy.replicas[0].healthy.Store(false)
+
+	for i := 0; i < 4; i++ {
+		if got := policy.Resolve(nil); got != gorm.ConnPool(policy.replicas[1].db) {
+			t.Fatalf("Expected read on the healthy replica, got %v", got)
+		}
+	}
+}
+
+func TestReplicaPolicy_FallsBackToPrimary(t *testing.T) {
+	policy := newTestPolicy(t, 2)
+	for _, r := range policy.replicas {
+		r.healthy.Store(false)
+	}
+
+	if got := policy.Resolve(nil); got != policy.primary {
+		t.Fatalf("Expected read on the primary, got %v", got)
+	}
+}
+
+func TestReadYourWrites_Window(t *testing.T) {
+	rw := newReadYourWrites(time.Second)
+	now := time.Now()
+
+	rw.mark("courses/1", now)
+
+	if !rw.recent("courses/1", now.Add(500*time.Millisecond)) {
+		t.Fatal("Expected key to be pinned inside the window")
+	}
+	if rw.recent("courses/1", now.Add(2*time.Second)) {
+		t.Fatal("Expected key to be released after the window")
+	}
+	if rw.recent("courses/2", now) {
+		t.Fatal("Expected unknown key not to be pinned")
+	}
+}
+
+func TestRedactDSN(t *testing.T) {
+	got := redactDSN("api_user:secret@tcp(replica:3306)/go_api_db?parseTime=true")
+	want := "api_user:***@tcp(replica:3306)/go_api_db?parseTime=true"
+
+	if got != want {
+		t.Fatalf("redactDSN=%q want=%q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *Store) ResolveSlug(ctx context.Context, slug string) (string, string, error) {
+	var course domain.Course
+	err := s.db.WithContext(ctx).Select("id", "slug").Take(&course, "slug = ?", slug).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = s.db.WithContext(ctx).Model(&domain.Course{}).
+			Select("courses.id, courses.slug").
+			Joins("JOIN course_slugs ON course_slugs.course_id = courses.id").
+			Where("course_slugs.slug = ?", slug).
+			Take(&course).Error
+	}
+	if err != nil {
+		return "", "", storeError(err)
+	}
+
+	current := ""
+	if course.Slug != nil {
+		current = *course.Slug
+	}
+	return course.ID, current, nil
+}
+
+// BackfillCourseSlugs gives the courses without a slug the slug of their
+// title, oldest first, and returns how many it updated. It records no domain
+// event.
+func (s *Store) BackfillCourseSlugs(ctx context.Context) (int, error) {
+	var courses []domain.Course
+	err := s.db.WithContext(ctx).Select("id", "title").Where("slug IS NULL").Order("created_at, id").Find(&courses).Error
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range courses {
+		course := &courses[i]
+		slug := service.CourseSlug(course.Title)
+		course.Slug = &slug
+
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := placeCourse(tx, course, nil); err != nil {
+				return err
+			}
+			return tx.Model(course).Update("slug", course.Slug).Error
+		})
+		if err != nil {
+			return i, err
+		}
+		MarkWritten(s.db, "courses/"+course.ID)
+	}
+	return len(courses), nil
+}
+
+// placeCourse moves the course from slug from, nil when it had none, to the
+// first free slug starting with course.Slug, keeping from in its history.
+func placeCourse(tx *gorm.DB, course *domain.Course, from *string) error {
+	if course.Slug == nil || (from != nil && *from == *course.Slug) {
+		return nil
+	}
+
+	slug, err := freeSlug(tx, *course.Slug, course.ID)
+	if err != nil {
+		return err
+	}
+	course.Slug = &slug
+	if from == nil || *from == slug {
+		return nil
+	}
+
+	// the course may take back one of its former slugs
+	if err := tx.Where("slug = ? AND course_id = ?", slug, course.ID).Delete(&domain.CourseSlug{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(&domain.CourseSlug{Slug: *from, CourseID: course.ID}).Error
+}
+
+// freeSlug returns the first of base, base-2, base-3 and so on that no other
+// course holds or held, locking the rows it read so that a concurrent writer
+// of the same base waits.
+func freeSlug(tx *gorm.DB, base, courseID string) (string, error) {
+	var current, former []string
+	err := tx.Model(&domain.Course{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("(slug = ? OR slug LIKE ?) AND id <> ?", base, base+"-%", courseID).
+		Pluck("slug", &current).Error
+	if err != nil {
+		return "", err
+	}
+	err = tx.Model(&domain.CourseSlug{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("(slug = ? OR slug LIKE ?) AND course_id <> ?", base, base+"-%", courseID).
+		Pluck("slug", &former).Error
+	if err != nil {
+		return "", err
+	}
+
+	taken := make(map[string]bool, len(current)+len(former))
+	for _, slug := range append(current, former...) {
+		taken[slug] = true
+	}
+	slug := base
+	for n := 2; taken[slug]; n++ {
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+	return slug, nil
+}
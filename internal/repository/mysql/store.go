@@ -0,0 +1,341 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Store implements the service stores on MySQL. Records it writes are read
+// back from the primary for the read-your-writes window.
+type Store struct {
+	db *gorm.DB
+}
+
+var (
+	_ service.CourseStore     = (*Store)(nil)
+	_ service.UserStore       = (*Store)(nil)
+	_ service.EnrollmentStore = (*Store)(nil)
+	_ service.CategoryStore   = (*Store)(nil)
+	_ service.TagStore        = (*Store)(nil)
+)
+
+func NewStore(db *gorm.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) GetCourse(ctx context.Context, id string, columns ...string) (domain.Course, error) {
+	var course domain.Course
+	err := selectColumns(ForRead(s.db, "courses/"+id).WithContext(ctx), columns).
+		First(&course, "id = ?", id).Error
+	return course, storeError(err)
+}
+
+func (s *Store) ListCourses(ctx context.Context, filter service.CourseFilter, limit, offset int, columns ...string) ([]domain.Course, int64, error) {
+	tx := filterCourses(readFor(ctx, s.db).Model(&domain.Course{}), filter)
+
+	var total int64
+	tx.Count(&total)
+
+	var courses []domain.Course
+	err := selectColumns(tx, columns).
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&courses).Error
+
+	return courses, total, err
+}
+
+func (s *Store) CountEnrollments(ctx context.Context, courseIDs []string) (map[string]int64, error) {
+	var rows []struct {
+		CourseID string
+		Count    int64
+	}
+	err := s.db.WithContext(ctx).Model(&domain.Enrollment{}).
+		Select("course_id, COUNT(*) AS count").
+		Where("course_id IN ?", courseIDs).
+		Group("course_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CourseID] = row.Count
+	}
+	return counts, nil
+}
+
+func (s *Store) UsersByID(ctx context.Context, ids []string) ([]domain.User, error) {
+	var users []domain.User
+	err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+func (s *Store) CourseModules(ctx context.Context, courseIDs []string) ([]domain.CourseModule, error) {
+	var modules []domain.CourseModule
+	err := s.db.WithContext(ctx).
+		Where("course_id IN ?", courseIDs).
+		Order("course_id, position").
+		Find(&modules).Error
+	return modules, err
+}
+
+func (s *Store) CoursesByTitle(ctx context.Context, titles []string) ([]domain.Course, error) {
+	var courses []domain.Course
+	err := s.db.WithContext(ctx).Where("title IN ?", titles).Find(&courses).Error
+	return courses, err
+}
+
+func (s *Store) CoursesByID(ctx context.Context, ids []string) ([]domain.Course, error) {
+	var courses []domain.Course
+	err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&courses).Error
+	return courses, err
+}
+
+func (s *Store) CreateCourse(ctx context.Context, course *domain.Course) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := placeCourse(tx, course, nil); err != nil {
+			return err
+		}
+		if err := tx.Create(course).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.CourseCreated(*course))
+	})
+	if err != nil {
+		return storeError(err)
+	}
+
+	MarkWritten(s.db, "courses/"+course.ID)
+	return nil
+}
+
+func (s *Store) CreateCourses(ctx context.Context, courses []*domain.Course) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, course := range courses {
+			if err := placeCourse(tx, course, nil); err != nil {
+				return err
+			}
+			if err := tx.Create(course).Error; err != nil {
+				return &service.BatchItemError{Index: i, Err: storeError(err)}
+			}
+			if err := events.Record(tx, events.CourseCreated(*course)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return storeError(err)
+	}
+
+	for _, course := range courses {
+		MarkWritten(s.db, "courses/"+course.ID)
+	}
+	return nil
+}
+
+func (s *Store) UpdateCourse(ctx context.Context, id string, change func(*domain.Course) error) (domain.Course, error) {
+	var course domain.Course
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&course, "id = ?", id).Error; err != nil {
+			return err
+		}
+		from := course.Slug
+		if err := change(&course); err != nil {
+			return err
+		}
+		if err := placeCourse(tx, &course, from); err != nil {
+			return err
+		}
+
+		err := tx.Model(&course).
+			Updates(map[string]any{"title": course.Title, "slug": course.Slug, "description": course.Description}).Error
+		if err != nil {
+			return err
+		}
+		return events.Record(tx, events.CourseUpdated(course))
+	})
+	if err != nil {
+		return domain.Course{}, storeError(err)
+	}
+
+	MarkWritten(s.db, "courses/"+course.ID)
+	return course, nil
+}
+
+func (s *Store) GetUser(ctx context.Context, id string) (domain.User, error) {
+	var user domain.User
+	err := ForRead(s.db, "users/"+id).WithContext(ctx).First(&user, "id = ?", id).Error
+	return user, storeError(err)
+}
+
+func (s *Store) ListUsers(ctx context.Context, limit, offset int) ([]domain.User, int64, error) {
+	tx := s.db.WithContext(ctx).Model(&domain.User{})
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []domain.User
+	err := tx.
+		Order("created_at desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&users).Error
+
+	return users, total, err
+}
+
+func (s *Store) CreateUser(ctx context.Context, user *domain.User) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.UserCreated(*user))
+	})
+	if err != nil {
+		return storeError(err)
+	}
+
+	MarkWritten(s.db, "users/"+user.ID)
+	return nil
+}
+
+func (s *Store) UpdateUser(ctx context.Context, id string, change func(*domain.User) error) (domain.User, error) {
+	var user domain.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := change(&user); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.UserUpdated(user))
+	})
+	if err != nil {
+		return domain.User{}, storeError(err)
+	}
+
+	MarkWritten(s.db, "users/"+user.ID)
+	return user, nil
+}
+
+func (s *Store) GetEnrollment(ctx context.Context, id string) (domain.Enrollment, error) {
+	var enrollment domain.Enrollment
+	err := s.db.WithContext(ctx).First(&enrollment, "id = ?", id).Error
+	return enrollment, storeError(err)
+}
+
+func (s *Store) ListEnrollments(ctx context.Context, filter service.EnrollmentFilter, limit, offset int) ([]domain.Enrollment, int64, error) {
+	tx := s.db.WithContext(ctx).Model(&domain.Enrollment{})
+	if filter.UserID != "" {
+		tx = tx.Where("user_id = ?", filter.UserID)
+	}
+	if filter.CourseID != "" {
+		tx = tx.Where("course_id = ?", filter.CourseID)
+	}
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var enrollments []domain.Enrollment
+	err := tx.
+		Order("created_at desc").
+		Order("id desc").
+		Limit(limit).
+		Offset(offset).
+		Find(&enrollments).Error
+
+	return enrollments, total, err
+}
+
+func (s *Store) CreateEnrollment(ctx context.Context, enrollment *domain.Enrollment) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(enrollment).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.EnrollmentCreated(*enrollment))
+	})
+	return storeError(err)
+}
+
+func (s *Store) CreateEnrollments(ctx context.Context, enrollments []*domain.Enrollment) error {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, enrollment := range enrollments {
+			if err := tx.Omit(clause.Associations).Create(enrollment).Error; err != nil {
+				return &service.BatchItemError{Index: i, Err: storeError(err)}
+			}
+			if err := events.Record(tx, events.EnrollmentCreated(*enrollment)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return storeError(err)
+}
+
+func (s *Store) DeleteEnrollment(ctx context.Context, id string) (domain.Enrollment, error) {
+	var enrollment domain.Enrollment
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&enrollment, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&domain.Enrollment{}, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return events.Record(tx, events.EnrollmentCancelled(enrollment))
+	})
+	if err != nil {
+		return domain.Enrollment{}, storeError(err)
+	}
+
+	return enrollment, nil
+}
+
+// storeError maps missing records and MySQL key violations to the service
+// errors, keeping err for logs. Batch item errors are already mapped.
+func storeError(err error) error {
+	var me *mysql.MySQLError
+	errors.As(err, &me)
+	var itemErr *service.BatchItemError
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &itemErr):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return service.ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey), me != nil && me.Number == 1062:
+		return fmt.Errorf("%w: %w", service.ErrDuplicate, err)
+	case errors.Is(err, gorm.ErrForeignKeyViolated), me != nil && me.Number == 1452:
+		return fmt.Errorf("%w: %w", service.ErrMissingReference, err)
+	}
+	return err
+}
+
+// selectColumns limits tx to columns when any are given.
+func selectColumns(tx *gorm.DB, columns []string) *gorm.DB {
+	if len(columns) == 0 {
+		return tx
+	}
+	return tx.Select(columns)
+}
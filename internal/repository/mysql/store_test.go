@@ -0,0 +1,366 @@
+package mysql_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+func TestStore_ListCoursesSelectsColumns(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses` WHERE title LIKE \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT `id`,`title` FROM `courses` WHERE title LIKE \\? ORDER BY created_at desc LIMIT \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("c1", "Go basics"))
+
+	courses, total, err := store.ListCourses(context.Background(), service.CourseFilter{Query: "Go"}, 10, 0, "id", "title")
+	if err != nil {
+		t.Fatalf("ListCourses: %v", err)
+	}
+	if total != 1 || len(courses) != 1 || courses[0].Title != "Go basics" {
+		t.Fatalf("courses=%+v total=%d", courses, total)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_GetCourseSelectsColumns(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectQuery("SELECT `id`,`title` FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("c1", "Go basics"))
+
+	course, err := store.GetCourse(context.Background(), "c1", "id", "title")
+	if err != nil {
+		t.Fatalf("GetCourse: %v", err)
+	}
+	if course.Title != "Go basics" || course.Description != "" {
+		t.Fatalf("course=%+v", course)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_CountEnrollmentsGroupsByCourse(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectQuery("SELECT course_id, COUNT\\(\\*\\) AS count FROM `enrollments` WHERE course_id IN \\(\\?,\\?\\) GROUP BY `course_id`").
+		WillReturnRows(sqlmock.NewRows([]string{"course_id", "count"}).AddRow("c1", 3))
+
+	counts, err := store.CountEnrollments(context.Background(), []string{"c1", "c2"})
+	if err != nil {
+		t.Fatalf("CountEnrollments: %v", err)
+	}
+	if counts["c1"] != 3 || counts["c2"] != 0 {
+		t.Fatalf("counts=%v", counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_CreateCoursesReportsTheFailingItem(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `courses`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `courses`").WillReturnError(&mysql.MySQLError{Number: 1062})
+	mock.ExpectRollback()
+
+	err := store.CreateCourses(context.Background(), []*domain.Course{
+		{ID: "c1", Title: "Go advanced"},
+		{ID: "c2", Title: "Go basics"},
+	})
+	var itemErr *service.BatchItemError
+	if !errors.As(err, &itemErr) || itemErr.Index != 1 || !errors.Is(err, service.ErrDuplicate) {
+		t.Fatalf("err=%v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_ListCoursesFiltersByCategoryAndTag(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	where := "WHERE id IN \\(SELECT course_id FROM course_categories WHERE category_id IN \\(\\?,\\?\\)\\) " +
+		"AND id IN \\(SELECT course_tags.course_id FROM course_tags JOIN tags ON tags.id = course_tags.tag_id WHERE tags.name = \\?\\)"
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses` "+where).
+		WithArgs("cat1", "cat2", "go").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("SELECT \\* FROM `courses` " + where).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	filter := service.CourseFilter{CategoryIDs: []string{"cat1", "cat2"}, Tag: "go"}
+	if _, _, err := store.ListCourses(context.Background(), filter, 10, 0); err != nil {
+		t.Fatalf("ListCourses: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_CountTagsOfTheSearch(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectQuery("SELECT tags.name, COUNT\\(\\*\\) AS count FROM `course_tags` JOIN tags ON tags.id = course_tags.tag_id " +
+		"WHERE course_tags.course_id IN \\(SELECT `id` FROM `courses` WHERE title LIKE \\?\\) GROUP BY `tags`.`name`").
+		WithArgs("%Go%").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("backend", 2))
+
+	counts, err := store.CountTags(context.Background(), service.CourseFilter{Query: "Go"})
+	if err != nil {
+		t.Fatalf("CountTags: %v", err)
+	}
+	if counts["backend"] != 2 {
+		t.Fatalf("counts=%v", counts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_SetCourseCategoriesRejectsUnknownCategories(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT `id` FROM `courses` WHERE id = \\?.*FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("c1"))
+	mock.ExpectQuery("SELECT \\* FROM `categories` WHERE id IN \\(\\?,\\?\\)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}).AddRow("cat1", "Go", "go"))
+	mock.ExpectRollback()
+
+	_, err := store.SetCourseCategories(context.Background(), "c1", []string{"cat1", "cat2"})
+	if !errors.Is(err, service.ErrMissingReference) {
+		t.Fatalf("err=%v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_UpdateCategoryChecksUnderALockOnEveryCategory(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `categories` ORDER BY name FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id"}).
+			AddRow("cat1", "Backend", "backend", "cat2").
+			AddRow("cat2", "Programming", "programming", nil))
+	mock.ExpectRollback()
+
+	refused := errors.New("refused")
+	_, err := store.UpdateCategory(context.Background(), "cat2", func(category *domain.Category, categories []domain.Category) error {
+		if category.ID != "cat2" || len(categories) != 2 {
+			t.Fatalf("category=%+v categories=%+v", category, categories)
+		}
+		return refused
+	})
+	if !errors.Is(err, refused) {
+		t.Fatalf("err=%v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_UpdateCourseMovesToAFreeSlug(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+	id := "4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\? .*FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "slug"}).AddRow(id, "Go Basics", "go-basics"))
+	mock.ExpectQuery("SELECT `slug` FROM `courses` WHERE \\(slug = \\? OR slug LIKE \\?\\) AND id <> \\? FOR UPDATE").
+		WithArgs("go-fundamentals", "go-fundamentals-%", id).
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("go-fundamentals"))
+	mock.ExpectQuery("SELECT `slug` FROM `course_slugs` WHERE \\(slug = \\? OR slug LIKE \\?\\) AND course_id <> \\? FOR UPDATE").
+		WithArgs("go-fundamentals", "go-fundamentals-%", id).
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("go-fundamentals-2"))
+	mock.ExpectExec("DELETE FROM `course_slugs` WHERE slug = \\? AND course_id = \\?").
+		WithArgs("go-fundamentals-3", id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `course_slugs`").
+		WithArgs("go-basics", id, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE `courses` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `outbox_events`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	course, err := store.UpdateCourse(context.Background(), id, func(course *domain.Course) error {
+		slug := "go-fundamentals"
+		course.Title, course.Slug = "Go Fundamentals", &slug
+		return nil
+	})
+	if err != nil || *course.Slug != "go-fundamentals-3" {
+		t.Fatalf("course=%+v err=%v", course, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_ResolveSlugFollowsFormerSlugs(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+	id := "4e70d7c4-5f5b-4f5a-9c9f-0e0b4a7c0d18"
+
+	mock.ExpectQuery("SELECT `id`,`slug` FROM `courses` WHERE slug = \\?").
+		WithArgs("go-basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}))
+	mock.ExpectQuery("SELECT courses.id, courses.slug FROM `courses` JOIN course_slugs ON course_slugs.course_id = courses.id WHERE course_slugs.slug = \\?").
+		WithArgs("go-basics", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(id, "go-fundamentals"))
+
+	gotID, current, err := store.ResolveSlug(context.Background(), "go-basics")
+	if err != nil || gotID != id || current != "go-fundamentals" {
+		t.Fatalf("ResolveSlug=%q,%q,%v", gotID, current, err)
+	}
+}
+
+func TestStore_ListCoursesSearchesTranslatedTitles(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+
+	where := "WHERE \\(title LIKE \\? OR id IN \\(SELECT course_id FROM course_translations WHERE locale IN \\(\\?\\) AND title LIKE \\?\\)\\)"
+	mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses` "+where).
+		WithArgs("%básico%", "pt-BR", "%básico%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("SELECT \\* FROM `courses` " + where).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("c1", "Go basics"))
+
+	filter := service.CourseFilter{Query: "básico", Locales: []string{"pt-BR"}}
+	if _, total, err := store.ListCourses(context.Background(), filter, 10, 0); err != nil || total != 1 {
+		t.Fatalf("ListCourses: total=%d err=%v", total, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_ImportUploadRoundTripsInChunks(t *testing.T) {
+	db, mock := openMockDB(t)
+	store := mysqlrepo.NewStore(db)
+	ctx := context.Background()
+
+	// Two full chunks and a partial one.
+	body := bytes.Repeat([]byte("x"), 2<<20+5)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `course_import_jobs`").WillReturnResult(sqlmock.NewResult(0, 1))
+	for seq := range 3 {
+		mock.ExpectExec("INSERT INTO `course_import_chunks`").
+			WithArgs(sqlmock.AnyArg(), seq, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	job := domain.CourseImportJob{Status: domain.ImportJobPending, Format: "csv"}
+	if err := store.CreateImportJob(ctx, &job, bytes.NewReader(body)); err != nil {
+		t.Fatalf("CreateImportJob: %v", err)
+	}
+
+	for seq, data := range []string{"title\n", "Go basics\n"} {
+		mock.ExpectQuery("SELECT \\* FROM `course_import_chunks` WHERE job_id = \\? AND seq = \\?").
+			WithArgs(job.ID, seq, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"job_id", "seq", "data"}).AddRow(job.ID, seq, []byte(data)))
+	}
+	mock.ExpectQuery("SELECT \\* FROM `course_import_chunks`").
+		WithArgs(job.ID, 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"job_id", "seq", "data"}))
+
+	got, err := io.ReadAll(store.ImportUpload(ctx, job.ID))
+	if err != nil || string(got) != "title\nGo basics\n" {
+		t.Fatalf("upload=%q err=%v", got, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestStore_ListCoursesReadsPrimaryWhenAsked(t *testing.T) {
+	db, primary := openMockDB(t)
+
+	replicaDB, replica, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = replicaDB.Close() })
+
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{gormmysql.New(gormmysql.Config{Conn: replicaDB, SkipInitializeWithVersion: true})},
+	}))
+	if err != nil {
+		t.Fatalf("dbresolver: %v", err)
+	}
+	store := mysqlrepo.NewStore(db)
+
+	for _, tc := range []struct {
+		name string
+		ctx  context.Context
+		mock sqlmock.Sqlmock
+	}{
+		{"replica", context.Background(), replica},
+		{"primary", service.FromPrimary(context.Background()), primary},
+	} {
+		tc.mock.ExpectQuery("SELECT count\\(\\*\\) FROM `courses`").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		tc.mock.ExpectQuery("SELECT \\* FROM `courses`").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		if _, _, err := store.ListCourses(tc.ctx, service.CourseFilter{}, 10, 0); err != nil {
+			t.Fatalf("%s: ListCourses: %v", tc.name, err)
+		}
+	}
+
+	if err := primary.ExpectationsWereMet(); err != nil {
+		t.Fatalf("primary: %v", err)
+	}
+	if err := replica.ExpectationsWereMet(); err != nil {
+		t.Fatalf("replica: %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+package mysql
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// filterCourses narrows a courses query to filter.
+func filterCourses(tx *gorm.DB, filter service.CourseFilter) *gorm.DB {
+	switch {
+	case filter.Query != "" && len(filter.Locales) > 0:
+		tx = tx.Where("(title LIKE ? OR id IN (SELECT course_id FROM course_translations WHERE locale IN ? AND title LIKE ?))",
+			"%"+filter.Query+"%", filter.Locales, "%"+filter.Query+"%")
+	case filter.Query != "":
+		tx = tx.Where("title LIKE ?", "%"+filter.Query+"%")
+	}
+	if len(filter.CategoryIDs) > 0 {
+		tx = tx.Where("id IN (SELECT course_id FROM course_categories WHERE category_id IN ?)", filter.CategoryIDs)
+	}
+	if filter.Tag != "" {
+		tx = tx.Where("id IN (SELECT course_tags.course_id FROM course_tags JOIN tags ON tags.id = course_tags.tag_id WHERE tags.name = ?)", filter.Tag)
+	}
+	return tx
+}
+
+// matchingIDs is the subquery of the IDs of the courses filter matches.
+func (s *Store) matchingIDs(filter service.CourseFilter) *gorm.DB {
+	return filterCourses(s.db.Model(&domain.Course{}).Select("id"), filter)
+}
+
+func (s *Store) CategoryLinks(ctx context.Context, filter service.CourseFilter) ([]service.CategoryLink, error) {
+	var links []service.CategoryLink
+	err := readFor(ctx, s.db).Table("course_categories").
+		Select("course_id, category_id").
+		Where("course_id IN (?)", s.matchingIDs(filter)).
+		Scan(&links).Error
+	return links, err
+}
+
+func (s *Store) CountTags(ctx context.Context, filter service.CourseFilter) (map[string]int64, error) {
+	var rows []struct {
+		Name  string
+		Count int64
+	}
+	err := readFor(ctx, s.db).Table("course_tags").
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN tags ON tags.id = course_tags.tag_id").
+		Where("course_tags.course_id IN (?)", s.matchingIDs(filter)).
+		Group("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Name] = row.Count
+	}
+	return counts, nil
+}
+
+func (s *Store) CourseCategories(ctx context.Context, courseIDs []string) (map[string][]domain.Category, error) {
+	var rows []struct {
+		CourseID  string
+		ID        string
+		Name      string
+		Slug      string
+		ParentID  *string
+		CreatedAt time.Time
+	}
+	err := s.db.WithContext(ctx).Table("categories").
+		Select("course_categories.course_id, categories.id, categories.name, categories.slug, categories.parent_id, categories.created_at").
+		Joins("JOIN course_categories ON course_categories.category_id = categories.id").
+		Where("course_categories.course_id IN ?", courseIDs).
+		Order("categories.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byCourse := make(map[string][]domain.Category, len(courseIDs))
+	for _, row := range rows {
+		byCourse[row.CourseID] = append(byCourse[row.CourseID], domain.Category{
+			ID:        row.ID,
+			Name:      row.Name,
+			Slug:      row.Slug,
+			ParentID:  row.ParentID,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+	return byCourse, nil
+}
+
+func (s *Store) CourseTags(ctx context.Context, courseIDs []string) (map[string][]domain.Tag, error) {
+	var rows []struct {
+		CourseID  string
+		ID        string
+		Name      string
+		CreatedAt time.Time
+	}
+	err := s.db.WithContext(ctx).Table("tags").
+		Select("course_tags.course_id, tags.id, tags.name, tags.created_at").
+		Joins("JOIN course_tags ON course_tags.tag_id = tags.id").
+		Where("course_tags.course_id IN ?", courseIDs).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byCourse := make(map[string][]domain.Tag, len(courseIDs))
+	for _, row := range rows {
+		byCourse[row.CourseID] = append(byCourse[row.CourseID], domain.Tag{ID: row.ID, Name: row.Name, CreatedAt: row.CreatedAt})
+	}
+	return byCourse, nil
+}
+
+// SetCourseCategories records no domain event.
+func (s *Store) SetCourseCategories(ctx context.Context, courseID string, categoryIDs []string) ([]domain.Category, error) {
+	categories := []domain.Category{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCourse(tx, courseID); err != nil {
+			return err
+		}
+
+		if len(categoryIDs) > 0 {
+			if err := tx.Where("id IN ?", categoryIDs).Order("name").Find(&categories).Error; err != nil {
+				return err
+			}
+			if len(categories) != len(categoryIDs) {
+				return service.ErrMissingReference
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM course_categories WHERE course_id = ?", courseID).Error; err != nil {
+			return err
+		}
+		if len(categories) == 0 {
+			return nil
+		}
+		links := make([]map[string]any, len(categories))
+		for i, category := range categories {
+			links[i] = map[string]any{"course_id": courseID, "category_id": category.ID}
+		}
+		return tx.Table("course_categories").Create(links).Error
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	return categories, nil
+}
+
+// SetCourseTags records no domain event.
+func (s *Store) SetCourseTags(ctx context.Context, courseID string, names []string) ([]domain.Tag, error) {
+	tags := []domain.Tag{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCourse(tx, courseID); err != nil {
+			return err
+		}
+
+		if len(names) > 0 {
+			if err := tx.Where("name IN ?", names).Find(&tags).Error; err != nil {
+				return err
+			}
+			known := make(map[string]bool, len(tags))
+			for _, tag := range tags {
+				known[tag.Name] = true
+			}
+			var missing []domain.Tag
+			for _, name := range names {
+				if !known[name] {
+					missing = append(missing, domain.Tag{Name: name})
+				}
+			}
+			if len(missing) > 0 {
+				if err := tx.Create(&missing).Error; err != nil {
+					return err
+				}
+				tags = append(tags, missing...)
+			}
+		}
+
+		if err := tx.Exec("DELETE FROM course_tags WHERE course_id = ?", courseID).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		links := make([]map[string]any, len(tags))
+		for i, tag := range tags {
+			links[i] = map[string]any{"course_id": courseID, "tag_id": tag.ID}
+		}
+		return tx.Table("course_tags").Create(links).Error
+	})
+	if err != nil {
+		return nil, storeError(err)
+	}
+
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags, nil
+}
+
+// lockCourse locks the course row, failing with gorm.ErrRecordNotFound when
+// it does not exist.
+func lockCourse(tx *gorm.DB, courseID string) error {
+	var course domain.Course
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&course, "id = ?", courseID).Error
+}
+
+func (s *Store) GetCategory(ctx context.Context, id string) (domain.Category, error) {
+	var category domain.Category
+	err := s.db.WithContext(ctx).First(&category, "id = ?", id).Error
+	return category, storeError(err)
+}
+
+func (s *Store) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	var categories []domain.Category
+	err := readFor(ctx, s.db).Order("name").Find(&categories).Error
+	return categories, err
+}
+
+func (s *Store) CreateCategory(ctx context.Context, category *domain.Category) error {
+	return storeError(s.db.WithContext(ctx).Omit(clause.Associations).Create(category).Error)
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, id string, change func(category *domain.Category, categories []domain.Category) error) (domain.Category, error) {
+	var category domain.Category
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Two moves checked against the same tree could create a cycle
+		// together, so they take their turns on the whole set.
+		var categories []domain.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("name").Find(&categories).Error; err != nil {
+			return err
+		}
+		i := slices.IndexFunc(categories, func(c domain.Category) bool { return c.ID == id })
+		if i < 0 {
+			return gorm.ErrRecordNotFound
+		}
+		category = categories[i]
+		if err := change(&category, categories); err != nil {
+			return err
+		}
+
+		return tx.Model(&category).
+			Updates(map[string]any{"name": category.Name, "slug": category.Slug, "parent_id": category.ParentID}).Error
+	})
+	if err != nil {
+		return domain.Category{}, storeError(err)
+	}
+
+	return category, nil
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, id string) (domain.Category, error) {
+	var category domain.Category
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&category, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM course_categories WHERE category_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Category{}, "id = ?", id).Error
+	})
+	if err != nil {
+		return domain.Category{}, storeError(err)
+	}
+
+	return category, nil
+}
+
+func (s *Store) GetTag(ctx context.Context, name string) (domain.Tag, error) {
+	var tag domain.Tag
+	err := s.db.WithContext(ctx).First(&tag, "name = ?", name).Error
+	return tag, storeError(err)
+}
+
+func (s *Store) ListTags(ctx context.Context, limit, offset int) ([]domain.Tag, int64, error) {
+	tx := s.db.WithContext(ctx).Model(&domain.Tag{})
+
+	var total int64
+	if err := tx.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var tags []domain.Tag
+	err := tx.Order("name").Limit(limit).Offset(offset).Find(&tags).Error
+	return tags, total, err
+}
+
+func (s *Store) CreateTag(ctx context.Context, tag *domain.Tag) error {
+	return storeError(s.db.WithContext(ctx).Create(tag).Error)
+}
+
+func (s *Store) UpdateTag(ctx context.Context, name string, change func(*domain.Tag) error) (domain.Tag, error) {
+	var tag domain.Tag
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tag, "name = ?", name).Error; err != nil {
+			return err
+		}
+		if err := change(&tag); err != nil {
+			return err
+		}
+
+		return tx.Model(&tag).Update("name", tag.Name).Error
+	})
+	if err != nil {
+		return domain.Tag{}, storeError(err)
+	}
+
+	return tag, nil
+}
+
+func (s *Store) DeleteTag(ctx context.Context, name string) (domain.Tag, error) {
+	var tag domain.Tag
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tag, "name = ?", name).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM course_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Tag{}, "id = ?", tag.ID).Error
+	})
+	if err != nil {
+		return domain.Tag{}, storeError(err)
+	}
+
+	return tag, nil
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (s *Store) CourseTranslations(ctx context.Context, courseIDs, locales []string) ([]domain.CourseTranslation, error) {
+	tx := s.db.WithContext(ctx).Where("course_id IN ?", courseIDs)
+	if len(locales) > 0 {
+		tx = tx.Where("locale IN ?", locales)
+	}
+
+	var translations []domain.CourseTranslation
+	err := tx.Order("locale").Find(&translations).Error
+	return translations, err
+}
+
+// PutCourseTranslation records no domain event.
+func (s *Store) PutCourseTranslation(ctx context.Context, translation *domain.CourseTranslation) (bool, error) {
+	var created bool
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var course domain.Course
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&course, "id = ?", translation.CourseID).Error; err != nil {
+			return err
+		}
+
+		var existing domain.CourseTranslation
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Take(&existing, "course_id = ? AND locale = ?", translation.CourseID, translation.Locale).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			created = true
+			return tx.Create(translation).Error
+		case err != nil:
+			return err
+		}
+
+		translation.CreatedAt = existing.CreatedAt
+		return tx.Model(translation).
+			Updates(map[string]any{"title": translation.Title, "description": translation.Description}).Error
+	})
+	return created, storeError(err)
+}
+
+// DeleteCourseTranslation records no domain event.
+func (s *Store) DeleteCourseTranslation(ctx context.Context, courseID, locale string) (domain.CourseTranslation, error) {
+	var translation domain.CourseTranslation
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Take(&translation, "course_id = ? AND locale = ?", courseID, locale).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&translation).Error
+	})
+	return translation, storeError(err)
+}
@@ -0,0 +1,128 @@
+// Package rpc serves the gRPC API defined in proto/courses/v1. It calls the
+// same service layer as the REST and GraphQL APIs, authenticates with the
+// AUTH_TOKENS bearer tokens and maps service errors to status codes:
+// invalid input to InvalidArgument, missing records to NotFound and
+// uniqueness conflicts to AlreadyExists.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/rpc/coursesv1"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type Config struct {
+	// Addr is the listen address; empty disables the server.
+	Addr string
+}
+
+// ConfigFromEnv reads GRPC_ADDR (default ":50051"; "off" disables the server).
+func ConfigFromEnv() Config {
+	addr := os.Getenv("GRPC_ADDR")
+	switch addr {
+	case "":
+		addr = ":50051"
+	case "off":
+		addr = ""
+	}
+	return Config{Addr: addr}
+}
+
+type Deps struct {
+	DB     *gorm.DB
+	Cache  *cache.Cache
+	Tokens *auth.Tokens
+}
+
+// NewServer returns a server with the course, user and enrollment services,
+// the health service and reflection registered.
+func NewServer(deps Deps) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(authenticate(deps.Tokens)),
+	)
+
+	store := mysqlrepo.NewStore(deps.DB)
+	coursesv1.RegisterCourseServiceServer(srv, &courseServer{
+		courses: service.NewCourseService(store, service.WithCache(deps.Cache)),
+	})
+	coursesv1.RegisterUserServiceServer(srv, &userServer{users: service.NewUserService(store)})
+	coursesv1.RegisterEnrollmentServiceServer(srv, &enrollmentServer{
+		enrollments: service.NewEnrollmentService(store),
+	})
+
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+
+	return srv
+}
+
+// Start serves NewServer on cfg.Addr in the background. The caller stops the
+// returned server, which is nil when cfg.Addr is empty.
+func Start(ctx context.Context, cfg Config, deps Deps) (*grpc.Server, error) {
+	if cfg.Addr == "" {
+		return nil, nil
+	}
+
+	lis, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	srv := NewServer(deps)
+	go func() {
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			slog.ErrorContext(ctx, "grpc listener stopped", "addr", cfg.Addr, "error", err)
+		}
+	}()
+
+	slog.InfoContext(ctx, "grpc listener started", "addr", cfg.Addr)
+	return srv, nil
+}
+
+// toStatus maps a service error to a status. Unexpected errors are logged and
+// hidden from the client.
+func toStatus(ctx context.Context, err error) error {
+	var invalid *service.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		st := status.New(codes.InvalidArgument, err.Error())
+		details := &errdetails.BadRequest{}
+		for field, msg := range invalid.Fields {
+			details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       field,
+				Description: msg,
+			})
+		}
+		if withDetails, err := st.WithDetails(details); err == nil {
+			st = withDetails
+		}
+		return st.Err()
+	case errors.Is(err, service.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrConflict):
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	obs.Logger(ctx).ErrorContext(ctx, "grpc internal error", "error", err)
+	obs.SpanError(ctx, err)
+	return status.Error(codes.Internal, "internal server error")
+}
@@ -0,0 +1,188 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/guycanella/api-courses-golang/internal/auth"
+	"github.com/guycanella/api-courses-golang/internal/rpc"
+	"github.com/guycanella/api-courses-golang/internal/rpc/coursesv1"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+// expectFreeSlug expects the lookups of the slugs a new course could clash
+// with, answering that none is taken.
+func expectFreeSlug(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery("SELECT `slug` FROM `courses`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+	mock.ExpectQuery("SELECT `slug` FROM `course_slugs`").WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+}
+
+// dial serves rpc.NewServer over an in-memory listener.
+func dial(t *testing.T, db *gorm.DB) *grpc.ClientConn {
+	t.Helper()
+
+	tokens, err := auth.ParseTokens("dashboard=tok1=users:read;ops=tok2=courses:read")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := rpc.NewServer(rpc.Deps{DB: db, Tokens: tokens})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestServer_Health(t *testing.T) {
+	conn := dial(t, nil)
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("status=%v", resp.GetStatus())
+	}
+}
+
+func TestServer_ChecksScopes(t *testing.T) {
+	users := coursesv1.NewUserServiceClient(dial(t, nil))
+	get := func(ctx context.Context) error {
+		_, err := users.GetUser(ctx, &coursesv1.GetUserRequest{Id: "x"})
+		return err
+	}
+	create := func(ctx context.Context) error {
+		_, err := users.CreateUser(ctx, &coursesv1.CreateUserRequest{Email: "ana@example.com", Name: "Ana"})
+		return err
+	}
+
+	courses := coursesv1.NewCourseServiceClient(dial(t, nil))
+	update := func(ctx context.Context) error {
+		title := "Go basics"
+		_, err := courses.UpdateCourse(ctx, &coursesv1.UpdateCourseRequest{Id: "x", Title: &title})
+		return err
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		call func(context.Context) error
+		want codes.Code
+	}{
+		{"anonymous", context.Background(), get, codes.Unauthenticated},
+		{"unknown token", withToken("nope"), get, codes.Unauthenticated},
+		{"missing scope", withToken("tok2"), get, codes.PermissionDenied},
+		{"read scope only", withToken("tok1"), create, codes.PermissionDenied},
+		{"anonymous course update", context.Background(), update, codes.Unauthenticated},
+		{"course update without courses:admin", withToken("tok2"), update, codes.PermissionDenied},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := status.Code(tc.call(tc.ctx)); got != tc.want {
+				t.Fatalf("code=%v want=%v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestServer_InvalidArgument(t *testing.T) {
+	courses := coursesv1.NewCourseServiceClient(dial(t, nil))
+
+	_, err := courses.CreateCourse(context.Background(), &coursesv1.CreateCourseRequest{Title: "Go"})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("code=%v err=%v", st.Code(), err)
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, d := range st.Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			violations = br.GetFieldViolations()
+		}
+	}
+	if len(violations) != 1 || violations[0].GetField() != "title" || violations[0].GetDescription() != "must be at least 3 characters" {
+		t.Fatalf("violations=%v", violations)
+	}
+}
+
+func TestServer_NotFound(t *testing.T) {
+	db, mock := openMockDB(t)
+	courses := coursesv1.NewCourseServiceClient(dial(t, db))
+
+	mock.ExpectQuery("SELECT \\* FROM `courses` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "created_at"}))
+
+	_, err := courses.GetCourse(context.Background(), &coursesv1.GetCourseRequest{Id: "7c9e6679-7425-40de-944b-e07fc1f90ae7"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("err=%v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestServer_TitleConflictIsAlreadyExists(t *testing.T) {
+	db, mock := openMockDB(t)
+	courses := coursesv1.NewCourseServiceClient(dial(t, db))
+
+	mock.ExpectBegin()
+	expectFreeSlug(mock)
+	mock.ExpectExec("INSERT INTO `courses`").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	_, err := courses.CreateCourse(context.Background(), &coursesv1.CreateCourseRequest{Title: "Go basics"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("err=%v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
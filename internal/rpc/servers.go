@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/rpc/coursesv1"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type courseServer struct {
+	coursesv1.UnimplementedCourseServiceServer
+	courses *service.CourseService
+}
+
+func (s *courseServer) GetCourse(ctx context.Context, req *coursesv1.GetCourseRequest) (*coursesv1.Course, error) {
+	course, err := s.courses.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toCourse(course), nil
+}
+
+func (s *courseServer) ListCourses(ctx context.Context, req *coursesv1.ListCoursesRequest) (*coursesv1.ListCoursesResponse, error) {
+	page, err := s.courses.List(ctx, service.ListCourses{
+		Page:  service.Page{Page: int(req.GetPage()), Limit: int(req.GetLimit())},
+		Query: req.GetQuery(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &coursesv1.ListCoursesResponse{Total: page.Total}
+	for _, course := range page.Data {
+		resp.Courses = append(resp.Courses, toCourse(course))
+	}
+	return resp, nil
+}
+
+func (s *courseServer) CreateCourse(ctx context.Context, req *coursesv1.CreateCourseRequest) (*coursesv1.Course, error) {
+	course, err := s.courses.Create(ctx, service.CourseInput{Title: req.GetTitle(), Description: req.GetDescription()})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toCourse(course), nil
+}
+
+func (s *courseServer) UpdateCourse(ctx context.Context, req *coursesv1.UpdateCourseRequest) (*coursesv1.Course, error) {
+	course, err := s.courses.Update(ctx, req.GetId(), service.CourseUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toCourse(course), nil
+}
+
+type userServer struct {
+	coursesv1.UnimplementedUserServiceServer
+	users *service.UserService
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *coursesv1.GetUserRequest) (*coursesv1.User, error) {
+	user, err := s.users.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *coursesv1.ListUsersRequest) (*coursesv1.ListUsersResponse, error) {
+	page, err := s.users.List(ctx, service.Page{Page: int(req.GetPage()), Limit: int(req.GetLimit())})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &coursesv1.ListUsersResponse{Total: page.Total}
+	for _, user := range page.Data {
+		resp.Users = append(resp.Users, toUser(user))
+	}
+	return resp, nil
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *coursesv1.CreateUserRequest) (*coursesv1.User, error) {
+	user, err := s.users.Create(ctx, service.UserInput{Email: req.GetEmail(), Name: req.GetName()})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+func (s *userServer) UpdateUser(ctx context.Context, req *coursesv1.UpdateUserRequest) (*coursesv1.User, error) {
+	user, err := s.users.Update(ctx, req.GetId(), service.UserUpdate{Email: req.Email, Name: req.Name})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toUser(user), nil
+}
+
+type enrollmentServer struct {
+	coursesv1.UnimplementedEnrollmentServiceServer
+	enrollments *service.EnrollmentService
+}
+
+func (s *enrollmentServer) GetEnrollment(ctx context.Context, req *coursesv1.GetEnrollmentRequest) (*coursesv1.Enrollment, error) {
+	enrollment, err := s.enrollments.Get(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toEnrollment(enrollment), nil
+}
+
+func (s *enrollmentServer) ListEnrollments(ctx context.Context, req *coursesv1.ListEnrollmentsRequest) (*coursesv1.ListEnrollmentsResponse, error) {
+	page, err := s.enrollments.List(ctx, service.ListEnrollments{
+		Page:     service.Page{Page: int(req.GetPage()), Limit: int(req.GetLimit())},
+		UserID:   req.GetUserId(),
+		CourseID: req.GetCourseId(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &coursesv1.ListEnrollmentsResponse{Total: page.Total}
+	for _, enrollment := range page.Data {
+		resp.Enrollments = append(resp.Enrollments, toEnrollment(enrollment))
+	}
+	return resp, nil
+}
+
+func (s *enrollmentServer) CreateEnrollment(ctx context.Context, req *coursesv1.CreateEnrollmentRequest) (*coursesv1.Enrollment, error) {
+	enrollment, err := s.enrollments.Create(ctx, service.EnrollmentInput{
+		UserID:   req.GetUserId(),
+		CourseID: req.GetCourseId(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toEnrollment(enrollment), nil
+}
+
+func (s *enrollmentServer) CancelEnrollment(ctx context.Context, req *coursesv1.CancelEnrollmentRequest) (*coursesv1.Enrollment, error) {
+	enrollment, err := s.enrollments.Cancel(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toEnrollment(enrollment), nil
+}
+
+func toCourse(c domain.Course) *coursesv1.Course {
+	return &coursesv1.Course{
+		Id:          c.ID,
+		Title:       c.Title,
+		Description: c.Description,
+		CreatedAt:   timestamppb.New(c.CreatedAt),
+	}
+}
+
+func toUser(u domain.User) *coursesv1.User {
+	return &coursesv1.User{
+		Id:        u.ID,
+		Email:     u.Email,
+		Name:      u.Name,
+		CreatedAt: timestamppb.New(u.CreatedAt),
+	}
+}
+
+func toEnrollment(e domain.Enrollment) *coursesv1.Enrollment {
+	return &coursesv1.Enrollment{
+		Id:        e.ID,
+		UserId:    e.UserID,
+		CourseId:  e.CourseID,
+		CreatedAt: timestamppb.New(e.CreatedAt),
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+)
+
+// BatchMode is how a batch treats failing items.
+type BatchMode string
+
+const (
+	// BatchAtomic applies every item in one transaction, or none of them.
+	BatchAtomic BatchMode = "atomic"
+	// BatchBestEffort applies each item on its own.
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// MaxBatchItems bounds the items of one batch.
+const MaxBatchItems = 100
+
+// ErrNotApplied is the error of the valid items of a failed atomic batch.
+var ErrNotApplied = errors.New("not applied: another item failed")
+
+// BatchResult is the outcome of one item, as the single call would return it.
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// Failed reports whether any item failed.
+func Failed[T any](results []BatchResult[T]) bool {
+	for _, r := range results {
+		if r.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBatch validates the mode, defaulting to atomic, and the item count.
+func checkBatch(mode BatchMode, field string, n int) (BatchMode, error) {
+	invalid := map[string]string{}
+	switch mode {
+	case "":
+		mode = BatchAtomic
+	case BatchAtomic, BatchBestEffort:
+	default:
+		invalid["mode"] = "is invalid"
+	}
+
+	switch {
+	case n == 0:
+		invalid[field] = "is required"
+	case n > MaxBatchItems:
+		invalid[field] = fmt.Sprintf("at most %d items", MaxBatchItems)
+	}
+
+	if len(invalid) > 0 {
+		return mode, &ValidationError{Fields: invalid}
+	}
+	return mode, nil
+}
+
+// abort fails a whole atomic batch: items without an error of their own were
+// not applied.
+func abort[T any](results []BatchResult[T]) []BatchResult[T] {
+	for i := range results {
+		var zero T
+		results[i].Value = zero
+		if results[i].Err == nil {
+			results[i].Err = ErrNotApplied
+		}
+	}
+	return results
+}
+
+// CreateBatch creates courses like Create. The error is only set when the
+// batch itself is invalid or the store failed outside any item.
+func (s *CourseService) CreateBatch(ctx context.Context, mode BatchMode, inputs []CourseInput) ([]BatchResult[domain.Course], error) {
+	mode, err := checkBatch(mode, "items", len(inputs))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult[domain.Course], len(inputs))
+	if mode == BatchBestEffort {
+		for i, in := range inputs {
+			results[i].Value, results[i].Err = s.Create(ctx, in)
+		}
+		return results, nil
+	}
+
+	courses := make([]*domain.Course, len(inputs))
+	for i, in := range inputs {
+		if err := check(ctx, "course", &in); err != nil {
+			results[i].Err = err
+			continue
+		}
+		courses[i] = &domain.Course{ID: uuid.NewString(), Description: strings.TrimSpace(in.Description)}
+		retitle(courses[i], strings.TrimSpace(in.Title))
+	}
+	if Failed(results) {
+		return abort(results), nil
+	}
+
+	var itemErr *BatchItemError
+	err = s.store.CreateCourses(ctx, courses)
+	switch {
+	case errors.As(err, &itemErr) && errors.Is(itemErr.Err, ErrDuplicate):
+		results[itemErr.Index].Err = titleTaken(ctx)
+		return abort(results), nil
+	case errors.As(err, &itemErr):
+		results[itemErr.Index].Err = itemErr.Err
+		return abort(results), nil
+	case err != nil:
+		return nil, err
+	}
+
+	for i, course := range courses {
+		results[i].Value = *course
+		s.written(ctx, course.ID)
+	}
+	s.cache.Bump(ctx, coursesListNamespace)
+	metrics.CoursesCreatedTotal.Add(float64(len(courses)))
+	return results, nil
+}
+
+// GetBatch reads courses like Get. In atomic mode no course is returned
+// unless all of them exist.
+func (s *CourseService) GetBatch(ctx context.Context, mode BatchMode, ids []string) ([]BatchResult[domain.Course], error) {
+	mode, err := checkBatch(mode, "ids", len(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult[domain.Course], len(ids))
+	var valid []string
+	for i, id := range ids {
+		if results[i].Err = checkID("id", id); results[i].Err == nil {
+			valid = append(valid, id)
+		}
+	}
+
+	var found []domain.Course
+	if len(valid) > 0 {
+		if found, err = s.store.CoursesByID(ctx, valid); err != nil {
+			return nil, err
+		}
+		if err := s.localize(ctx, found); err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]domain.Course, len(found))
+	for _, course := range found {
+		byID[course.ID] = course
+	}
+
+	for i, id := range ids {
+		if results[i].Err != nil {
+			continue
+		}
+		course, ok := byID[id]
+		if !ok {
+			results[i].Err = notFound("course")
+			continue
+		}
+		results[i].Value = course
+	}
+
+	if mode == BatchAtomic && Failed(results) {
+		return abort(results), nil
+	}
+	return results, nil
+}
+
+// EnrollBatch enrolls users in the course like Create.
+func (s *EnrollmentService) EnrollBatch(ctx context.Context, mode BatchMode, courseID string, userIDs []string) ([]BatchResult[domain.Enrollment], error) {
+	mode, err := checkBatch(mode, "userIds", len(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkID("courseId", courseID); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult[domain.Enrollment], len(userIDs))
+	if mode == BatchBestEffort {
+		for i, userID := range userIDs {
+			results[i].Value, results[i].Err = s.Create(ctx, EnrollmentInput{UserID: userID, CourseID: courseID})
+		}
+		return results, nil
+	}
+
+	enrollments := make([]*domain.Enrollment, len(userIDs))
+	for i, userID := range userIDs {
+		in := EnrollmentInput{UserID: userID, CourseID: courseID}
+		if err := check(ctx, "enrollment", &in); err != nil {
+			results[i].Err = err
+			continue
+		}
+		enrollments[i] = &domain.Enrollment{ID: uuid.NewString(), UserID: userID, CourseID: courseID}
+	}
+	if Failed(results) {
+		return abort(results), nil
+	}
+
+	var itemErr *BatchItemError
+	err = s.store.CreateEnrollments(ctx, enrollments)
+	switch {
+	case errors.As(err, &itemErr):
+		results[itemErr.Index].Err = s.enrollError(ctx, itemErr.Err)
+		return abort(results), nil
+	case err != nil:
+		return nil, err
+	}
+
+	for i, enrollment := range enrollments {
+		results[i].Value = *enrollment
+	}
+	metrics.EnrollmentsCreatedTotal.Add(float64(len(enrollments)))
+	return results, nil
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+// CategoryInput is a new category, or the full state of an updated one. Slug
+// defaults to the slug of Name.
+type CategoryInput struct {
+	Name     string  `json:"name"      validate:"required,min=2,max=100"`
+	Slug     string  `json:"slug"      validate:"required,max=100"`
+	ParentID *string `json:"parent_id" validate:"omitempty,uuid"`
+}
+
+func (in *CategoryInput) normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+	if in.Slug = Slug(in.Slug); in.Slug == "" {
+		in.Slug = Slug(in.Name)
+	}
+	if in.ParentID != nil && strings.TrimSpace(*in.ParentID) == "" {
+		in.ParentID = nil
+	}
+}
+
+// CategoryUpdate changes the fields that are not nil. An empty ParentID moves
+// the category to the top level.
+type CategoryUpdate struct {
+	Name     *string
+	Slug     *string
+	ParentID *string
+}
+
+type CategoryService struct {
+	store CategoryStore
+	cache *cache.Cache
+}
+
+// NewCategoryService returns the service. Writes invalidate the course list
+// pages cached in c, which hold category facets.
+func NewCategoryService(store CategoryStore, c *cache.Cache) *CategoryService {
+	return &CategoryService{store: store, cache: c}
+}
+
+func (s *CategoryService) Get(ctx context.Context, id string) (domain.Category, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Category{}, err
+	}
+
+	category, err := s.store.GetCategory(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return domain.Category{}, notFound("category")
+	}
+
+	return category, err
+}
+
+// List returns every category, by name. Subcategories name their parent in
+// ParentID.
+func (s *CategoryService) List(ctx context.Context) ([]domain.Category, error) {
+	return s.store.ListCategories(ctx)
+}
+
+func (s *CategoryService) Create(ctx context.Context, in CategoryInput) (domain.Category, error) {
+	in.normalize()
+	if err := check(ctx, "category", &in); err != nil {
+		return domain.Category{}, err
+	}
+
+	categories, err := s.store.ListCategories(ctx)
+	if err != nil {
+		return domain.Category{}, err
+	}
+	if err := checkParent(categories, "", in.ParentID); err != nil {
+		return domain.Category{}, err
+	}
+
+	category := domain.Category{ID: uuid.NewString(), Name: in.Name, Slug: in.Slug, ParentID: in.ParentID}
+	err = s.store.CreateCategory(ctx, &category)
+	if errors.Is(err, ErrDuplicate) {
+		return domain.Category{}, slugTaken(ctx)
+	}
+	if err != nil {
+		return domain.Category{}, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return category, nil
+}
+
+// Update changes the category. Its slug only changes when Slug is set.
+func (s *CategoryService) Update(ctx context.Context, id string, in CategoryUpdate) (domain.Category, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Category{}, err
+	}
+
+	category, err := s.store.UpdateCategory(ctx, id, func(category *domain.Category, categories []domain.Category) error {
+		next := CategoryInput{Name: category.Name, Slug: category.Slug, ParentID: category.ParentID}
+		if in.Name != nil {
+			next.Name = *in.Name
+		}
+		if in.Slug != nil {
+			next.Slug = *in.Slug
+		}
+		if in.ParentID != nil {
+			next.ParentID = in.ParentID
+		}
+		next.normalize()
+		if err := check(ctx, "category", &next); err != nil {
+			return err
+		}
+		if err := checkParent(categories, id, next.ParentID); err != nil {
+			return err
+		}
+
+		category.Name, category.Slug, category.ParentID = next.Name, next.Slug, next.ParentID
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return domain.Category{}, notFound("category")
+	case errors.Is(err, ErrDuplicate):
+		return domain.Category{}, slugTaken(ctx)
+	case err != nil:
+		return domain.Category{}, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return category, nil
+}
+
+// Delete removes the category from its courses and deletes it. A category
+// with subcategories is a conflict.
+func (s *CategoryService) Delete(ctx context.Context, id string) (domain.Category, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Category{}, err
+	}
+
+	categories, err := s.store.ListCategories(ctx)
+	if err != nil {
+		return domain.Category{}, err
+	}
+	for _, other := range categories {
+		if other.ParentID != nil && *other.ParentID == id {
+			metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCategoryHasChildren).Inc()
+			obs.Conflict(ctx, metrics.ConflictCategoryHasChildren)
+			return domain.Category{}, &ConflictError{
+				Reason:  metrics.ConflictCategoryHasChildren,
+				Message: "category has subcategories",
+			}
+		}
+	}
+
+	category, err := s.store.DeleteCategory(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return domain.Category{}, notFound("category")
+	}
+	if err != nil {
+		return domain.Category{}, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return category, nil
+}
+
+// checkParent rejects a parent that does not exist or that is id itself or
+// below it.
+func checkParent(categories []domain.Category, id string, parentID *string) error {
+	if parentID == nil {
+		return nil
+	}
+
+	parents := parentsOf(categories)
+	if _, ok := parents[*parentID]; !ok {
+		return &ValidationError{Fields: map[string]string{"parent_id": "does not exist"}}
+	}
+
+	if slices.Contains(ancestry(parents, *parentID), id) {
+		return &ValidationError{Fields: map[string]string{"parent_id": "would create a cycle"}}
+	}
+	return nil
+}
+
+// parentsOf maps the ID of each category to its ParentID.
+func parentsOf(categories []domain.Category) map[string]*string {
+	parents := make(map[string]*string, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+	return parents
+}
+
+// ancestry returns id and the IDs above it, nearest first. It stops at a
+// category it already passed, so a cycle in stored data cannot hang it.
+func ancestry(parents map[string]*string, id string) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for at := &id; at != nil && !seen[*at]; at = parents[*at] {
+		seen[*at] = true
+		ids = append(ids, *at)
+	}
+	return ids
+}
+
+// subtree returns the ID of the category with slug and of every category
+// below it, or nil when there is none.
+func subtree(categories []domain.Category, slug string) []string {
+	var ids []string
+	seen := map[string]bool{}
+	for _, category := range categories {
+		if category.Slug == slug {
+			ids, seen[category.ID] = append(ids, category.ID), true
+		}
+	}
+
+	for i := 0; i < len(ids); i++ {
+		for _, category := range categories {
+			if category.ParentID != nil && *category.ParentID == ids[i] && !seen[category.ID] {
+				ids, seen[category.ID] = append(ids, category.ID), true
+			}
+		}
+	}
+	return ids
+}
+
+func slugTaken(ctx context.Context) *ConflictError {
+	metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCategorySlugTaken).Inc()
+	obs.Conflict(ctx, metrics.ConflictCategorySlugTaken)
+	return &ConflictError{Reason: metrics.ConflictCategorySlugTaken, Message: "slug already exists"}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+// CourseInput is a new course, or the full state of an updated one.
+type CourseInput struct {
+	Title       string `json:"title"       validate:"required,min=3,max=255,nohtml,noprofanity"`
+	Description string `json:"description" validate:"omitempty,min=3,noprofanity"`
+}
+
+// CourseUpdate changes the fields that are not nil.
+type CourseUpdate struct {
+	Title       *string
+	Description *string
+}
+
+// ListCourses selects a page of courses, newest first, whose title contains
+// Query, filed under the category with slug Category or below it, and tagged
+// Tag, each when not empty. Facets also counts the matches per category and
+// tag.
+type ListCourses struct {
+	Page
+	Query    string
+	Category string
+	Tag      string
+	Facets   bool
+}
+
+func (in ListCourses) normalize() ListCourses {
+	in.Page = in.Page.normalize()
+	in.Query = strings.TrimSpace(in.Query)
+	in.Category, in.Tag = Slug(in.Category), Slug(in.Tag)
+	return in
+}
+
+type CoursePage struct {
+	Data   []domain.Course `json:"data"`
+	Total  int64           `json:"total"`
+	Facets *CourseFacets   `json:"facets,omitempty"`
+}
+
+type CourseService struct {
+	store CourseStore
+	cache *cache.Cache
+}
+
+type CourseOption func(*CourseService)
+
+// WithCache serves course reads through c and invalidates it on writes.
+func WithCache(c *cache.Cache) CourseOption {
+	return func(s *CourseService) { s.cache = c }
+}
+
+func NewCourseService(store CourseStore, opts ...CourseOption) *CourseService {
+	s := &CourseService{store: store}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+const coursesListNamespace = "courses:list"
+
+func courseCacheKey(id string) string { return "course:" + id }
+
+func (s *CourseService) Get(ctx context.Context, id string) (domain.Course, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Course{}, err
+	}
+
+	course, err := cache.Fetch(ctx, s.cache, "course", courseCacheKey(id), func() (domain.Course, error) {
+		return s.store.GetCourse(ctx, id)
+	})
+	if errors.Is(err, ErrNotFound) {
+		return domain.Course{}, notFound("course")
+	}
+	if err != nil {
+		return domain.Course{}, err
+	}
+
+	courses := []domain.Course{course}
+	err = s.localize(ctx, courses)
+	return courses[0], err
+}
+
+func (s *CourseService) List(ctx context.Context, in ListCourses) (CoursePage, error) {
+	in = in.normalize()
+
+	// the search matches titles in the locales of ctx; the page is cached in
+	// the default one
+	key := fmt.Sprintf("%s:%d:%d:%s:%s:%t:%s:%s", s.cache.Namespace(ctx, coursesListNamespace),
+		in.Page.Page, in.Page.Limit, in.Category, in.Tag, in.Facets, strings.Join(i18n.Translated(ctx), ","), in.Query)
+	result, err := cache.Fetch(ctx, s.cache, "courses_list", key, func() (CoursePage, error) {
+		// a cached page is only refilled after a bump or once it expires, and
+		// is then served until the next one; reading it from a lagging
+		// replica would keep the write that bumped it out of the list
+		if s.cache != nil {
+			return s.search(FromPrimary(ctx), in)
+		}
+		return s.search(ctx, in)
+	})
+	if err != nil {
+		return CoursePage{}, err
+	}
+
+	return result, s.localize(ctx, result.Data)
+}
+
+func (s *CourseService) Create(ctx context.Context, in CourseInput) (domain.Course, error) {
+	if err := check(ctx, "course", &in); err != nil {
+		return domain.Course{}, err
+	}
+
+	course := domain.Course{ID: uuid.NewString(), Description: strings.TrimSpace(in.Description)}
+	retitle(&course, strings.TrimSpace(in.Title))
+
+	err := s.store.CreateCourse(ctx, &course)
+	if errors.Is(err, ErrDuplicate) {
+		return domain.Course{}, titleTaken(ctx)
+	}
+	if err != nil {
+		return domain.Course{}, err
+	}
+
+	s.written(ctx, course.ID)
+	s.cache.Bump(ctx, coursesListNamespace)
+	metrics.CoursesCreatedTotal.Inc()
+	return course, nil
+}
+
+func (s *CourseService) Update(ctx context.Context, id string, in CourseUpdate) (domain.Course, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Course{}, err
+	}
+
+	course, err := s.store.UpdateCourse(ctx, id, func(course *domain.Course) error {
+		next := CourseInput{Title: course.Title, Description: course.Description}
+		if in.Title != nil {
+			next.Title = *in.Title
+		}
+		if in.Description != nil {
+			next.Description = *in.Description
+		}
+		if err := check(ctx, "course", &next); err != nil {
+			return err
+		}
+
+		retitle(course, strings.TrimSpace(next.Title))
+		course.Description = strings.TrimSpace(next.Description)
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return domain.Course{}, notFound("course")
+	case errors.Is(err, ErrDuplicate):
+		return domain.Course{}, titleTaken(ctx)
+	case err != nil:
+		return domain.Course{}, err
+	}
+
+	s.written(ctx, course.ID)
+	s.cache.Bump(ctx, coursesListNamespace)
+	return course, nil
+}
+
+// Resolve returns the ID of the course idOrSlug names and, when idOrSlug is a
+// former slug of it, the current one.
+func (s *CourseService) Resolve(ctx context.Context, idOrSlug string) (id, moved string, err error) {
+	if checkID("id", idOrSlug) == nil {
+		return idOrSlug, "", nil
+	}
+	if !IsCourseSlug(idOrSlug) {
+		return "", "", &ValidationError{Fields: map[string]string{"id": "is invalid"}}
+	}
+
+	id, current, err := s.store.ResolveSlug(ctx, idOrSlug)
+	if errors.Is(err, ErrNotFound) {
+		return "", "", notFound("course")
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if current != "" && current != idOrSlug {
+		moved = current
+	}
+	return id, moved, nil
+}
+
+// written drops the cached copy of the course.
+func (s *CourseService) written(ctx context.Context, id string) {
+	s.cache.Delete(ctx, courseCacheKey(id))
+}
+
+func titleTaken(ctx context.Context) *ConflictError {
+	metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCourseTitleTaken).Inc()
+	obs.Conflict(ctx, metrics.ConflictCourseTitleTaken)
+	return &ConflictError{Reason: metrics.ConflictCourseTitleTaken, Message: i18n.T(ctx, "title already exists")}
+}
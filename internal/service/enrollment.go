@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+type EnrollmentInput struct {
+	UserID   string `json:"userId"   validate:"required,uuid"`
+	CourseID string `json:"courseId" validate:"required,uuid"`
+}
+
+// ListEnrollments selects a page of enrollments, newest first, of the user
+// and course when they are set.
+type ListEnrollments struct {
+	Page
+	UserID   string
+	CourseID string
+}
+
+type EnrollmentPage struct {
+	Data  []domain.Enrollment
+	Total int64
+}
+
+type EnrollmentService struct {
+	store EnrollmentStore
+}
+
+func NewEnrollmentService(store EnrollmentStore) *EnrollmentService {
+	return &EnrollmentService{store: store}
+}
+
+func (s *EnrollmentService) Get(ctx context.Context, id string) (domain.Enrollment, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Enrollment{}, err
+	}
+
+	enrollment, err := s.store.GetEnrollment(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return domain.Enrollment{}, notFound("enrollment")
+	}
+
+	return enrollment, err
+}
+
+func (s *EnrollmentService) List(ctx context.Context, in ListEnrollments) (EnrollmentPage, error) {
+	if in.UserID != "" {
+		if err := checkID("userId", in.UserID); err != nil {
+			return EnrollmentPage{}, err
+		}
+	}
+	if in.CourseID != "" {
+		if err := checkID("courseId", in.CourseID); err != nil {
+			return EnrollmentPage{}, err
+		}
+	}
+	page := in.Page.normalize()
+
+	var result EnrollmentPage
+	var err error
+	result.Data, result.Total, err = s.store.ListEnrollments(ctx,
+		EnrollmentFilter{UserID: in.UserID, CourseID: in.CourseID}, page.Limit, page.offset())
+	return result, err
+}
+
+// Create enrolls the user in the course. A missing user or course is
+// ErrNotFound.
+func (s *EnrollmentService) Create(ctx context.Context, in EnrollmentInput) (domain.Enrollment, error) {
+	if err := check(ctx, "enrollment", &in); err != nil {
+		return domain.Enrollment{}, err
+	}
+
+	enrollment := domain.Enrollment{ID: uuid.NewString(), UserID: in.UserID, CourseID: in.CourseID}
+	if err := s.store.CreateEnrollment(ctx, &enrollment); err != nil {
+		return domain.Enrollment{}, s.enrollError(ctx, err)
+	}
+
+	metrics.EnrollmentsCreatedTotal.Inc()
+	return enrollment, nil
+}
+
+// enrollError maps a store error of an enrollment write.
+func (s *EnrollmentService) enrollError(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, ErrDuplicate):
+		metrics.ConflictsTotal.WithLabelValues(metrics.ConflictAlreadyEnrolled).Inc()
+		obs.Conflict(ctx, metrics.ConflictAlreadyEnrolled)
+		return &ConflictError{
+			Reason:  metrics.ConflictAlreadyEnrolled,
+			Message: "user is already enrolled in this course",
+		}
+	case errors.Is(err, ErrMissingReference):
+		return notFound("user or course")
+	}
+	return err
+}
+
+// Cancel deletes the enrollment and returns it.
+func (s *EnrollmentService) Cancel(ctx context.Context, id string) (domain.Enrollment, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.Enrollment{}, err
+	}
+
+	enrollment, err := s.store.DeleteEnrollment(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return domain.Enrollment{}, notFound("enrollment")
+	}
+	if err != nil {
+		return domain.Enrollment{}, err
+	}
+
+	metrics.EnrollmentsCancelledTotal.Inc()
+	return enrollment, nil
+}
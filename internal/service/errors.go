@@ -0,0 +1,50 @@
+// Package service holds the business rules for courses, users and
+// enrollments: validation, normalisation, conflict detection and cache
+// invalidation. Persistence, with the domain events of each write, is left to
+// the stores in store.go. Transports (REST, GraphQL, gRPC) and tools call it
+// with plain inputs and map its errors.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrNotFound is matched by errors of missing records.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched by *ConflictError.
+	ErrConflict = errors.New("conflict")
+)
+
+func notFound(resource string) error {
+	return fmt.Errorf("%s %w", resource, ErrNotFound)
+}
+
+// ValidationError holds the message per invalid input field, keyed by the
+// field's JSON name.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		msgs = append(msgs, field+" "+msg)
+	}
+	sort.Strings(msgs)
+	return "invalid input: " + strings.Join(msgs, ", ")
+}
+
+// ConflictError is a write rejected by a uniqueness rule, or by records that
+// depend on the one written. Reason is one of the metrics.Conflict* values.
+type ConflictError struct {
+	Reason  string
+	Message string
+}
+
+func (e *ConflictError) Error() string { return e.Message }
+
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
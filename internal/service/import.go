@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+)
+
+const (
+	importChunkSize = 500
+	// maxReportedErrors bounds the report size; Failed still counts every row.
+	maxReportedErrors = 1000
+)
+
+// ImportRow is one decoded record. Err is set when the record itself could
+// not be parsed; the import reports it and moves on.
+type ImportRow struct {
+	Line  int
+	Input CourseInput
+	Err   string
+}
+
+type RowReader interface {
+	// Next returns the next record, or io.EOF after the last one.
+	Next() (ImportRow, error)
+}
+
+type ImportReport struct {
+	DryRun    bool             `json:"dry_run"`
+	Total     int              `json:"total"     example:"3"`
+	Created   int              `json:"created"   example:"1"`
+	Updated   int              `json:"updated"   example:"1"`
+	Unchanged int              `json:"unchanged" example:"0"`
+	Failed    int              `json:"failed"    example:"1"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+type ImportRowError struct {
+	Line   int               `json:"line"             example:"4"`
+	Title  string            `json:"title,omitempty"  example:"Go"`
+	Errors map[string]string `json:"errors,omitempty" example:"{\"title\":\"must be at least 3 characters\"}"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// Import upserts courses by title. Rows are validated like Create and written
+// in chunks, calling progress after each chunk when not nil; a dry run does
+// the same lookups but skips the writes.
+func (s *CourseService) Import(ctx context.Context, rows RowReader, dryRun bool, progress func(ImportReport)) (ImportReport, error) {
+	imp := &courseImport{
+		courses: s,
+		report:  ImportReport{DryRun: dryRun, Errors: []ImportRowError{}},
+		seen:    map[string]int{},
+	}
+	return imp.run(ctx, rows, progress)
+}
+
+type courseImport struct {
+	courses *CourseService
+	report  ImportReport
+
+	// seen maps each lower-cased title to the line it first appeared on.
+	seen    map[string]int
+	pending []ImportRow
+}
+
+func (imp *courseImport) run(ctx context.Context, rows RowReader, progress func(ImportReport)) (ImportReport, error) {
+	changed := false
+	defer func() {
+		if changed {
+			imp.courses.cache.Bump(ctx, coursesListNamespace)
+		}
+	}()
+
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imp.report, err
+		}
+
+		imp.report.Total++
+		if !imp.accept(ctx, row) {
+			continue
+		}
+
+		if len(imp.pending) == importChunkSize {
+			wrote, err := imp.flush(ctx)
+			changed = changed || wrote
+			if err != nil {
+				return imp.report, err
+			}
+			if progress != nil {
+				progress(imp.report)
+			}
+		}
+	}
+
+	wrote, err := imp.flush(ctx)
+	changed = changed || wrote
+
+	return imp.report, err
+}
+
+// accept validates row and queues it for the next chunk.
+func (imp *courseImport) accept(ctx context.Context, row ImportRow) bool {
+	if row.Err != "" {
+		imp.fail(ImportRowError{Line: row.Line, Error: row.Err})
+		return false
+	}
+
+	var invalid *ValidationError
+	if err := check(ctx, "course", &row.Input); errors.As(err, &invalid) {
+		imp.fail(ImportRowError{Line: row.Line, Title: row.Input.Title, Errors: invalid.Fields})
+		return false
+	}
+
+	row.Input.Title = strings.TrimSpace(row.Input.Title)
+	row.Input.Description = strings.TrimSpace(row.Input.Description)
+
+	key := strings.ToLower(row.Input.Title)
+	if first, ok := imp.seen[key]; ok {
+		imp.fail(ImportRowError{
+			Line:   row.Line,
+			Title:  row.Input.Title,
+			Errors: map[string]string{"title": fmt.Sprintf("duplicates line %d", first)},
+		})
+		return false
+	}
+	imp.seen[key] = row.Line
+
+	imp.pending = append(imp.pending, row)
+	return true
+}
+
+// flush upserts the pending rows and reports whether anything was written.
+func (imp *courseImport) flush(ctx context.Context) (bool, error) {
+	if len(imp.pending) == 0 {
+		return false, nil
+	}
+	defer func() { imp.pending = imp.pending[:0] }()
+
+	titles := make([]string, len(imp.pending))
+	for i, row := range imp.pending {
+		titles[i] = row.Input.Title
+	}
+
+	existing, err := imp.courses.store.CoursesByTitle(ctx, titles)
+	if err != nil {
+		return false, err
+	}
+
+	byTitle := make(map[string]domain.Course, len(existing))
+	for _, course := range existing {
+		byTitle[strings.ToLower(course.Title)] = course
+	}
+
+	wrote := false
+	for _, row := range imp.pending {
+		course, found := byTitle[strings.ToLower(row.Input.Title)]
+
+		switch {
+		case found && course.Title == row.Input.Title && course.Description == row.Input.Description:
+			imp.report.Unchanged++
+			continue
+		case imp.report.DryRun:
+		case found:
+			_, err := imp.courses.store.UpdateCourse(ctx, course.ID, func(course *domain.Course) error {
+				retitle(course, row.Input.Title)
+				course.Description = row.Input.Description
+				return nil
+			})
+			if err != nil {
+				return wrote, err
+			}
+			imp.courses.written(ctx, course.ID)
+		default:
+			course = domain.Course{ID: uuid.NewString(), Description: row.Input.Description}
+			retitle(&course, row.Input.Title)
+			err := imp.courses.store.CreateCourse(ctx, &course)
+			if errors.Is(err, ErrDuplicate) {
+				metrics.ConflictsTotal.WithLabelValues(metrics.ConflictCourseTitleTaken).Inc()
+				imp.fail(ImportRowError{Line: row.Line, Title: row.Input.Title, Errors: map[string]string{"title": "already exists"}})
+				continue
+			}
+			if err != nil {
+				return wrote, err
+			}
+			imp.courses.written(ctx, course.ID)
+			metrics.CoursesCreatedTotal.Inc()
+		}
+
+		wrote = wrote || !imp.report.DryRun
+		if found {
+			imp.report.Updated++
+		} else {
+			imp.report.Created++
+		}
+	}
+
+	return wrote, nil
+}
+
+func (imp *courseImport) fail(rowErr ImportRowError) {
+	imp.report.Failed++
+	if len(imp.report.Errors) < maxReportedErrors {
+		imp.report.Errors = append(imp.report.Errors, rowErr)
+	}
+}
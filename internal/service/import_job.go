@@ -0,0 +1,106 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+// CreateImportJob records a pending import of body in format, to be run by
+// RunImportJob. The body is saved with the job, so any instance can run it.
+func (s *CourseService) CreateImportJob(ctx context.Context, format string, dryRun bool, body io.Reader) (domain.CourseImportJob, error) {
+	job := domain.CourseImportJob{Status: domain.ImportJobPending, Format: format, DryRun: dryRun}
+	if err := s.store.CreateImportJob(ctx, &job, body); err != nil {
+		return domain.CourseImportJob{}, err
+	}
+	return job, nil
+}
+
+// ImportJob returns the import job and its report so far, which is nil until
+// the first chunk is written.
+func (s *CourseService) ImportJob(ctx context.Context, id string) (domain.CourseImportJob, *ImportReport, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.CourseImportJob{}, nil, err
+	}
+
+	job, err := s.store.GetImportJob(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return domain.CourseImportJob{}, nil, notFound("import job")
+	}
+	if err != nil {
+		return domain.CourseImportJob{}, nil, err
+	}
+	if job.Report == "" {
+		return job, nil, nil
+	}
+
+	report := &ImportReport{}
+	if err := json.Unmarshal([]byte(job.Report), report); err != nil {
+		return domain.CourseImportJob{}, nil, err
+	}
+	return job, report, nil
+}
+
+// RunImportJob imports the saved body of the job into the catalog, saving
+// the report after each chunk. A failed import is recorded on the job; the
+// error returned means the job could not be run or its outcome not recorded,
+// and running it again is safe: a job that is done is left alone and one
+// still running starts over, matching the courses it already wrote by title.
+// The body is dropped once the job is done.
+func (s *CourseService) RunImportJob(ctx context.Context, id string) error {
+	job, err := s.store.GetImportJob(ctx, id)
+	if err != nil {
+		return err
+	}
+	if job.Status == domain.ImportJobSucceeded || job.Status == domain.ImportJobFailed {
+		return nil
+	}
+
+	logger := obs.Logger(ctx).With("job_id", job.ID)
+	// The outcome is recorded even when ctx ran out, so the job does not
+	// stay running.
+	recordCtx := context.WithoutCancel(ctx)
+	saveReport := func(report ImportReport) {
+		b, _ := json.Marshal(report)
+		if err := s.store.SaveImportReport(recordCtx, job.ID, string(b)); err != nil {
+			logger.ErrorContext(ctx, "save import report failed", "error", err)
+		}
+	}
+
+	if err := s.store.SetImportJobStatus(ctx, job.ID, domain.ImportJobRunning, ""); err != nil {
+		return err
+	}
+
+	var report ImportReport
+	rows, err := NewRowReader(job.Format, bufio.NewReader(s.store.ImportUpload(ctx, job.ID)))
+	if err == nil {
+		report, err = s.Import(ctx, rows, job.DryRun, saveReport)
+	}
+	// The report is written apart from the outcome, so a report that cannot
+	// be saved does not leave the job running.
+	saveReport(report)
+
+	status, message := domain.ImportJobSucceeded, ""
+	if err != nil {
+		status, message = domain.ImportJobFailed, err.Error()
+		logger.ErrorContext(ctx, "import job failed", "error", err)
+	}
+	if err := s.store.SetImportJobStatus(recordCtx, job.ID, status, message); err != nil {
+		return err
+	}
+	if err := s.store.DeleteImportUpload(recordCtx, job.ID); err != nil {
+		logger.WarnContext(ctx, "drop import upload failed", "error", err)
+	}
+	return nil
+}
+
+// Export returns a cursor over the whole catalog, oldest first, in the
+// default locale. The caller closes it.
+func (s *CourseService) Export(ctx context.Context) (CourseCursor, error) {
+	return s.store.ScanCourses(ctx)
+}
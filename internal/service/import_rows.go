@@ -0,0 +1,130 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats accepted by imports and produced by exports.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// LayoutError rejects an import whose layout cannot be read at all, as
+// opposed to a single bad record.
+type LayoutError struct{ msg string }
+
+func (e *LayoutError) Error() string { return e.msg }
+
+// NewRowReader reads the records of an import in format.
+func NewRowReader(format string, r io.Reader) (RowReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVRows(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		return &ndjsonRows{scanner: scanner}, nil
+	}
+
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// csvRows reads a CSV file with a header row. Columns are matched by name,
+// case-insensitively; id, created_at and unknown columns are ignored so
+// exports can be imported back.
+type csvRows struct {
+	r           *csv.Reader
+	title, desc int
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &LayoutError{"empty CSV: a header row with a title column is required"}
+	}
+	if err != nil {
+		return nil, &LayoutError{"invalid CSV header: " + err.Error()}
+	}
+
+	rows := &csvRows{r: cr, title: -1, desc: -1}
+	for i, name := range header {
+		// Spreadsheets often prefix UTF-8 files with a byte order mark.
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "title":
+			rows.title = i
+		case "description":
+			rows.desc = i
+		}
+	}
+	if rows.title < 0 {
+		return nil, &LayoutError{"CSV header must contain a title column"}
+	}
+
+	return rows, nil
+}
+
+func (rows *csvRows) Next() (ImportRow, error) {
+	record, err := rows.r.Read()
+	if err != nil {
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			return ImportRow{Line: pe.Line, Err: pe.Err.Error()}, nil
+		}
+		return ImportRow{}, err
+	}
+
+	line, _ := rows.r.FieldPos(0)
+	row := ImportRow{Line: line}
+	if rows.title < len(record) {
+		row.Input.Title = record[rows.title]
+	}
+	if rows.desc >= 0 && rows.desc < len(record) {
+		row.Input.Description = record[rows.desc]
+	}
+
+	return row, nil
+}
+
+// ndjsonRows reads one JSON object per line, skipping blank lines.
+type ndjsonRows struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (rows *ndjsonRows) Next() (ImportRow, error) {
+	for rows.scanner.Scan() {
+		rows.line++
+
+		text := strings.TrimSpace(rows.scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := ImportRow{Line: rows.line}
+		if err := json.Unmarshal([]byte(text), &row.Input); err != nil {
+			row.Err = "invalid JSON"
+		}
+
+		return row, nil
+	}
+
+	if err := rows.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return ImportRow{}, &LayoutError{fmt.Sprintf("line %d is longer than 1 MiB", rows.line+1)}
+		}
+		return ImportRow{}, err
+	}
+
+	return ImportRow{}, io.EOF
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+)
+
+// CourseFields are the course fields a client can select, by JSON name, which
+// is also the column name. slug comes last so that CSV columns keep their
+// positions.
+var CourseFields = []string{"id", "title", "description", "instructor_id", "created_at", "slug"}
+
+// Relations that can be embedded in a course.
+const (
+	IncludeEnrollmentsCount = "enrollments_count"
+	IncludeInstructor       = "instructor"
+	IncludeModules          = "modules"
+	IncludeCategories       = "categories"
+	IncludeTags             = "tags"
+)
+
+var courseIncludes = []string{IncludeEnrollmentsCount, IncludeInstructor, IncludeModules, IncludeCategories, IncludeTags}
+
+// CourseProjection selects the fields of a course and the relations embedded
+// in it. No Fields means every field.
+type CourseProjection struct {
+	Fields  []string
+	Include []string
+}
+
+// ParseCourseProjection reads the comma-separated fields and include lists,
+// rejecting names outside CourseFields and the Include* relations.
+func ParseCourseProjection(fields, include string) (CourseProjection, error) {
+	var p CourseProjection
+	invalid := map[string]string{}
+
+	for _, name := range splitList(fields) {
+		if !slices.Contains(CourseFields, name) {
+			invalid["fields"] = fmt.Sprintf("unknown field %q", name)
+			continue
+		}
+		if !slices.Contains(p.Fields, name) {
+			p.Fields = append(p.Fields, name)
+		}
+	}
+	for _, name := range splitList(include) {
+		if !slices.Contains(courseIncludes, name) {
+			invalid["include"] = fmt.Sprintf("unknown relation %q", name)
+			continue
+		}
+		if !slices.Contains(p.Include, name) {
+			p.Include = append(p.Include, name)
+		}
+	}
+
+	if len(invalid) > 0 {
+		return CourseProjection{}, &ValidationError{Fields: invalid}
+	}
+	return p, nil
+}
+
+func splitList(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// isZero reports whether p is the full course without relations.
+func (p CourseProjection) isZero() bool {
+	return len(p.Fields) == 0 && len(p.Include) == 0
+}
+
+func (p CourseProjection) includes(relation string) bool {
+	return slices.Contains(p.Include, relation)
+}
+
+// columns returns the columns to read, always with the ones the relations
+// are loaded by, or nil for all of them.
+func (p CourseProjection) columns() []string {
+	if len(p.Fields) == 0 {
+		return nil
+	}
+
+	columns := []string{"id"}
+	for _, field := range p.Fields {
+		if !slices.Contains(columns, field) {
+			columns = append(columns, field)
+		}
+	}
+	if p.includes(IncludeInstructor) && !slices.Contains(columns, "instructor_id") {
+		columns = append(columns, "instructor_id")
+	}
+	return columns
+}
+
+// CourseView is a course rendered with a projection: only the selected fields
+// and the included relations are marshalled.
+type CourseView struct {
+	Course     domain.Course
+	Projection CourseProjection
+
+	EnrollmentsCount int64
+	Instructor       *domain.User
+	Modules          []domain.CourseModule
+	Categories       []domain.Category
+	Tags             []domain.Tag
+}
+
+func (v CourseView) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(v.Course)
+	if err != nil {
+		return nil, err
+	}
+	if v.Projection.isZero() {
+		return b, nil
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(b, &all); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]any, len(all)+len(v.Projection.Include))
+	if len(v.Projection.Fields) == 0 {
+		for name, value := range all {
+			out[name] = value
+		}
+	}
+	for _, field := range v.Projection.Fields {
+		// instructor_id and slug are omitted when empty; a selected field is
+		// null instead.
+		out[field] = all[field]
+	}
+
+	for _, relation := range v.Projection.Include {
+		switch relation {
+		case IncludeEnrollmentsCount:
+			out[relation] = v.EnrollmentsCount
+		case IncludeInstructor:
+			out[relation] = v.Instructor
+		case IncludeModules:
+			out[relation] = orEmpty(v.Modules)
+		case IncludeCategories:
+			out[relation] = orEmpty(v.Categories)
+		case IncludeTags:
+			out[relation] = orEmpty(v.Tags)
+		}
+	}
+	return json.Marshal(out)
+}
+
+// orEmpty marshals a missing relation list as [] rather than null.
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+type CourseViewPage struct {
+	Data   []CourseView  `json:"data"`
+	Total  int64         `json:"total"`
+	Facets *CourseFacets `json:"facets,omitempty"`
+}
+
+// GetView returns the course projected with p. Sparse reads select their
+// columns and bypass the cache.
+func (s *CourseService) GetView(ctx context.Context, id string, p CourseProjection) (CourseView, error) {
+	var course domain.Course
+	var err error
+	if len(p.Fields) == 0 {
+		course, err = s.Get(ctx, id)
+	} else if err = checkID("id", id); err == nil {
+		course, err = s.store.GetCourse(ctx, id, p.columns()...)
+		if errors.Is(err, ErrNotFound) {
+			err = notFound("course")
+		} else if err == nil {
+			courses := []domain.Course{course}
+			err = s.localize(ctx, courses)
+			course = courses[0]
+		}
+	}
+	if err != nil {
+		return CourseView{}, err
+	}
+
+	views, err := s.embed(ctx, []domain.Course{course}, p)
+	if err != nil {
+		return CourseView{}, err
+	}
+	return views[0], nil
+}
+
+// ListViews returns a page of courses projected with p, loading each
+// included relation with one query for the whole page.
+func (s *CourseService) ListViews(ctx context.Context, in ListCourses, p CourseProjection) (CourseViewPage, error) {
+	var result CoursePage
+	var err error
+	if len(p.Fields) == 0 {
+		result, err = s.List(ctx, in)
+	} else {
+		if result, err = s.search(ctx, in.normalize(), p.columns()...); err == nil {
+			err = s.localize(ctx, result.Data)
+		}
+	}
+	if err != nil {
+		return CourseViewPage{}, err
+	}
+
+	views, err := s.embed(ctx, result.Data, p)
+	if err != nil {
+		return CourseViewPage{}, err
+	}
+	return CourseViewPage{Data: views, Total: result.Total, Facets: result.Facets}, nil
+}
+
+// embed wraps courses in views with the relations p includes.
+func (s *CourseService) embed(ctx context.Context, courses []domain.Course, p CourseProjection) ([]CourseView, error) {
+	views := make([]CourseView, len(courses))
+	ids := make([]string, len(courses))
+	for i, course := range courses {
+		views[i] = CourseView{Course: course, Projection: p}
+		ids[i] = course.ID
+	}
+	if len(courses) == 0 || len(p.Include) == 0 {
+		return views, nil
+	}
+
+	if p.includes(IncludeEnrollmentsCount) {
+		counts, err := s.store.CountEnrollments(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range views {
+			views[i].EnrollmentsCount = counts[views[i].Course.ID]
+		}
+	}
+
+	if p.includes(IncludeInstructor) {
+		var instructorIDs []string
+		for _, course := range courses {
+			if course.InstructorID != nil && !slices.Contains(instructorIDs, *course.InstructorID) {
+				instructorIDs = append(instructorIDs, *course.InstructorID)
+			}
+		}
+		if len(instructorIDs) > 0 {
+			users, err := s.store.UsersByID(ctx, instructorIDs)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[string]domain.User, len(users))
+			for _, user := range users {
+				byID[user.ID] = user
+			}
+			for i := range views {
+				if id := views[i].Course.InstructorID; id != nil {
+					if user, ok := byID[*id]; ok {
+						views[i].Instructor = &user
+					}
+				}
+			}
+		}
+	}
+
+	if p.includes(IncludeCategories) {
+		categories, err := s.store.CourseCategories(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range views {
+			views[i].Categories = categories[views[i].Course.ID]
+		}
+	}
+
+	if p.includes(IncludeTags) {
+		tags, err := s.store.CourseTags(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range views {
+			views[i].Tags = tags[views[i].Course.ID]
+		}
+	}
+
+	if p.includes(IncludeModules) {
+		modules, err := s.store.CourseModules(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byCourse := make(map[string][]domain.CourseModule, len(courses))
+		for _, module := range modules {
+			byCourse[module.CourseID] = append(byCourse[module.CourseID], module)
+		}
+		for i := range views {
+			views[i].Modules = byCourse[views[i].Course.ID]
+		}
+	}
+
+	return views, nil
+}
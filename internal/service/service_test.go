@@ -0,0 +1,1675 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+	"github.com/guycanella/api-courses-golang/internal/service"
+)
+
+// fakeStore keeps records in maps and enforces the unique keys and
+// references of the MySQL schema.
+type fakeStore struct {
+	courses     map[string]domain.Course
+	users       map[string]domain.User
+	enrollments map[string]domain.Enrollment
+	modules     []domain.CourseModule
+	categories  map[string]domain.Category
+	tags        map[string]domain.Tag
+	// courseCategories and courseTags hold category and tag IDs by course.
+	courseCategories map[string][]string
+	courseTags       map[string][]string
+	// formerSlugs holds course IDs by former slug.
+	formerSlugs map[string]string
+	// translations holds translations by course ID and locale.
+	translations map[string]map[string]domain.CourseTranslation
+	importJobs   map[string]domain.CourseImportJob
+	// uploads holds the bodies of import jobs by ID.
+	uploads map[string][]byte
+	writes  int
+	// relationReads counts the relation queries.
+	relationReads int
+	// primaryListReads counts the course lists read from the primary.
+	primaryListReads int
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		courses:     map[string]domain.Course{},
+		users:       map[string]domain.User{},
+		enrollments: map[string]domain.Enrollment{},
+		categories:  map[string]domain.Category{},
+		tags:        map[string]domain.Tag{},
+
+		courseCategories: map[string][]string{},
+		courseTags:       map[string][]string{},
+		formerSlugs:      map[string]string{},
+		translations:     map[string]map[string]domain.CourseTranslation{},
+		importJobs:       map[string]domain.CourseImportJob{},
+		uploads:          map[string][]byte{},
+	}
+}
+
+func (f *fakeStore) GetCourse(_ context.Context, id string, columns ...string) (domain.Course, error) {
+	course, ok := f.courses[id]
+	if !ok {
+		return domain.Course{}, service.ErrNotFound
+	}
+	return selectColumns(course, columns), nil
+}
+
+func (f *fakeStore) ListCourses(ctx context.Context, filter service.CourseFilter, limit, offset int, columns ...string) ([]domain.Course, int64, error) {
+	if service.ReadsPrimary(ctx) {
+		f.primaryListReads++
+	}
+
+	var matched []domain.Course
+	for _, course := range f.matching(filter) {
+		matched = append(matched, selectColumns(course, columns))
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Title < matched[j].Title })
+
+	total := int64(len(matched))
+	if offset > len(matched) {
+		offset = len(matched)
+	}
+	matched = matched[offset:]
+	if limit < len(matched) {
+		matched = matched[:limit]
+	}
+	return matched, total, nil
+}
+
+func (f *fakeStore) CoursesByTitle(_ context.Context, titles []string) ([]domain.Course, error) {
+	var found []domain.Course
+	for _, course := range f.courses {
+		for _, title := range titles {
+			if strings.EqualFold(course.Title, title) {
+				found = append(found, course)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (f *fakeStore) CreateCourse(_ context.Context, course *domain.Course) error {
+	for _, other := range f.courses {
+		if strings.EqualFold(other.Title, course.Title) {
+			return service.ErrDuplicate
+		}
+	}
+	f.place(course, nil)
+	f.courses[course.ID] = *course
+	f.writes++
+	return nil
+}
+
+// place gives the course the first free slug starting with its own, like the
+// MySQL store, keeping from as a former slug.
+func (f *fakeStore) place(course *domain.Course, from *string) {
+	if course.Slug == nil || (from != nil && *from == *course.Slug) {
+		return
+	}
+
+	taken := func(slug string) bool {
+		for _, other := range f.courses {
+			if other.ID != course.ID && other.Slug != nil && *other.Slug == slug {
+				return true
+			}
+		}
+		id, ok := f.formerSlugs[slug]
+		return ok && id != course.ID
+	}
+	slug := *course.Slug
+	for n := 2; taken(slug); n++ {
+		slug = fmt.Sprintf("%s-%d", *course.Slug, n)
+	}
+	course.Slug = &slug
+
+	if from != nil && *from != slug {
+		delete(f.formerSlugs, slug)
+		f.formerSlugs[*from] = course.ID
+	}
+}
+
+func (f *fakeStore) ResolveSlug(_ context.Context, slug string) (string, string, error) {
+	id, ok := f.formerSlugs[slug]
+	for _, course := range f.courses {
+		if course.Slug != nil && *course.Slug == slug {
+			id, ok = course.ID, true
+		}
+	}
+	if !ok {
+		return "", "", service.ErrNotFound
+	}
+	return id, *f.courses[id].Slug, nil
+}
+
+func (f *fakeStore) CoursesByID(_ context.Context, ids []string) ([]domain.Course, error) {
+	var found []domain.Course
+	for _, id := range ids {
+		if course, ok := f.courses[id]; ok {
+			found = append(found, course)
+		}
+	}
+	return found, nil
+}
+
+// CreateCourses rolls back the courses it created when one fails.
+func (f *fakeStore) CreateCourses(ctx context.Context, courses []*domain.Course) error {
+	saved, writes := maps.Clone(f.courses), f.writes
+	for i, course := range courses {
+		if err := f.CreateCourse(ctx, course); err != nil {
+			f.courses, f.writes = saved, writes
+			return &service.BatchItemError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) UpdateCourse(_ context.Context, id string, change func(*domain.Course) error) (domain.Course, error) {
+	course, ok := f.courses[id]
+	if !ok {
+		return domain.Course{}, service.ErrNotFound
+	}
+	from := course.Slug
+	if err := change(&course); err != nil {
+		return domain.Course{}, err
+	}
+	for _, other := range f.courses {
+		if other.ID != id && strings.EqualFold(other.Title, course.Title) {
+			return domain.Course{}, service.ErrDuplicate
+		}
+	}
+	f.place(&course, from)
+	f.courses[id] = course
+	f.writes++
+	return course, nil
+}
+
+// selectColumns keeps the columns of course, like a SELECT of them.
+func selectColumns(course domain.Course, columns []string) domain.Course {
+	if len(columns) == 0 {
+		return course
+	}
+
+	var out domain.Course
+	for _, column := range columns {
+		switch column {
+		case "id":
+			out.ID = course.ID
+		case "title":
+			out.Title = course.Title
+		case "slug":
+			out.Slug = course.Slug
+		case "description":
+			out.Description = course.Description
+		case "instructor_id":
+			out.InstructorID = course.InstructorID
+		case "created_at":
+			out.CreatedAt = course.CreatedAt
+		}
+	}
+	return out
+}
+
+func (f *fakeStore) CountEnrollments(_ context.Context, courseIDs []string) (map[string]int64, error) {
+	f.relationReads++
+	counts := map[string]int64{}
+	for _, e := range f.enrollments {
+		if slices.Contains(courseIDs, e.CourseID) {
+			counts[e.CourseID]++
+		}
+	}
+	return counts, nil
+}
+
+func (f *fakeStore) UsersByID(_ context.Context, ids []string) ([]domain.User, error) {
+	f.relationReads++
+	var users []domain.User
+	for _, id := range ids {
+		if user, ok := f.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeStore) CourseModules(_ context.Context, courseIDs []string) ([]domain.CourseModule, error) {
+	f.relationReads++
+	var modules []domain.CourseModule
+	for _, module := range f.modules {
+		if slices.Contains(courseIDs, module.CourseID) {
+			modules = append(modules, module)
+		}
+	}
+	return modules, nil
+}
+
+func (f *fakeStore) matching(filter service.CourseFilter) []domain.Course {
+	var matched []domain.Course
+	for _, course := range f.courses {
+		if !strings.Contains(course.Title, filter.Query) && !slices.ContainsFunc(filter.Locales, func(locale string) bool {
+			t, ok := f.translations[course.ID][locale]
+			return ok && strings.Contains(t.Title, filter.Query)
+		}) {
+			continue
+		}
+		if len(filter.CategoryIDs) > 0 && !slices.ContainsFunc(f.courseCategories[course.ID], func(id string) bool {
+			return slices.Contains(filter.CategoryIDs, id)
+		}) {
+			continue
+		}
+		if filter.Tag != "" && !slices.ContainsFunc(f.courseTags[course.ID], func(id string) bool {
+			return f.tags[id].Name == filter.Tag
+		}) {
+			continue
+		}
+		matched = append(matched, course)
+	}
+	return matched
+}
+
+func (f *fakeStore) CategoryLinks(_ context.Context, filter service.CourseFilter) ([]service.CategoryLink, error) {
+	var links []service.CategoryLink
+	for _, course := range f.matching(filter) {
+		for _, id := range f.courseCategories[course.ID] {
+			links = append(links, service.CategoryLink{CourseID: course.ID, CategoryID: id})
+		}
+	}
+	return links, nil
+}
+
+func (f *fakeStore) CountTags(_ context.Context, filter service.CourseFilter) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, course := range f.matching(filter) {
+		for _, id := range f.courseTags[course.ID] {
+			counts[f.tags[id].Name]++
+		}
+	}
+	return counts, nil
+}
+
+func (f *fakeStore) CourseCategories(_ context.Context, courseIDs []string) (map[string][]domain.Category, error) {
+	f.relationReads++
+	out := map[string][]domain.Category{}
+	for _, courseID := range courseIDs {
+		for _, id := range f.courseCategories[courseID] {
+			out[courseID] = append(out[courseID], f.categories[id])
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) CourseTags(_ context.Context, courseIDs []string) (map[string][]domain.Tag, error) {
+	f.relationReads++
+	out := map[string][]domain.Tag{}
+	for _, courseID := range courseIDs {
+		for _, id := range f.courseTags[courseID] {
+			out[courseID] = append(out[courseID], f.tags[id])
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) SetCourseCategories(_ context.Context, courseID string, categoryIDs []string) ([]domain.Category, error) {
+	if _, ok := f.courses[courseID]; !ok {
+		return nil, service.ErrNotFound
+	}
+	categories := []domain.Category{}
+	for _, id := range categoryIDs {
+		category, ok := f.categories[id]
+		if !ok {
+			return nil, service.ErrMissingReference
+		}
+		categories = append(categories, category)
+	}
+	f.courseCategories[courseID] = categoryIDs
+	f.writes++
+	return categories, nil
+}
+
+func (f *fakeStore) SetCourseTags(_ context.Context, courseID string, names []string) ([]domain.Tag, error) {
+	if _, ok := f.courses[courseID]; !ok {
+		return nil, service.ErrNotFound
+	}
+	tags := []domain.Tag{}
+	var ids []string
+	for _, name := range names {
+		tag, err := f.GetTag(context.Background(), name)
+		if err != nil {
+			tag = domain.Tag{ID: "tag-" + name, Name: name}
+			f.tags[tag.ID] = tag
+		}
+		tags = append(tags, tag)
+		ids = append(ids, tag.ID)
+	}
+	f.courseTags[courseID] = ids
+	f.writes++
+	return tags, nil
+}
+
+func (f *fakeStore) CourseTranslations(_ context.Context, courseIDs, locales []string) ([]domain.CourseTranslation, error) {
+	f.relationReads++
+	var found []domain.CourseTranslation
+	for _, id := range courseIDs {
+		for locale, t := range f.translations[id] {
+			if len(locales) == 0 || slices.Contains(locales, locale) {
+				found = append(found, t)
+			}
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Locale < found[j].Locale })
+	return found, nil
+}
+
+func (f *fakeStore) PutCourseTranslation(_ context.Context, translation *domain.CourseTranslation) (bool, error) {
+	if _, ok := f.courses[translation.CourseID]; !ok {
+		return false, service.ErrNotFound
+	}
+	for id, byLocale := range f.translations {
+		if t, ok := byLocale[translation.Locale]; ok && id != translation.CourseID && strings.EqualFold(t.Title, translation.Title) {
+			return false, service.ErrDuplicate
+		}
+	}
+
+	if f.translations[translation.CourseID] == nil {
+		f.translations[translation.CourseID] = map[string]domain.CourseTranslation{}
+	}
+	_, exists := f.translations[translation.CourseID][translation.Locale]
+	f.translations[translation.CourseID][translation.Locale] = *translation
+	f.writes++
+	return !exists, nil
+}
+
+func (f *fakeStore) DeleteCourseTranslation(_ context.Context, courseID, locale string) (domain.CourseTranslation, error) {
+	t, ok := f.translations[courseID][locale]
+	if !ok {
+		return domain.CourseTranslation{}, service.ErrNotFound
+	}
+	delete(f.translations[courseID], locale)
+	f.writes++
+	return t, nil
+}
+
+func (f *fakeStore) ScanCourses(_ context.Context) (service.CourseCursor, error) {
+	courses := slices.Collect(maps.Values(f.courses))
+	sort.Slice(courses, func(i, j int) bool { return courses[i].ID < courses[j].ID })
+	return &courseCursor{courses: courses}, nil
+}
+
+type courseCursor struct{ courses []domain.Course }
+
+func (c *courseCursor) Next() (domain.Course, error) {
+	if len(c.courses) == 0 {
+		return domain.Course{}, io.EOF
+	}
+	course := c.courses[0]
+	c.courses = c.courses[1:]
+	return course, nil
+}
+
+func (c *courseCursor) Close() error { return nil }
+
+func (f *fakeStore) CreateImportJob(_ context.Context, job *domain.CourseImportJob, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if job.ID == "" {
+		job.ID = uuid.NewString()
+	}
+	f.importJobs[job.ID] = *job
+	f.uploads[job.ID] = data
+	return nil
+}
+
+func (f *fakeStore) ImportUpload(_ context.Context, id string) io.Reader {
+	return bytes.NewReader(f.uploads[id])
+}
+
+func (f *fakeStore) DeleteImportUpload(_ context.Context, id string) error {
+	delete(f.uploads, id)
+	return nil
+}
+
+func (f *fakeStore) GetImportJob(_ context.Context, id string) (domain.CourseImportJob, error) {
+	job, ok := f.importJobs[id]
+	if !ok {
+		return domain.CourseImportJob{}, service.ErrNotFound
+	}
+	return job, nil
+}
+
+func (f *fakeStore) SaveImportReport(_ context.Context, id, report string) error {
+	job := f.importJobs[id]
+	job.Report = report
+	f.importJobs[id] = job
+	return nil
+}
+
+func (f *fakeStore) SetImportJobStatus(_ context.Context, id, status, message string) error {
+	job := f.importJobs[id]
+	job.Status, job.Error = status, message
+	f.importJobs[id] = job
+	return nil
+}
+
+func (f *fakeStore) GetCategory(_ context.Context, id string) (domain.Category, error) {
+	category, ok := f.categories[id]
+	if !ok {
+		return domain.Category{}, service.ErrNotFound
+	}
+	return category, nil
+}
+
+func (f *fakeStore) ListCategories(_ context.Context) ([]domain.Category, error) {
+	var categories []domain.Category
+	for _, category := range f.categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].Name < categories[j].Name })
+	return categories, nil
+}
+
+func (f *fakeStore) CreateCategory(_ context.Context, category *domain.Category) error {
+	for _, other := range f.categories {
+		if other.Slug == category.Slug {
+			return service.ErrDuplicate
+		}
+	}
+	f.categories[category.ID] = *category
+	f.writes++
+	return nil
+}
+
+func (f *fakeStore) UpdateCategory(ctx context.Context, id string, change func(*domain.Category, []domain.Category) error) (domain.Category, error) {
+	category, ok := f.categories[id]
+	if !ok {
+		return domain.Category{}, service.ErrNotFound
+	}
+	categories, _ := f.ListCategories(ctx)
+	if err := change(&category, categories); err != nil {
+		return domain.Category{}, err
+	}
+	for _, other := range f.categories {
+		if other.ID != id && other.Slug == category.Slug {
+			return domain.Category{}, service.ErrDuplicate
+		}
+	}
+	f.categories[id] = category
+	f.writes++
+	return category, nil
+}
+
+func (f *fakeStore) DeleteCategory(_ context.Context, id string) (domain.Category, error) {
+	category, ok := f.categories[id]
+	if !ok {
+		return domain.Category{}, service.ErrNotFound
+	}
+	delete(f.categories, id)
+	for courseID, ids := range f.courseCategories {
+		f.courseCategories[courseID] = slices.DeleteFunc(ids, func(other string) bool { return other == id })
+	}
+	f.writes++
+	return category, nil
+}
+
+func (f *fakeStore) GetTag(_ context.Context, name string) (domain.Tag, error) {
+	for _, tag := range f.tags {
+		if tag.Name == name {
+			return tag, nil
+		}
+	}
+	return domain.Tag{}, service.ErrNotFound
+}
+
+func (f *fakeStore) ListTags(_ context.Context, limit, offset int) ([]domain.Tag, int64, error) {
+	var tags []domain.Tag
+	for _, tag := range f.tags {
+		tags = append(tags, tag)
+	}
+	return tags, int64(len(tags)), nil
+}
+
+func (f *fakeStore) CreateTag(ctx context.Context, tag *domain.Tag) error {
+	if _, err := f.GetTag(ctx, tag.Name); err == nil {
+		return service.ErrDuplicate
+	}
+	f.tags[tag.ID] = *tag
+	f.writes++
+	return nil
+}
+
+func (f *fakeStore) UpdateTag(ctx context.Context, name string, change func(*domain.Tag) error) (domain.Tag, error) {
+	tag, err := f.GetTag(ctx, name)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	if err := change(&tag); err != nil {
+		return domain.Tag{}, err
+	}
+	if other, err := f.GetTag(ctx, tag.Name); err == nil && other.ID != tag.ID {
+		return domain.Tag{}, service.ErrDuplicate
+	}
+	f.tags[tag.ID] = tag
+	f.writes++
+	return tag, nil
+}
+
+func (f *fakeStore) DeleteTag(ctx context.Context, name string) (domain.Tag, error) {
+	tag, err := f.GetTag(ctx, name)
+	if err != nil {
+		return domain.Tag{}, err
+	}
+	delete(f.tags, tag.ID)
+	for courseID, ids := range f.courseTags {
+		f.courseTags[courseID] = slices.DeleteFunc(ids, func(other string) bool { return other == tag.ID })
+	}
+	f.writes++
+	return tag, nil
+}
+
+func (f *fakeStore) GetUser(_ context.Context, id string) (domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return domain.User{}, service.ErrNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeStore) ListUsers(_ context.Context, limit, offset int) ([]domain.User, int64, error) {
+	var users []domain.User
+	for _, user := range f.users {
+		users = append(users, user)
+	}
+	return users, int64(len(users)), nil
+}
+
+func (f *fakeStore) CreateUser(_ context.Context, user *domain.User) error {
+	for _, other := range f.users {
+		if strings.EqualFold(other.Email, user.Email) {
+			return service.ErrDuplicate
+		}
+	}
+	f.users[user.ID] = *user
+	f.writes++
+	return nil
+}
+
+func (f *fakeStore) UpdateUser(_ context.Context, id string, change func(*domain.User) error) (domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return domain.User{}, service.ErrNotFound
+	}
+	if err := change(&user); err != nil {
+		return domain.User{}, err
+	}
+	f.users[id] = user
+	f.writes++
+	return user, nil
+}
+
+func (f *fakeStore) GetEnrollment(_ context.Context, id string) (domain.Enrollment, error) {
+	enrollment, ok := f.enrollments[id]
+	if !ok {
+		return domain.Enrollment{}, service.ErrNotFound
+	}
+	return enrollment, nil
+}
+
+func (f *fakeStore) ListEnrollments(_ context.Context, filter service.EnrollmentFilter, limit, offset int) ([]domain.Enrollment, int64, error) {
+	var matched []domain.Enrollment
+	for _, e := range f.enrollments {
+		if (filter.UserID == "" || e.UserID == filter.UserID) && (filter.CourseID == "" || e.CourseID == filter.CourseID) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, int64(len(matched)), nil
+}
+
+func (f *fakeStore) CreateEnrollment(_ context.Context, enrollment *domain.Enrollment) error {
+	if _, ok := f.users[enrollment.UserID]; !ok {
+		return service.ErrMissingReference
+	}
+	if _, ok := f.courses[enrollment.CourseID]; !ok {
+		return service.ErrMissingReference
+	}
+	for _, other := range f.enrollments {
+		if other.UserID == enrollment.UserID && other.CourseID == enrollment.CourseID {
+			return service.ErrDuplicate
+		}
+	}
+	f.enrollments[enrollment.ID] = *enrollment
+	f.writes++
+	return nil
+}
+
+// CreateEnrollments rolls back the enrollments it created when one fails.
+func (f *fakeStore) CreateEnrollments(ctx context.Context, enrollments []*domain.Enrollment) error {
+	saved, writes := maps.Clone(f.enrollments), f.writes
+	for i, enrollment := range enrollments {
+		if err := f.CreateEnrollment(ctx, enrollment); err != nil {
+			f.enrollments, f.writes = saved, writes
+			return &service.BatchItemError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) DeleteEnrollment(_ context.Context, id string) (domain.Enrollment, error) {
+	enrollment, ok := f.enrollments[id]
+	if !ok {
+		return domain.Enrollment{}, service.ErrNotFound
+	}
+	delete(f.enrollments, id)
+	f.writes++
+	return enrollment, nil
+}
+
+func fields(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var invalid *service.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("err=%v, want a ValidationError", err)
+	}
+	return invalid.Fields
+}
+
+func ptr(s string) *string { return &s }
+
+const missingID = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+func TestCourseService_CreateTrimsAndValidates(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	ctx := context.Background()
+
+	course, err := courses.Create(ctx, service.CourseInput{Title: "  Go basics ", Description: " Intro "})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if course.Title != "Go basics" || course.Description != "Intro" || course.ID == "" {
+		t.Fatalf("course=%+v", course)
+	}
+
+	_, err = courses.Create(ctx, service.CourseInput{Title: "Go", Description: "x"})
+	if got := fields(t, err); got["title"] != "must be at least 3 characters" || got["description"] != "must be at least 3 characters" {
+		t.Fatalf("fields=%v", got)
+	}
+	if store.writes != 1 {
+		t.Fatalf("writes=%d", store.writes)
+	}
+}
+
+func TestCourseService_CreateConflict(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	if _, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	_, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"})
+	if !errors.Is(err, service.ErrConflict) || err.Error() != "title already exists" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestCourseService_Get(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	if _, err := courses.Get(ctx, "nope"); fields(t, err)["id"] != "is invalid" {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := courses.Get(ctx, missingID); !errors.Is(err, service.ErrNotFound) || err.Error() != "course not found" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestCourseService_UpdateAppliesSetFields(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	created, err := courses.Create(ctx, service.CourseInput{Title: "Go basics", Description: "Intro"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	updated, err := courses.Update(ctx, created.ID, service.CourseUpdate{Title: ptr(" Go in depth ")})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Title != "Go in depth" || updated.Description != "Intro" {
+		t.Fatalf("updated=%+v", updated)
+	}
+
+	_, err = courses.Update(ctx, created.ID, service.CourseUpdate{Description: ptr("x")})
+	if fields(t, err)["description"] != "must be at least 3 characters" {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := courses.Update(ctx, missingID, service.CourseUpdate{}); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestCourseService_ListNormalizesPage(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	for _, title := range []string{"Go basics", "Go in depth", "Rust basics"} {
+		if _, err := courses.Create(ctx, service.CourseInput{Title: title}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	page, err := courses.List(ctx, service.ListCourses{Page: service.Page{Page: 0, Limit: 500}, Query: " Go "})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if page.Total != 2 || len(page.Data) != 2 {
+		t.Fatalf("page=%+v", page)
+	}
+}
+
+func TestCourseService_ListRefillsCacheFromPrimary(t *testing.T) {
+	ctx := context.Background()
+	in := service.ListCourses{Page: service.Page{Page: 1, Limit: 10}}
+
+	store := newFakeStore()
+	courses := service.NewCourseService(store, service.WithCache(cache.New(cache.NewLRU(100), time.Minute)))
+	if _, err := courses.List(ctx, in); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if _, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	page, err := courses.List(ctx, in)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if page.Total != 1 || store.primaryListReads != 2 {
+		t.Fatalf("total=%d primaryListReads=%d", page.Total, store.primaryListReads)
+	}
+
+	// without a cache nothing is shared, so replicas serve the lists
+	store = newFakeStore()
+	if _, err := service.NewCourseService(store).List(ctx, in); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if store.primaryListReads != 0 {
+		t.Fatalf("primaryListReads=%d", store.primaryListReads)
+	}
+}
+
+type rows []service.ImportRow
+
+func (r *rows) Next() (service.ImportRow, error) {
+	if len(*r) == 0 {
+		return service.ImportRow{}, io.EOF
+	}
+	row := (*r)[0]
+	*r = (*r)[1:]
+	return row, nil
+}
+
+func TestCourseService_ImportUpsertsByTitle(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	ctx := context.Background()
+
+	if _, err := courses.Create(ctx, service.CourseInput{Title: "Go basics", Description: "Old"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	input := rows{
+		{Line: 2, Input: service.CourseInput{Title: "Go basics", Description: "New"}},
+		{Line: 3, Input: service.CourseInput{Title: "Rust basics"}},
+		{Line: 4, Input: service.CourseInput{Title: "go basics"}},
+		{Line: 5, Input: service.CourseInput{Title: "Go"}},
+		{Line: 6, Err: "bare \" in non-quoted field"},
+	}
+	report, err := courses.Import(ctx, &input, false, nil)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Total != 5 || report.Updated != 1 || report.Created != 1 || report.Failed != 3 {
+		t.Fatalf("report=%+v", report)
+	}
+	if report.Errors[0].Errors["title"] != "duplicates line 2" {
+		t.Fatalf("errors=%+v", report.Errors)
+	}
+	if len(store.courses) != 2 {
+		t.Fatalf("courses=%v", store.courses)
+	}
+}
+
+func TestCourseService_ImportDryRunWritesNothing(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+
+	input := rows{{Line: 2, Input: service.CourseInput{Title: "Rust basics"}}}
+	report, err := courses.Import(context.Background(), &input, true, nil)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !report.DryRun || report.Created != 1 || store.writes != 0 {
+		t.Fatalf("report=%+v writes=%d", report, store.writes)
+	}
+}
+
+func TestCourseService_RunImportJobRecordsReportAndOutcome(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	ctx := context.Background()
+
+	job, err := courses.CreateImportJob(ctx, service.FormatCSV, false, strings.NewReader("title\nGo basics\nGo\n"))
+	if err != nil || job.Status != domain.ImportJobPending {
+		t.Fatalf("job=%+v err=%v", job, err)
+	}
+	if err := courses.RunImportJob(ctx, job.ID); err != nil {
+		t.Fatalf("RunImportJob: %v", err)
+	}
+
+	job, report, err := courses.ImportJob(ctx, job.ID)
+	if err != nil || job.Status != domain.ImportJobSucceeded {
+		t.Fatalf("job=%+v err=%v", job, err)
+	}
+	if report == nil || report.Created != 1 || report.Failed != 1 {
+		t.Fatalf("report=%+v", report)
+	}
+	if _, ok := store.uploads[job.ID]; ok {
+		t.Fatal("the body of a finished job is kept")
+	}
+
+	// Running a finished job again, as after a lost lock, changes nothing.
+	writes := store.writes
+	if err := courses.RunImportJob(ctx, job.ID); err != nil || store.writes != writes {
+		t.Fatalf("rerun: err=%v writes=%d->%d", err, writes, store.writes)
+	}
+
+	// A layout that cannot be read fails the job with the reason.
+	bad, _ := courses.CreateImportJob(ctx, service.FormatCSV, false, strings.NewReader("name\nGo basics\n"))
+	if err := courses.RunImportJob(ctx, bad.ID); err != nil {
+		t.Fatalf("RunImportJob: %v", err)
+	}
+	if bad, _, _ = courses.ImportJob(ctx, bad.ID); bad.Status != domain.ImportJobFailed || bad.Error == "" {
+		t.Fatalf("job=%+v", bad)
+	}
+
+	if _, _, err := courses.ImportJob(ctx, uuid.NewString()); !errors.Is(err, service.ErrNotFound) || err.Error() != "import job not found" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestCourseService_ExportStreamsEveryCourse(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	ctx := context.Background()
+	for _, title := range []string{"Go basics", "Rust basics"} {
+		if _, err := courses.Create(ctx, service.CourseInput{Title: title}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	cursor, err := courses.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	defer cursor.Close()
+
+	n := 0
+	for {
+		if _, err := cursor.Next(); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Fatalf("exported %d courses", n)
+	}
+}
+
+func TestUserService_CreateAndConflict(t *testing.T) {
+	users := service.NewUserService(newFakeStore())
+	ctx := context.Background()
+
+	if _, err := users.Create(ctx, service.UserInput{Email: "not-an-email", Name: "A"}); len(fields(t, err)) != 2 {
+		t.Fatalf("err=%v", err)
+	}
+
+	user, err := users.Create(ctx, service.UserInput{Email: " ana@example.com ", Name: "Ana"})
+	if err != nil || user.Email != "ana@example.com" {
+		t.Fatalf("user=%+v err=%v", user, err)
+	}
+	if _, err := users.Create(ctx, service.UserInput{Email: "ana@example.com", Name: "Ana"}); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestEnrollmentService_CreateAndCancel(t *testing.T) {
+	store := newFakeStore()
+	ctx := context.Background()
+	course, err := service.NewCourseService(store).Create(ctx, service.CourseInput{Title: "Go basics"})
+	if err != nil {
+		t.Fatalf("Create course: %v", err)
+	}
+	user, err := service.NewUserService(store).Create(ctx, service.UserInput{Email: "ana@example.com", Name: "Ana"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	enrollments := service.NewEnrollmentService(store)
+
+	_, err = enrollments.Create(ctx, service.EnrollmentInput{UserID: user.ID, CourseID: missingID})
+	if !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("missing course: err=%v", err)
+	}
+
+	enrollment, err := enrollments.Create(ctx, service.EnrollmentInput{UserID: user.ID, CourseID: course.ID})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	_, err = enrollments.Create(ctx, service.EnrollmentInput{UserID: user.ID, CourseID: course.ID})
+	if !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("repeat: err=%v", err)
+	}
+
+	if _, err := enrollments.Cancel(ctx, enrollment.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if _, err := enrollments.Cancel(ctx, enrollment.ID); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("Cancel twice: err=%v", err)
+	}
+}
+
+func TestParseCourseProjection(t *testing.T) {
+	p, err := service.ParseCourseProjection(" id, title ,id", "modules,,instructor")
+	if err != nil {
+		t.Fatalf("ParseCourseProjection: %v", err)
+	}
+	if !slices.Equal(p.Fields, []string{"id", "title"}) || !slices.Equal(p.Include, []string{"modules", "instructor"}) {
+		t.Fatalf("p=%+v", p)
+	}
+
+	_, err = service.ParseCourseProjection("id,secret", "students")
+	got := fields(t, err)
+	if got["fields"] != `unknown field "secret"` || got["include"] != `unknown relation "students"` {
+		t.Fatalf("fields=%v", got)
+	}
+}
+
+func TestCourseService_ListViewsEmbedsRelations(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	users := service.NewUserService(store)
+	enrollments := service.NewEnrollmentService(store)
+	ctx := context.Background()
+
+	instructor, err := users.Create(ctx, service.UserInput{Email: "ada@example.com", Name: "Ada"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	var ids []string
+	for _, title := range []string{"Go basics", "Go in depth", "Go testing"} {
+		course, err := courses.Create(ctx, service.CourseInput{Title: title, Description: "A long description"})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ids = append(ids, course.ID)
+	}
+	for _, id := range ids[:2] {
+		course := store.courses[id]
+		course.InstructorID = &instructor.ID
+		store.courses[id] = course
+	}
+	if _, err := enrollments.Create(ctx, service.EnrollmentInput{UserID: instructor.ID, CourseID: ids[0]}); err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	store.modules = []domain.CourseModule{
+		{ID: "m1", CourseID: ids[0], Title: "Setup", Position: 1},
+		{ID: "m2", CourseID: ids[0], Title: "Types", Position: 2},
+	}
+
+	p, err := service.ParseCourseProjection("id,title", "enrollments_count,instructor,modules")
+	if err != nil {
+		t.Fatalf("ParseCourseProjection: %v", err)
+	}
+	page, err := courses.ListViews(ctx, service.ListCourses{Page: service.Page{Page: 1, Limit: 10}}, p)
+	if err != nil {
+		t.Fatalf("ListViews: %v", err)
+	}
+	if page.Total != 3 || len(page.Data) != 3 {
+		t.Fatalf("page=%+v", page)
+	}
+	if store.relationReads != 3 {
+		t.Fatalf("relationReads=%d, want one per relation", store.relationReads)
+	}
+
+	// Go basics sorts first in the fake store.
+	var first map[string]any
+	b, err := json.Marshal(page.Data[0])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if err := json.Unmarshal(b, &first); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if _, ok := first["description"]; ok {
+		t.Fatalf("description was not selected: %s", b)
+	}
+	if _, ok := first["instructor_id"]; ok {
+		t.Fatalf("instructor_id was not selected: %s", b)
+	}
+	if first["title"] != "Go basics" || first["enrollments_count"] != float64(1) {
+		t.Fatalf("first=%s", b)
+	}
+	if first["instructor"].(map[string]any)["name"] != "Ada" || len(first["modules"].([]any)) != 2 {
+		t.Fatalf("first=%s", b)
+	}
+
+	b, err = json.Marshal(page.Data[2])
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(b) != `{"enrollments_count":0,"id":"`+page.Data[2].Course.ID+`","instructor":null,"modules":[],"title":"Go testing"}` {
+		t.Fatalf("last=%s", b)
+	}
+}
+
+func TestCourseService_GetViewWithoutProjectionIsTheCourse(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	created, err := courses.Create(ctx, service.CourseInput{Title: "Go basics", Description: "Intro"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	view, err := courses.GetView(ctx, created.ID, service.CourseProjection{})
+	if err != nil {
+		t.Fatalf("GetView: %v", err)
+	}
+	got, _ := json.Marshal(view)
+	want, _ := json.Marshal(created)
+	if string(got) != string(want) {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	p := service.CourseProjection{Fields: []string{"title"}}
+	if _, err := courses.GetView(ctx, missingID, p); !errors.Is(err, service.ErrNotFound) || err.Error() != "course not found" {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := courses.GetView(ctx, "nope", p); fields(t, err)["id"] != "is invalid" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestCourseService_CreateBatchAtomicRollsBack(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	ctx := context.Background()
+
+	if _, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	results, err := courses.CreateBatch(ctx, "", []service.CourseInput{
+		{Title: "Go advanced"},
+		{Title: "go basics"},
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if !errors.Is(results[0].Err, service.ErrNotApplied) || !errors.Is(results[1].Err, service.ErrConflict) {
+		t.Fatalf("results=%+v", results)
+	}
+	if len(store.courses) != 1 {
+		t.Fatalf("courses=%v", store.courses)
+	}
+
+	results, err = courses.CreateBatch(ctx, service.BatchAtomic, []service.CourseInput{{Title: "Go advanced"}, {Title: "Go"}})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if !errors.Is(results[0].Err, service.ErrNotApplied) || fields(t, results[1].Err)["title"] != "must be at least 3 characters" {
+		t.Fatalf("results=%+v", results)
+	}
+	if store.writes != 1 {
+		t.Fatalf("writes=%d", store.writes)
+	}
+}
+
+func TestCourseService_CreateBatchBestEffort(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+
+	results, err := courses.CreateBatch(context.Background(), service.BatchBestEffort, []service.CourseInput{
+		{Title: "Go basics"},
+		{Title: "Go basics"},
+		{Title: "Go"},
+	})
+	if err != nil {
+		t.Fatalf("CreateBatch: %v", err)
+	}
+	if results[0].Err != nil || results[0].Value.ID == "" {
+		t.Fatalf("first: %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, service.ErrConflict) {
+		t.Fatalf("second: %+v", results[1])
+	}
+	if fields(t, results[2].Err)["title"] != "must be at least 3 characters" {
+		t.Fatalf("third: %+v", results[2])
+	}
+	if len(store.courses) != 1 {
+		t.Fatalf("courses=%v", store.courses)
+	}
+}
+
+func TestCourseService_CreateBatchRejectsTheBatch(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	_, err := courses.CreateBatch(ctx, "all", nil)
+	if got := fields(t, err); got["mode"] != "is invalid" || got["items"] != "is required" {
+		t.Fatalf("fields=%v", got)
+	}
+
+	_, err = courses.CreateBatch(ctx, "", make([]service.CourseInput, service.MaxBatchItems+1))
+	if got := fields(t, err); got["items"] != "at most 100 items" {
+		t.Fatalf("fields=%v", got)
+	}
+}
+
+func TestCourseService_GetBatch(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+	course, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	ids := []string{course.ID, missingID, "nope"}
+
+	results, err := courses.GetBatch(ctx, service.BatchBestEffort, ids)
+	if err != nil {
+		t.Fatalf("GetBatch: %v", err)
+	}
+	if results[0].Value.Title != "Go basics" || !errors.Is(results[1].Err, service.ErrNotFound) || fields(t, results[2].Err)["id"] != "is invalid" {
+		t.Fatalf("results=%+v", results)
+	}
+
+	results, err = courses.GetBatch(ctx, service.BatchAtomic, ids)
+	if err != nil {
+		t.Fatalf("GetBatch: %v", err)
+	}
+	if results[0].Value.ID != "" || !errors.Is(results[0].Err, service.ErrNotApplied) {
+		t.Fatalf("results=%+v", results)
+	}
+}
+
+func TestCourseService_GetBatchInTheLocale(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+	course, err := courses.Create(ctx, service.CourseInput{Title: "Go basics", Description: "Intro"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	other, err := courses.Create(ctx, service.CourseInput{Title: "Rust basics"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, _, err := courses.PutTranslation(ctx, course.ID, "pt-BR", service.CourseTranslationInput{Title: "Go básico"}); err != nil {
+		t.Fatalf("PutTranslation: %v", err)
+	}
+
+	pt := i18n.WithLocales(ctx, []string{"pt-BR", "en"})
+	results, err := courses.GetBatch(pt, service.BatchBestEffort, []string{course.ID, other.ID})
+	if err != nil {
+		t.Fatalf("GetBatch: %v", err)
+	}
+	if got := results[0].Value; got.Title != "Go básico" || got.Description != "Intro" {
+		t.Fatalf("results[0]=%+v", got)
+	}
+	if got := results[1].Value; got.Title != "Rust basics" {
+		t.Fatalf("results[1]=%+v", got)
+	}
+}
+
+func TestEnrollmentService_EnrollBatch(t *testing.T) {
+	store := newFakeStore()
+	ctx := context.Background()
+	course, err := service.NewCourseService(store).Create(ctx, service.CourseInput{Title: "Go basics"})
+	if err != nil {
+		t.Fatalf("Create course: %v", err)
+	}
+	users := service.NewUserService(store)
+	ana, err := users.Create(ctx, service.UserInput{Email: "ana@example.com", Name: "Ana"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	bia, err := users.Create(ctx, service.UserInput{Email: "bia@example.com", Name: "Bia"})
+	if err != nil {
+		t.Fatalf("Create user: %v", err)
+	}
+	enrollments := service.NewEnrollmentService(store)
+
+	results, err := enrollments.EnrollBatch(ctx, service.BatchAtomic, course.ID, []string{ana.ID, missingID})
+	if err != nil {
+		t.Fatalf("EnrollBatch: %v", err)
+	}
+	if !errors.Is(results[0].Err, service.ErrNotApplied) || !errors.Is(results[1].Err, service.ErrNotFound) {
+		t.Fatalf("results=%+v", results)
+	}
+	if len(store.enrollments) != 0 {
+		t.Fatalf("enrollments=%v", store.enrollments)
+	}
+
+	results, err = enrollments.EnrollBatch(ctx, service.BatchAtomic, course.ID, []string{ana.ID, bia.ID})
+	if err != nil || service.Failed(results) {
+		t.Fatalf("results=%+v err=%v", results, err)
+	}
+
+	results, err = enrollments.EnrollBatch(ctx, service.BatchBestEffort, course.ID, []string{ana.ID, missingID})
+	if err != nil {
+		t.Fatalf("EnrollBatch: %v", err)
+	}
+	if !errors.Is(results[0].Err, service.ErrConflict) || !errors.Is(results[1].Err, service.ErrNotFound) {
+		t.Fatalf("results=%+v", results)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	cases := map[string]string{
+		"Web Development":       "web-development",
+		"  Programação  em Go ": "programacao-em-go",
+		"C++ / C#":              "c-c",
+		"--already-a-slug--":    "already-a-slug",
+		"!!!":                   "",
+	}
+	for in, want := range cases {
+		if got := service.Slug(in); got != want {
+			t.Fatalf("Slug(%q)=%q want %q", in, got, want)
+		}
+	}
+}
+
+func TestCourseSlug(t *testing.T) {
+	cases := map[string]string{
+		"Programação Avançada: 1ª Edição": "programacao-avancada-1a-edicao",
+		"Introdução à Computação":         "introducao-a-computacao",
+		"2048":                            "course-2048",
+		"!!!":                             "course",
+		strings.Repeat("golang ", 20):     strings.TrimSuffix(strings.Repeat("golang-", 14), "-"),
+	}
+	for in, want := range cases {
+		if got := service.CourseSlug(in); got != want {
+			t.Fatalf("CourseSlug(%q)=%q want %q", in, got, want)
+		}
+	}
+
+	for s, want := range map[string]bool{"go-basics": true, "go-basics-2": true, "course-2048": true, "1233": false, "Go": false, "go--basics": false} {
+		if got := service.IsCourseSlug(s); got != want {
+			t.Fatalf("IsCourseSlug(%q)=%v", s, got)
+		}
+	}
+}
+
+func TestCourseService_SlugsCollideAndRedirect(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	first, err := courses.Create(ctx, service.CourseInput{Title: "Go Básico"})
+	if err != nil || *first.Slug != "go-basico" {
+		t.Fatalf("first=%+v err=%v", first, err)
+	}
+	second, err := courses.Create(ctx, service.CourseInput{Title: "Go basico!"})
+	if err != nil || *second.Slug != "go-basico-2" {
+		t.Fatalf("second=%+v err=%v", second, err)
+	}
+
+	renamed, err := courses.Update(ctx, first.ID, service.CourseUpdate{Title: ptr("Go Fundamentals")})
+	if err != nil || *renamed.Slug != "go-fundamentals" {
+		t.Fatalf("renamed=%+v err=%v", renamed, err)
+	}
+	if id, moved, err := courses.Resolve(ctx, "go-basico"); err != nil || id != first.ID || moved != "go-fundamentals" {
+		t.Fatalf("Resolve(go-basico)=%q,%q,%v", id, moved, err)
+	}
+
+	// the former slug stays with the first course
+	third, err := courses.Create(ctx, service.CourseInput{Title: "Go Básico"})
+	if err != nil || *third.Slug != "go-basico-3" {
+		t.Fatalf("third=%+v err=%v", third, err)
+	}
+
+	// and it can take it back
+	back, err := courses.Update(ctx, first.ID, service.CourseUpdate{Title: ptr("Go básico?")})
+	if err != nil || *back.Slug != "go-basico" {
+		t.Fatalf("back=%+v err=%v", back, err)
+	}
+	if id, moved, err := courses.Resolve(ctx, "go-basico"); err != nil || id != first.ID || moved != "" {
+		t.Fatalf("Resolve(go-basico)=%q,%q,%v", id, moved, err)
+	}
+	if id, moved, err := courses.Resolve(ctx, "go-fundamentals"); err != nil || id != first.ID || moved != "go-basico" {
+		t.Fatalf("Resolve(go-fundamentals)=%q,%q,%v", id, moved, err)
+	}
+
+	// a description change keeps the slug
+	kept, err := courses.Update(ctx, second.ID, service.CourseUpdate{Description: ptr("Sintaxe e tipos")})
+	if err != nil || *kept.Slug != "go-basico-2" {
+		t.Fatalf("kept=%+v err=%v", kept, err)
+	}
+
+	if id, _, err := courses.Resolve(ctx, second.ID); err != nil || id != second.ID {
+		t.Fatalf("Resolve(id)=%q,%v", id, err)
+	}
+	var invalid *service.ValidationError
+	if _, _, err := courses.Resolve(ctx, "1233"); !errors.As(err, &invalid) {
+		t.Fatalf("Resolve(1233) err=%v", err)
+	}
+	if _, _, err := courses.Resolve(ctx, "no-such-course"); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("Resolve(no-such-course) err=%v", err)
+	}
+}
+
+func TestCategoryService_CreateUpdateDelete(t *testing.T) {
+	categories := service.NewCategoryService(newFakeStore(), nil)
+	ctx := context.Background()
+
+	root, err := categories.Create(ctx, service.CategoryInput{Name: " Programação "})
+	if err != nil || root.Slug != "programacao" || root.Name != "Programação" {
+		t.Fatalf("root=%+v err=%v", root, err)
+	}
+	child, err := categories.Create(ctx, service.CategoryInput{Name: "Go", Slug: "Golang", ParentID: &root.ID})
+	if err != nil || child.Slug != "golang" || *child.ParentID != root.ID {
+		t.Fatalf("child=%+v err=%v", child, err)
+	}
+
+	if _, err := categories.Create(ctx, service.CategoryInput{Name: "Programacao"}); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("repeated slug: err=%v", err)
+	}
+	if _, err := categories.Create(ctx, service.CategoryInput{Name: "Orphan", ParentID: ptr(missingID)}); fields(t, err)["parent_id"] != "does not exist" {
+		t.Fatalf("missing parent: err=%v", err)
+	}
+	if _, err := categories.Update(ctx, root.ID, service.CategoryUpdate{ParentID: &child.ID}); fields(t, err)["parent_id"] != "would create a cycle" {
+		t.Fatalf("cycle: err=%v", err)
+	}
+
+	renamed, err := categories.Update(ctx, root.ID, service.CategoryUpdate{Name: ptr("Software")})
+	if err != nil || renamed.Name != "Software" || renamed.Slug != "programacao" {
+		t.Fatalf("renamed=%+v err=%v", renamed, err)
+	}
+
+	if _, err := categories.Delete(ctx, root.ID); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("delete parent: err=%v", err)
+	}
+	if _, err := categories.Update(ctx, child.ID, service.CategoryUpdate{ParentID: ptr("")}); err != nil {
+		t.Fatalf("move to top: %v", err)
+	}
+	if _, err := categories.Delete(ctx, root.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
+
+func TestCategoryService_StoredCycleDoesNotHangTreeWalks(t *testing.T) {
+	store := newFakeStore()
+	ctx := context.Background()
+	courses := service.NewCourseService(store)
+	categories := service.NewCategoryService(store, nil)
+
+	// a and b name each other as parent, as no write path allows.
+	a, b := uuid.NewString(), uuid.NewString()
+	store.categories[a] = domain.Category{ID: a, Name: "A", Slug: "a", ParentID: &b}
+	store.categories[b] = domain.Category{ID: b, Name: "B", Slug: "b", ParentID: &a}
+	other, err := categories.Create(ctx, service.CategoryInput{Name: "Other"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := categories.Update(ctx, other.ID, service.CategoryUpdate{ParentID: &a}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	course, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := courses.SetCategories(ctx, course.ID, []string{a}); err != nil {
+		t.Fatalf("SetCategories: %v", err)
+	}
+	page, err := courses.List(ctx, service.ListCourses{Category: "b", Facets: true})
+	if err != nil || page.Total != 1 || len(page.Facets.Categories) != 2 {
+		t.Fatalf("page=%+v err=%v", page, err)
+	}
+}
+
+func TestTagService_NormalizesNames(t *testing.T) {
+	tags := service.NewTagService(newFakeStore(), nil)
+	ctx := context.Background()
+
+	tag, err := tags.Create(ctx, service.TagInput{Name: "Web Dev"})
+	if err != nil || tag.Name != "web-dev" {
+		t.Fatalf("tag=%+v err=%v", tag, err)
+	}
+	if _, err := tags.Create(ctx, service.TagInput{Name: "WEB-dev"}); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("repeat: err=%v", err)
+	}
+	if _, err := tags.Create(ctx, service.TagInput{Name: "?!"}); fields(t, err)["name"] != "is required" {
+		t.Fatalf("empty: err=%v", err)
+	}
+
+	if got, err := tags.Get(ctx, "Web Dev"); err != nil || got.ID != tag.ID {
+		t.Fatalf("Get: tag=%+v err=%v", got, err)
+	}
+	if renamed, err := tags.Rename(ctx, "web-dev", service.TagInput{Name: "Frontend"}); err != nil || renamed.Name != "frontend" {
+		t.Fatalf("Rename: tag=%+v err=%v", renamed, err)
+	}
+	if _, err := tags.Delete(ctx, "web-dev"); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("Delete old name: err=%v", err)
+	}
+}
+
+func TestCourseService_ListFiltersAndCountsFacets(t *testing.T) {
+	store := newFakeStore()
+	ctx := context.Background()
+	courses := service.NewCourseService(store)
+	categories := service.NewCategoryService(store, nil)
+
+	programming, _ := categories.Create(ctx, service.CategoryInput{Name: "Programming"})
+	golang, _ := categories.Create(ctx, service.CategoryInput{Name: "Go", ParentID: &programming.ID})
+	rust, _ := categories.Create(ctx, service.CategoryInput{Name: "Rust", ParentID: &programming.ID})
+	design, _ := categories.Create(ctx, service.CategoryInput{Name: "Design"})
+
+	create := func(title string, categoryIDs []string, tags ...string) {
+		t.Helper()
+		course, err := courses.Create(ctx, service.CourseInput{Title: title})
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := courses.SetCategories(ctx, course.ID, categoryIDs); err != nil {
+			t.Fatalf("SetCategories: %v", err)
+		}
+		if _, err := courses.SetTags(ctx, course.ID, tags); err != nil {
+			t.Fatalf("SetTags: %v", err)
+		}
+	}
+	create("Go basics", []string{golang.ID, golang.ID}, "Beginner", "backend")
+	create("Go and Rust interop", []string{golang.ID, rust.ID}, "backend")
+	create("UI basics", []string{design.ID}, "beginner")
+
+	page, err := courses.List(ctx, service.ListCourses{Category: "Programming", Facets: true})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if page.Total != 2 {
+		t.Fatalf("total=%d", page.Total)
+	}
+	counts := map[string]int64{}
+	for _, facet := range page.Facets.Categories {
+		counts[facet.Slug] = facet.Count
+	}
+	if counts["programming"] != 2 || counts["go"] != 2 || counts["rust"] != 1 || counts["design"] != 0 {
+		t.Fatalf("category facets=%+v", page.Facets.Categories)
+	}
+	if tags := page.Facets.Tags; len(tags) != 2 || tags[0] != (service.TagFacet{Name: "backend", Count: 2}) {
+		t.Fatalf("tag facets=%+v", tags)
+	}
+
+	page, err = courses.List(ctx, service.ListCourses{Tag: "BEGINNER"})
+	if err != nil || page.Total != 2 || page.Facets != nil {
+		t.Fatalf("by tag: page=%+v err=%v", page, err)
+	}
+
+	page, err = courses.List(ctx, service.ListCourses{Category: "nope", Facets: true})
+	if err != nil || page.Total != 0 || len(page.Facets.Categories) != 0 {
+		t.Fatalf("unknown category: page=%+v err=%v", page, err)
+	}
+}
+
+func TestCourseService_SetTagsAndCategoriesValidate(t *testing.T) {
+	store := newFakeStore()
+	courses := service.NewCourseService(store)
+	ctx := context.Background()
+	course, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := courses.SetCategories(ctx, course.ID, []string{missingID}); fields(t, err)["categoryIds"] != "contains an unknown category" {
+		t.Fatalf("unknown category: err=%v", err)
+	}
+	if _, err := courses.SetTags(ctx, course.ID, []string{"go", "%%"}); fields(t, err)["tags"] != `invalid tag "%%"` {
+		t.Fatalf("invalid tag: err=%v", err)
+	}
+	if _, err := courses.SetTags(ctx, missingID, []string{"go"}); !errors.Is(err, service.ErrNotFound) {
+		t.Fatalf("missing course: err=%v", err)
+	}
+
+	tags, err := courses.SetTags(ctx, course.ID, []string{"Go", "go", "Back End"})
+	if err != nil || len(tags) != 2 || tags[0].Name != "go" || tags[1].Name != "back-end" {
+		t.Fatalf("tags=%+v err=%v", tags, err)
+	}
+
+	p, err := service.ParseCourseProjection("id", "tags,categories")
+	if err != nil {
+		t.Fatalf("ParseCourseProjection: %v", err)
+	}
+	view, err := courses.GetView(ctx, course.ID, p)
+	if err != nil {
+		t.Fatalf("GetView: %v", err)
+	}
+	b, _ := json.Marshal(view)
+	if !strings.Contains(string(b), `"categories":[]`) || !strings.Contains(string(b), `"name":"back-end"`) {
+		t.Fatalf("view=%s", b)
+	}
+}
+
+func TestCourseService_TranslationsFallBackAlongTheChain(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+	course, err := courses.Create(ctx, service.CourseInput{Title: "Go basics", Description: "Intro"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	other, err := courses.Create(ctx, service.CourseInput{Title: "Rust basics"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	_, created, err := courses.PutTranslation(ctx, course.ID, "pt-br", service.CourseTranslationInput{Title: "Go básico"})
+	if err != nil || !created {
+		t.Fatalf("PutTranslation: created=%t err=%v", created, err)
+	}
+	if _, _, err := courses.PutTranslation(ctx, other.ID, "pt-BR", service.CourseTranslationInput{Title: "Go básico"}); !errors.Is(err, service.ErrConflict) {
+		t.Fatalf("same title in pt-BR: err=%v", err)
+	}
+	if _, _, err := courses.PutTranslation(ctx, other.ID, "en", service.CourseTranslationInput{Title: "Rust"}); fields(t, err)["locale"] != "is the default" {
+		t.Fatalf("default locale: err=%v", err)
+	}
+	if _, _, err := courses.PutTranslation(ctx, other.ID, "fr", service.CourseTranslationInput{Title: "Rust"}); fields(t, err)["locale"] != "is not supported" {
+		t.Fatalf("unsupported locale: err=%v", err)
+	}
+
+	pt := i18n.WithLocales(ctx, []string{"pt-BR", "en"})
+	got, err := courses.Get(pt, course.ID)
+	if err != nil || got.Title != "Go básico" || got.Description != "Intro" {
+		t.Fatalf("Get(pt-BR)=%+v err=%v", got, err)
+	}
+	if got, _ := courses.Get(ctx, course.ID); got.Title != "Go basics" {
+		t.Fatalf("Get=%+v", got)
+	}
+
+	page, err := courses.List(pt, service.ListCourses{Query: "básico"})
+	if err != nil || page.Total != 1 || page.Data[0].Title != "Go básico" {
+		t.Fatalf("List(pt-BR)=%+v err=%v", page, err)
+	}
+	page, err = courses.List(pt, service.ListCourses{Query: "basics"})
+	if err != nil || page.Total != 2 || page.Data[0].Title != "Go básico" || page.Data[1].Title != "Rust basics" {
+		t.Fatalf("List(pt-BR)=%+v err=%v", page, err)
+	}
+	if page, _ := courses.List(ctx, service.ListCourses{Query: "básico"}); page.Total != 0 {
+		t.Fatalf("List=%+v", page)
+	}
+
+	if _, err := courses.DeleteTranslation(ctx, course.ID, "pt-BR"); err != nil {
+		t.Fatalf("DeleteTranslation: %v", err)
+	}
+	if _, err := courses.DeleteTranslation(ctx, course.ID, "pt-BR"); err == nil || err.Error() != "translation not found" {
+		t.Fatalf("DeleteTranslation again: err=%v", err)
+	}
+	if got, _ := courses.Get(pt, course.ID); got.Title != "Go basics" {
+		t.Fatalf("Get(pt-BR) after delete=%+v", got)
+	}
+}
+
+func TestCourseService_CreateMessagesInTheLocale(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	pt := i18n.WithLocales(context.Background(), []string{"pt-BR", "en"})
+
+	_, err := courses.Create(pt, service.CourseInput{Title: "Go", Description: "x"})
+	if got := fields(t, err); got["title"] != "deve ter pelo menos 3 caracteres" || got["description"] != "deve ter pelo menos 3 caracteres" {
+		t.Fatalf("fields=%v", got)
+	}
+	if _, err := courses.Create(pt, service.CourseInput{Title: "Go basics"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := courses.Create(pt, service.CourseInput{Title: "Go basics"}); err == nil || err.Error() != "título já existe" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestCourseService_ValidatesWithCustomRules(t *testing.T) {
+	courses := service.NewCourseService(newFakeStore())
+	ctx := context.Background()
+
+	_, err := courses.Create(ctx, service.CourseInput{Title: "<b>Go</b> basics", Description: "Que merda"})
+	if got := fields(t, err); got["title"] != "must not contain HTML" || got["description"] != "must not contain offensive words" {
+		t.Fatalf("fields=%v", got)
+	}
+
+	course, err := courses.Create(ctx, service.CourseInput{Title: "Go basics"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := courses.SetCategories(ctx, course.ID, []string{"nope"}); fields(t, err)["categoryIds"] != "must contain only UUIDs" {
+		t.Fatalf("err=%v", err)
+	}
+	if _, err := courses.SetCategories(ctx, course.ID, slices.Repeat([]string{missingID}, 11)); fields(t, err)["categoryIds"] != "must contain at most 10 items" {
+		t.Fatalf("err=%v", err)
+	}
+}
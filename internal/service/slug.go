@@ -0,0 +1,70 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Slug normalizes s for URLs and tags: lowercase ASCII letters and digits,
+// accents dropped (and ordinals such as 1ª spelled out), other runs of
+// characters turned into single dashes.
+func Slug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFKD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// maxCourseSlug bounds the slug a title gives, leaving room in the column
+// for a collision suffix.
+const maxCourseSlug = 100
+
+// CourseSlug is the URL slug of a course titled title, cut at a dash to 100
+// characters. A slug without letters gets a "course-" prefix so that it
+// never reads as an ID.
+func CourseSlug(title string) string {
+	slug := Slug(title)
+	if len(slug) > maxCourseSlug {
+		slug = slug[:maxCourseSlug]
+		if i := strings.LastIndexByte(slug, '-'); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	if !strings.ContainsFunc(slug, unicode.IsLetter) {
+		slug = strings.TrimSuffix("course-"+slug, "-")
+	}
+	return slug
+}
+
+// IsCourseSlug reports whether s has the form of a course slug, suffixed or
+// not.
+func IsCourseSlug(s string) bool {
+	return len(s) <= maxCourseSlug+20 && Slug(s) == s && strings.ContainsFunc(s, unicode.IsLetter)
+}
+
+// retitle gives course title and, when the title reads differently in a URL,
+// the slug of title. The store moves the course to the first free slug
+// starting with it.
+func retitle(course *domain.Course, title string) {
+	if course.Slug == nil || CourseSlug(course.Title) != CourseSlug(title) {
+		slug := CourseSlug(title)
+		course.Slug = &slug
+	}
+	course.Title = title
+}
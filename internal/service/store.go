@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+)
+
+// Stores return these for writes that break a unique key or refer to a
+// missing record, and ErrNotFound for missing records.
+var (
+	ErrDuplicate        = errors.New("duplicate key")
+	ErrMissingReference = errors.New("missing reference")
+)
+
+// BatchItemError is the store error of one item of a batch write, which was
+// rolled back as a whole.
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string { return fmt.Sprintf("item %d: %v", e.Index, e.Err) }
+
+func (e *BatchItemError) Unwrap() error { return e.Err }
+
+// CourseStore persists courses. Writes record their domain events in the same
+// transaction, and save a course with a Slug under the first free slug
+// starting with it: the slug itself, or it suffixed -2, -3 and so on. A
+// course whose slug changes keeps the former one in its history.
+// Reads given columns select only those, by column name.
+type CourseStore interface {
+	GetCourse(ctx context.Context, id string, columns ...string) (domain.Course, error)
+	// ResolveSlug returns the ID of the course with slug, now or formerly,
+	// and its current slug.
+	ResolveSlug(ctx context.Context, slug string) (id, current string, err error)
+	// ListCourses returns a page of the courses filter matches, newest first,
+	// and how many match in total.
+	ListCourses(ctx context.Context, filter CourseFilter, limit, offset int, columns ...string) ([]domain.Course, int64, error)
+	// CategoryLinks returns the categories of the courses filter matches.
+	CategoryLinks(ctx context.Context, filter CourseFilter) ([]CategoryLink, error)
+	// CountTags returns the number of courses filter matches per tag name.
+	CountTags(ctx context.Context, filter CourseFilter) (map[string]int64, error)
+	// CoursesByTitle returns the courses with any of titles.
+	CoursesByTitle(ctx context.Context, titles []string) ([]domain.Course, error)
+	// CoursesByID returns the courses with any of ids.
+	CoursesByID(ctx context.Context, ids []string) ([]domain.Course, error)
+	CreateCourse(ctx context.Context, course *domain.Course) error
+	// CreateCourses creates all courses in one transaction, failing with a
+	// *BatchItemError for the first one the store rejects.
+	CreateCourses(ctx context.Context, courses []*domain.Course) error
+	// UpdateCourse locks the course, applies change and saves it unless change
+	// fails.
+	UpdateCourse(ctx context.Context, id string, change func(*domain.Course) error) (domain.Course, error)
+
+	// CountEnrollments returns the number of enrollments per course, leaving
+	// out courses without any.
+	CountEnrollments(ctx context.Context, courseIDs []string) (map[string]int64, error)
+	// UsersByID returns the users with any of ids.
+	UsersByID(ctx context.Context, ids []string) ([]domain.User, error)
+	// CourseModules returns the modules of the courses, by position.
+	CourseModules(ctx context.Context, courseIDs []string) ([]domain.CourseModule, error)
+	// CourseCategories and CourseTags return the categories and tags of
+	// each course, by name, leaving out courses without any.
+	CourseCategories(ctx context.Context, courseIDs []string) (map[string][]domain.Category, error)
+	CourseTags(ctx context.Context, courseIDs []string) (map[string][]domain.Tag, error)
+
+	// ListCategories returns every category, by name.
+	ListCategories(ctx context.Context) ([]domain.Category, error)
+	// SetCourseCategories replaces the categories of the course. An unknown
+	// category is ErrMissingReference.
+	SetCourseCategories(ctx context.Context, courseID string, categoryIDs []string) ([]domain.Category, error)
+	// SetCourseTags replaces the tags of the course, creating the missing
+	// ones.
+	SetCourseTags(ctx context.Context, courseID string, names []string) ([]domain.Tag, error)
+
+	// CourseTranslations returns the translations of the courses into any of
+	// locales, or into every locale when none is given.
+	CourseTranslations(ctx context.Context, courseIDs, locales []string) ([]domain.CourseTranslation, error)
+	// PutCourseTranslation creates or replaces the translation of the course
+	// into its locale and reports whether it created it. An unknown course is
+	// ErrNotFound.
+	PutCourseTranslation(ctx context.Context, translation *domain.CourseTranslation) (created bool, err error)
+	// DeleteCourseTranslation deletes the translation and returns it.
+	DeleteCourseTranslation(ctx context.Context, courseID, locale string) (domain.CourseTranslation, error)
+
+	// ScanCourses returns a cursor over every course, oldest first.
+	ScanCourses(ctx context.Context) (CourseCursor, error)
+
+	// CreateImportJob saves the job and its body, in chunks, in one
+	// transaction.
+	CreateImportJob(ctx context.Context, job *domain.CourseImportJob, body io.Reader) error
+	GetImportJob(ctx context.Context, id string) (domain.CourseImportJob, error)
+	// ImportUpload reads the body of the job back.
+	ImportUpload(ctx context.Context, id string) io.Reader
+	// DeleteImportUpload drops the body of the job once it is done.
+	DeleteImportUpload(ctx context.Context, id string) error
+	// SaveImportReport replaces the JSON report of the job.
+	SaveImportReport(ctx context.Context, id, report string) error
+	// SetImportJobStatus moves the job to status, stamping started_at when
+	// it is running and finished_at when it is done, with message as its
+	// error.
+	SetImportJobStatus(ctx context.Context, id, status, message string) error
+}
+
+// CourseCursor reads courses one at a time. Close releases it.
+type CourseCursor interface {
+	// Next returns the next course, or io.EOF after the last one.
+	Next() (domain.Course, error)
+	Close() error
+}
+
+type primaryKey struct{}
+
+// FromPrimary marks ctx so the store reads from the primary rather than a
+// replica that may not have caught up with a recent write.
+func FromPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// ReadsPrimary reports whether ctx was marked by FromPrimary.
+func ReadsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// CourseFilter selects courses whose title, or their title in any of
+// Locales, contains Query, in any of CategoryIDs and tagged Tag, each when
+// set.
+type CourseFilter struct {
+	Query       string
+	Locales     []string
+	CategoryIDs []string
+	Tag         string
+}
+
+// CategoryLink files a course under a category.
+type CategoryLink struct {
+	CourseID   string
+	CategoryID string
+}
+
+// CategoryStore persists categories. Their writes have no domain events.
+type CategoryStore interface {
+	GetCategory(ctx context.Context, id string) (domain.Category, error)
+	ListCategories(ctx context.Context) ([]domain.Category, error)
+	CreateCategory(ctx context.Context, category *domain.Category) error
+	// UpdateCategory locks every category and passes them to change with
+	// the one to update, so checks over the tree see a set no other update
+	// changes meanwhile.
+	UpdateCategory(ctx context.Context, id string, change func(category *domain.Category, categories []domain.Category) error) (domain.Category, error)
+	// DeleteCategory removes the category from its courses and deletes it.
+	DeleteCategory(ctx context.Context, id string) (domain.Category, error)
+}
+
+// TagStore persists tags by name. Their writes have no domain events.
+type TagStore interface {
+	GetTag(ctx context.Context, name string) (domain.Tag, error)
+	// ListTags returns a page of tags, by name.
+	ListTags(ctx context.Context, limit, offset int) ([]domain.Tag, int64, error)
+	CreateTag(ctx context.Context, tag *domain.Tag) error
+	UpdateTag(ctx context.Context, name string, change func(*domain.Tag) error) (domain.Tag, error)
+	// DeleteTag removes the tag from its courses and deletes it.
+	DeleteTag(ctx context.Context, name string) (domain.Tag, error)
+}
+
+// UserStore persists users. Writes record their domain events in the same
+// transaction.
+type UserStore interface {
+	GetUser(ctx context.Context, id string) (domain.User, error)
+	ListUsers(ctx context.Context, limit, offset int) ([]domain.User, int64, error)
+	CreateUser(ctx context.Context, user *domain.User) error
+	UpdateUser(ctx context.Context, id string, change func(*domain.User) error) (domain.User, error)
+}
+
+// EnrollmentFilter selects the enrollments of a user and course when set.
+type EnrollmentFilter struct {
+	UserID   string
+	CourseID string
+}
+
+// EnrollmentStore persists enrollments. Writes record their domain events in
+// the same transaction.
+type EnrollmentStore interface {
+	GetEnrollment(ctx context.Context, id string) (domain.Enrollment, error)
+	// ListEnrollments returns a page of enrollments, newest first.
+	ListEnrollments(ctx context.Context, filter EnrollmentFilter, limit, offset int) ([]domain.Enrollment, int64, error)
+	CreateEnrollment(ctx context.Context, enrollment *domain.Enrollment) error
+	// CreateEnrollments creates all enrollments in one transaction, failing
+	// with a *BatchItemError for the first one the store rejects.
+	CreateEnrollments(ctx context.Context, enrollments []*domain.Enrollment) error
+	// DeleteEnrollment removes the enrollment and returns it.
+	DeleteEnrollment(ctx context.Context, id string) (domain.Enrollment, error)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+// TagInput names a tag. The name is stored in slug form: "Web Dev" is
+// "web-dev".
+type TagInput struct {
+	Name string `json:"name" validate:"required,max=50"`
+}
+
+// MaxCourseTags bounds the tags of one course.
+const MaxCourseTags = 20
+
+type TagPage struct {
+	Data  []domain.Tag
+	Total int64
+}
+
+type TagService struct {
+	store TagStore
+	cache *cache.Cache
+}
+
+// NewTagService returns the service. Writes invalidate the course list pages
+// cached in c, which hold tag facets.
+func NewTagService(store TagStore, c *cache.Cache) *TagService {
+	return &TagService{store: store, cache: c}
+}
+
+// Get returns the tag named name in any form.
+func (s *TagService) Get(ctx context.Context, name string) (domain.Tag, error) {
+	tag, err := s.store.GetTag(ctx, Slug(name))
+	if errors.Is(err, ErrNotFound) {
+		return domain.Tag{}, notFound("tag")
+	}
+
+	return tag, err
+}
+
+// List returns a page of tags, by name.
+func (s *TagService) List(ctx context.Context, page Page) (TagPage, error) {
+	page = page.normalize()
+
+	var result TagPage
+	var err error
+	result.Data, result.Total, err = s.store.ListTags(ctx, page.Limit, page.offset())
+	return result, err
+}
+
+func (s *TagService) Create(ctx context.Context, in TagInput) (domain.Tag, error) {
+	in.Name = Slug(in.Name)
+	if err := check(ctx, "tag", &in); err != nil {
+		return domain.Tag{}, err
+	}
+
+	tag := domain.Tag{ID: uuid.NewString(), Name: in.Name}
+	err := s.store.CreateTag(ctx, &tag)
+	if errors.Is(err, ErrDuplicate) {
+		return domain.Tag{}, tagTaken(ctx)
+	}
+	if err != nil {
+		return domain.Tag{}, err
+	}
+
+	return tag, nil
+}
+
+// Rename gives the tag named name the name of in, keeping its courses.
+func (s *TagService) Rename(ctx context.Context, name string, in TagInput) (domain.Tag, error) {
+	tag, err := s.store.UpdateTag(ctx, Slug(name), func(tag *domain.Tag) error {
+		in.Name = Slug(in.Name)
+		if err := check(ctx, "tag", &in); err != nil {
+			return err
+		}
+
+		tag.Name = in.Name
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return domain.Tag{}, notFound("tag")
+	case errors.Is(err, ErrDuplicate):
+		return domain.Tag{}, tagTaken(ctx)
+	case err != nil:
+		return domain.Tag{}, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return tag, nil
+}
+
+// Delete removes the tag from its courses and deletes it.
+func (s *TagService) Delete(ctx context.Context, name string) (domain.Tag, error) {
+	tag, err := s.store.DeleteTag(ctx, Slug(name))
+	if errors.Is(err, ErrNotFound) {
+		return domain.Tag{}, notFound("tag")
+	}
+	if err != nil {
+		return domain.Tag{}, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return tag, nil
+}
+
+// normalizeTags slugs names, dropping repeats, and rejects empty or long
+// ones, reporting them as field.
+func normalizeTags(ctx context.Context, field string, names []string) ([]string, error) {
+	if len(names) > MaxCourseTags {
+		return nil, &ValidationError{Fields: map[string]string{field: fmt.Sprintf("at most %d tags", MaxCourseTags)}}
+	}
+
+	seen := make(map[string]bool, len(names))
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		in := TagInput{Name: Slug(name)}
+		if err := check(ctx, "tag", &in); err != nil {
+			return nil, &ValidationError{Fields: map[string]string{field: fmt.Sprintf("invalid tag %q", name)}}
+		}
+		if !seen[in.Name] {
+			seen[in.Name] = true
+			tags = append(tags, in.Name)
+		}
+	}
+	return tags, nil
+}
+
+func tagTaken(ctx context.Context) *ConflictError {
+	metrics.ConflictsTotal.WithLabelValues(metrics.ConflictTagTaken).Inc()
+	obs.Conflict(ctx, metrics.ConflictTagTaken)
+	return &ConflictError{Reason: metrics.ConflictTagTaken, Message: "tag already exists"}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+)
+
+// MaxCourseCategories bounds the categories of one course.
+const MaxCourseCategories = 10
+
+// CourseFacets counts the courses of a search per category and tag, most
+// courses first. A category counts the courses filed under it or below it.
+type CourseFacets struct {
+	Categories []CategoryFacet `json:"categories"`
+	Tags       []TagFacet      `json:"tags"`
+}
+
+type CategoryFacet struct {
+	ID       string  `json:"id"`
+	Slug     string  `json:"slug"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+	Count    int64   `json:"count"`
+}
+
+type TagFacet struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// search runs a normalized in, selecting columns. An unknown category
+// matches no course.
+func (s *CourseService) search(ctx context.Context, in ListCourses, columns ...string) (CoursePage, error) {
+	filter := CourseFilter{Query: in.Query, Locales: i18n.Translated(ctx), Tag: in.Tag}
+
+	var categories []domain.Category
+	if in.Category != "" || in.Facets {
+		var err error
+		if categories, err = s.store.ListCategories(ctx); err != nil {
+			return CoursePage{}, err
+		}
+	}
+	if in.Category != "" {
+		if filter.CategoryIDs = subtree(categories, in.Category); filter.CategoryIDs == nil {
+			result := CoursePage{Data: []domain.Course{}}
+			if in.Facets {
+				result.Facets = &CourseFacets{Categories: []CategoryFacet{}, Tags: []TagFacet{}}
+			}
+			return result, nil
+		}
+	}
+
+	var result CoursePage
+	var err error
+	result.Data, result.Total, err = s.store.ListCourses(ctx, filter, in.Page.Limit, in.Page.offset(), columns...)
+	if err != nil || !in.Facets {
+		return result, err
+	}
+
+	result.Facets, err = s.facets(ctx, filter, categories)
+	return result, err
+}
+
+// facets counts the courses filter matches, rolling each course up to the
+// ancestors of its categories.
+func (s *CourseService) facets(ctx context.Context, filter CourseFilter, categories []domain.Category) (*CourseFacets, error) {
+	links, err := s.store.CategoryLinks(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	tagCounts, err := s.store.CountTags(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := parentsOf(categories)
+	courses := map[string]map[string]bool{}
+	for _, link := range links {
+		for _, id := range ancestry(parents, link.CategoryID) {
+			if courses[id] == nil {
+				courses[id] = map[string]bool{}
+			}
+			courses[id][link.CourseID] = true
+		}
+	}
+
+	facets := &CourseFacets{Categories: []CategoryFacet{}, Tags: []TagFacet{}}
+	for _, category := range categories {
+		if n := len(courses[category.ID]); n > 0 {
+			facets.Categories = append(facets.Categories, CategoryFacet{
+				ID:       category.ID,
+				Slug:     category.Slug,
+				Name:     category.Name,
+				ParentID: category.ParentID,
+				Count:    int64(n),
+			})
+		}
+	}
+	sort.SliceStable(facets.Categories, func(i, j int) bool {
+		return facets.Categories[i].Count > facets.Categories[j].Count
+	})
+
+	for name, n := range tagCounts {
+		facets.Tags = append(facets.Tags, TagFacet{Name: name, Count: n})
+	}
+	sort.Slice(facets.Tags, func(i, j int) bool {
+		if facets.Tags[i].Count != facets.Tags[j].Count {
+			return facets.Tags[i].Count > facets.Tags[j].Count
+		}
+		return facets.Tags[i].Name < facets.Tags[j].Name
+	})
+
+	return facets, nil
+}
+
+// courseCategoriesInput bounds the categories of a course to
+// MaxCourseCategories.
+type courseCategoriesInput struct {
+	CategoryIDs []string `json:"categoryIds" validate:"max=10,uuids"`
+}
+
+// SetCategories files the course under categoryIDs only.
+func (s *CourseService) SetCategories(ctx context.Context, id string, categoryIDs []string) ([]domain.Category, error) {
+	if err := checkID("id", id); err != nil {
+		return nil, err
+	}
+	if err := check(ctx, "course", &courseCategoriesInput{CategoryIDs: categoryIDs}); err != nil {
+		return nil, err
+	}
+	unique := make([]string, 0, len(categoryIDs))
+	for _, categoryID := range categoryIDs {
+		if !slices.Contains(unique, categoryID) {
+			unique = append(unique, categoryID)
+		}
+	}
+
+	categories, err := s.store.SetCourseCategories(ctx, id, unique)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, notFound("course")
+	case errors.Is(err, ErrMissingReference):
+		return nil, &ValidationError{Fields: map[string]string{"categoryIds": "contains an unknown category"}}
+	case err != nil:
+		return nil, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return categories, nil
+}
+
+// SetTags tags the course with names only, in slug form, creating the tags
+// that do not exist yet.
+func (s *CourseService) SetTags(ctx context.Context, id string, names []string) ([]domain.Tag, error) {
+	if err := checkID("id", id); err != nil {
+		return nil, err
+	}
+	names, err := normalizeTags(ctx, "tags", names)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := s.store.SetCourseTags(ctx, id, names)
+	if errors.Is(err, ErrNotFound) {
+		return nil, notFound("course")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return tags, nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+)
+
+// CourseTranslationInput is the text of a course in a locale. An empty
+// description falls back to the one of the default locale.
+type CourseTranslationInput struct {
+	Title       string `json:"title"       validate:"required,min=3,max=255,nohtml,noprofanity"`
+	Description string `json:"description" validate:"omitempty,min=3,noprofanity"`
+}
+
+// Translations returns the translations of the course, by locale.
+func (s *CourseService) Translations(ctx context.Context, courseID string) ([]domain.CourseTranslation, error) {
+	if _, err := s.Get(ctx, courseID); err != nil {
+		return nil, err
+	}
+
+	translations, err := s.store.CourseTranslations(ctx, []string{courseID}, nil)
+	if translations == nil {
+		translations = []domain.CourseTranslation{}
+	}
+	return translations, err
+}
+
+// PutTranslation creates or replaces the translation of the course into
+// locale, which must be a supported locale other than the default one, and
+// reports whether it created it.
+func (s *CourseService) PutTranslation(ctx context.Context, courseID, locale string, in CourseTranslationInput) (domain.CourseTranslation, bool, error) {
+	if err := checkID("id", courseID); err != nil {
+		return domain.CourseTranslation{}, false, err
+	}
+	locale, err := checkLocale(ctx, locale)
+	if err != nil {
+		return domain.CourseTranslation{}, false, err
+	}
+	if err := check(ctx, "course", &in); err != nil {
+		return domain.CourseTranslation{}, false, err
+	}
+
+	translation := domain.CourseTranslation{
+		CourseID:    courseID,
+		Locale:      locale,
+		Title:       strings.TrimSpace(in.Title),
+		Description: strings.TrimSpace(in.Description),
+	}
+	created, err := s.store.PutCourseTranslation(ctx, &translation)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return domain.CourseTranslation{}, false, notFound("course")
+	case errors.Is(err, ErrDuplicate):
+		return domain.CourseTranslation{}, false, titleTaken(ctx)
+	case err != nil:
+		return domain.CourseTranslation{}, false, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return translation, created, nil
+}
+
+// DeleteTranslation deletes the translation of the course into locale.
+func (s *CourseService) DeleteTranslation(ctx context.Context, courseID, locale string) (domain.CourseTranslation, error) {
+	if err := checkID("id", courseID); err != nil {
+		return domain.CourseTranslation{}, err
+	}
+	locale, err := checkLocale(ctx, locale)
+	if err != nil {
+		return domain.CourseTranslation{}, err
+	}
+
+	translation, err := s.store.DeleteCourseTranslation(ctx, courseID, locale)
+	if errors.Is(err, ErrNotFound) {
+		return domain.CourseTranslation{}, notFound("translation")
+	}
+	if err != nil {
+		return domain.CourseTranslation{}, err
+	}
+
+	s.cache.Bump(ctx, coursesListNamespace)
+	return translation, nil
+}
+
+// checkLocale returns the canonical form of a locale translations can be
+// written in.
+func checkLocale(ctx context.Context, locale string) (string, error) {
+	canonical, ok := i18n.Supported(locale)
+	switch {
+	case !ok:
+		return "", &ValidationError{Fields: map[string]string{"locale": i18n.T(ctx, "is not supported")}}
+	case canonical == i18n.Default():
+		return "", &ValidationError{Fields: map[string]string{"locale": i18n.T(ctx, "is the default")}}
+	}
+	return canonical, nil
+}
+
+// localize replaces the text of the courses with their translation into the
+// first locale of the fallback chain of ctx that has one. Courses without
+// any keep the text of the default locale.
+func (s *CourseService) localize(ctx context.Context, courses []domain.Course) error {
+	locales := i18n.Translated(ctx)
+	if len(locales) == 0 || len(courses) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(courses))
+	for i, course := range courses {
+		ids[i] = course.ID
+	}
+	translations, err := s.store.CourseTranslations(ctx, ids, locales)
+	if err != nil {
+		return err
+	}
+
+	best := make(map[string]domain.CourseTranslation, len(courses))
+	rank := func(t domain.CourseTranslation) int { return slices.Index(locales, t.Locale) }
+	for _, t := range translations {
+		if current, ok := best[t.CourseID]; !ok || rank(t) < rank(current) {
+			best[t.CourseID] = t
+		}
+	}
+
+	for i := range courses {
+		t, ok := best[courses[i].ID]
+		if !ok {
+			continue
+		}
+		courses[i].Title = t.Title
+		if t.Description != "" {
+			courses[i].Description = t.Description
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+)
+
+type UserInput struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Name  string `json:"name"  validate:"required,min=2,max=255"`
+}
+
+// UserUpdate changes the fields that are not nil.
+type UserUpdate struct {
+	Email *string
+	Name  *string
+}
+
+type UserPage struct {
+	Data  []domain.User
+	Total int64
+}
+
+type UserService struct {
+	store UserStore
+}
+
+func NewUserService(store UserStore) *UserService {
+	return &UserService{store: store}
+}
+
+func (s *UserService) Get(ctx context.Context, id string) (domain.User, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := s.store.GetUser(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return domain.User{}, notFound("user")
+	}
+
+	return user, err
+}
+
+// List returns a page of users, newest first.
+func (s *UserService) List(ctx context.Context, page Page) (UserPage, error) {
+	page = page.normalize()
+
+	var result UserPage
+	var err error
+	result.Data, result.Total, err = s.store.ListUsers(ctx, page.Limit, page.offset())
+	return result, err
+}
+
+func (s *UserService) Create(ctx context.Context, in UserInput) (domain.User, error) {
+	in.Email, in.Name = strings.TrimSpace(in.Email), strings.TrimSpace(in.Name)
+	if err := check(ctx, "user", &in); err != nil {
+		return domain.User{}, err
+	}
+
+	user := domain.User{ID: uuid.NewString(), Email: in.Email, Name: in.Name}
+	err := s.store.CreateUser(ctx, &user)
+	if errors.Is(err, ErrDuplicate) {
+		return domain.User{}, emailTaken(ctx)
+	}
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) Update(ctx context.Context, id string, in UserUpdate) (domain.User, error) {
+	if err := checkID("id", id); err != nil {
+		return domain.User{}, err
+	}
+
+	user, err := s.store.UpdateUser(ctx, id, func(user *domain.User) error {
+		next := UserInput{Email: user.Email, Name: user.Name}
+		if in.Email != nil {
+			next.Email = strings.TrimSpace(*in.Email)
+		}
+		if in.Name != nil {
+			next.Name = strings.TrimSpace(*in.Name)
+		}
+		if err := check(ctx, "user", &next); err != nil {
+			return err
+		}
+
+		user.Email, user.Name = next.Email, next.Name
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return domain.User{}, notFound("user")
+	case errors.Is(err, ErrDuplicate):
+		return domain.User{}, emailTaken(ctx)
+	case err != nil:
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+func emailTaken(ctx context.Context) *ConflictError {
+	metrics.ConflictsTotal.WithLabelValues(metrics.ConflictUserEmailTaken).Inc()
+	obs.Conflict(ctx, metrics.ConflictUserEmailTaken)
+	return &ConflictError{Reason: metrics.ConflictUserEmailTaken, Message: "email already exists"}
+}
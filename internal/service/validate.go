@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"github.com/guycanella/api-courses-golang/internal/validation"
+)
+
+// check validates in, counting each failure against resource (course, user,
+// enrollment). Messages are in the locale of ctx.
+func check(ctx context.Context, resource string, in any) error {
+	failures := validation.Struct(ctx, in)
+	if len(failures) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]string, len(failures))
+	for _, failure := range failures {
+		metrics.ValidationFailuresTotal.WithLabelValues(resource, failure.Field).Inc()
+		obs.ValidationFailed(ctx, failure.Field, failure.Rule)
+		fields[failure.Field] = failure.Message
+	}
+
+	return &ValidationError{Fields: fields}
+}
+
+// checkID rejects an id that is not a UUID, reporting it as field.
+func checkID(field, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return &ValidationError{Fields: map[string]string{field: "is invalid"}}
+	}
+	return nil
+}
+
+// Page selects a page of a list. Page starts at 1; Limit outside [1, 100]
+// falls back to 10.
+type Page struct {
+	Page  int
+	Limit int
+}
+
+func (p Page) normalize() Page {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.Limit < 1 || p.Limit > 100 {
+		p.Limit = 10
+	}
+	return p
+}
+
+func (p Page) offset() int { return (p.Page - 1) * p.Limit }
@@ -0,0 +1,77 @@
+// Package tasks registers the application's background job handlers, so the
+// API and cmd/worker run the same set.
+package tasks
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/cache"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	mysqlrepo "github.com/guycanella/api-courses-golang/internal/repository/mysql"
+	"github.com/guycanella/api-courses-golang/internal/service"
+	"github.com/guycanella/api-courses-golang/internal/webhooks"
+	"gorm.io/gorm"
+)
+
+// EventsPurgeKind deletes published outbox events.
+const EventsPurgeKind = "events.purge"
+
+// CourseImportKind runs a course import job the API saved with its body.
+const CourseImportKind = "courses.import"
+
+type courseImport struct {
+	JobID string `json:"job_id"`
+}
+
+// EnqueueCourseImport queues the import job id for a worker.
+func EnqueueCourseImport(ctx context.Context, q *jobs.Queue, id string) error {
+	_, err := q.Enqueue(ctx, CourseImportKind, courseImport{JobID: id}, jobs.Unique(CourseImportKind+":"+id))
+	return err
+}
+
+// Register adds every job handler to q. JOBS_RETENTION and EVENTS_RETENTION
+// (default 168h each) are how long finished jobs and published events are
+// kept. COURSES_IMPORT_TIMEOUT (default 1h, 0 for none) bounds an import
+// attempt instead of JOBS_TIMEOUT.
+func Register(q *jobs.Queue, db *gorm.DB) {
+	q.RegisterPurge(retention("JOBS_RETENTION"))
+
+	eventsRetention := retention("EVENTS_RETENTION")
+	jobs.Handle(q, EventsPurgeKind, func(ctx context.Context, _ struct{}) error {
+		_, err := events.PurgePublished(ctx, db, time.Now().Add(-eventsRetention))
+		return err
+	})
+	q.Every(EventsPurgeKind, time.Hour, struct{}{})
+
+	webhooks.New(db, q, webhooks.ConfigFromEnv()).Register()
+
+	// Imports bump the course list caches, which are shared through Redis.
+	RegisterCourseImport(q, service.NewCourseService(mysqlrepo.NewStore(db), service.WithCache(cache.FromEnv())),
+		jobs.Timeout(importTimeout()))
+}
+
+// RegisterCourseImport runs CourseImportKind jobs with courses.
+func RegisterCourseImport(q *jobs.Queue, courses *service.CourseService, opts ...jobs.HandlerOption) {
+	jobs.Handle(q, CourseImportKind, func(ctx context.Context, p courseImport) error {
+		return courses.RunImportJob(ctx, p.JobID)
+	}, opts...)
+}
+
+func importTimeout() time.Duration {
+	d, err := time.ParseDuration(os.Getenv("COURSES_IMPORT_TIMEOUT"))
+	if err != nil || d < 0 {
+		return time.Hour
+	}
+	return d
+}
+
+func retention(k string) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(k))
+	if err != nil || d <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return d
+}
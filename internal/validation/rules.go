@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// htmlTag matches an opening, closing or self-closing tag, or a comment.
+var htmlTag = regexp.MustCompile(`<\s*/?\s*[a-zA-Z][a-zA-Z0-9-]*(\s[^>]*)?/?\s*>|<!--`)
+
+func noHTML(fl validator.FieldLevel) bool {
+	return !htmlTag.MatchString(fl.Field().String())
+}
+
+// blockedWords are matched against whole words, lowercased and without
+// accents.
+var blockedWords = map[string]bool{
+	"asshole": true, "bastard": true, "bitch": true, "bullshit": true,
+	"cunt": true, "fuck": true, "fucking": true, "motherfucker": true,
+	"shit":   true,
+	"babaca": true, "buceta": true, "caralho": true, "cacete": true,
+	"merda": true, "porra": true, "puta": true,
+}
+
+func noProfanity(fl validator.FieldLevel) bool {
+	folded, _, err := transform.String(transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn))), fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, word := range words {
+		if blockedWords[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// uuids accepts a list of strings that are all UUIDs.
+func uuids(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if kind := field.Kind(); kind != reflect.Slice && kind != reflect.Array {
+		return false
+	}
+
+	for i := 0; i < field.Len(); i++ {
+		item := field.Index(i)
+		if item.Kind() != reflect.String {
+			return false
+		}
+		if _, err := uuid.Parse(item.String()); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+// Package validation checks inputs against their validate tags, reporting
+// each invalid field by its JSON name with a message in the locale of the
+// context.
+//
+// Besides the validator built-ins it knows these rules:
+//
+//	nohtml       a string without HTML tags or comments
+//	noprofanity  a string without a blocked word, ignoring case and accents
+//	uuids        a list of UUIDs
+package validation
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+)
+
+// FieldError is the first failed rule of a field.
+type FieldError struct {
+	// Field is the JSON name of the field.
+	Field string
+	// Rule is the failed tag, such as min or nohtml.
+	Rule    string
+	Message string
+}
+
+// rule is a custom validation and the English template of its message.
+type rule struct {
+	fn      validator.Func
+	message string
+}
+
+var rules = map[string]rule{
+	"nohtml":      {noHTML, "must not contain HTML"},
+	"noprofanity": {noProfanity, "must not contain offensive words"},
+	"uuids":       {uuids, "must contain only UUIDs"},
+}
+
+// New returns a validator with the custom rules that names fields by their
+// JSON tags.
+func New() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	for tag, r := range rules {
+		if err := v.RegisterValidation(tag, r.fn); err != nil {
+			panic(err)
+		}
+	}
+	return v
+}
+
+var validate = New()
+
+// Struct validates in, a struct or a pointer to one, and returns the failures
+// in the locale of ctx, or nil when in is valid.
+func Struct(ctx context.Context, in any) []FieldError {
+	err := validate.Struct(in)
+	if err == nil {
+		return nil
+	}
+	invalid, ok := err.(validator.ValidationErrors)
+	if !ok {
+		panic(err)
+	}
+
+	failures := make([]FieldError, 0, len(invalid))
+	for _, fe := range invalid {
+		failures = append(failures, FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: Message(ctx, fe)})
+	}
+	return failures
+}
+
+// Message returns the message of fe in the locale of ctx, with the rule
+// parameter filled in.
+func Message(ctx context.Context, fe validator.FieldError) string {
+	template := message(fe)
+	if !strings.Contains(template, "%s") {
+		return i18n.T(ctx, template)
+	}
+	return i18n.Tf(ctx, template, fe.Param())
+}
+
+// message returns the English template of fe, where %s is the rule parameter.
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "max", "len":
+		bound := map[string]string{"min": "at least", "max": "at most", "len": "exactly"}[fe.Tag()]
+		switch fe.Kind() {
+		case reflect.String:
+			return "must be " + bound + " %s characters"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return "must contain " + bound + " %s items"
+		default:
+			return "must be " + bound + " %s"
+		}
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	case "oneof":
+		return "must be one of %s"
+	}
+
+	if r, ok := rules[fe.Tag()]; ok {
+		return r.message
+	}
+	return "is invalid"
+}
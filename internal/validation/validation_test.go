@@ -0,0 +1,92 @@
+package validation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/guycanella/api-courses-golang/internal/i18n"
+	"github.com/guycanella/api-courses-golang/internal/validation"
+)
+
+type input struct {
+	Title    string   `json:"title"    validate:"required,min=3,max=40,nohtml,noprofanity"`
+	Email    string   `json:"email"    validate:"omitempty,email"`
+	IDs      []string `json:"ids"      validate:"max=2,uuids"`
+	Level    string   `json:"level"    validate:"omitempty,oneof=basic advanced"`
+	Internal string   `json:"-"        validate:"omitempty,min=5"`
+}
+
+func messages(t *testing.T, ctx context.Context, in input) map[string]string {
+	t.Helper()
+
+	out := map[string]string{}
+	for _, failure := range validation.Struct(ctx, &in) {
+		if _, ok := out[failure.Field]; ok {
+			t.Fatalf("field %q reported twice", failure.Field)
+		}
+		out[failure.Field] = failure.Message
+	}
+	return out
+}
+
+func TestStruct_MessagesWithParameters(t *testing.T) {
+	ctx := context.Background()
+	const id = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+
+	if got := messages(t, ctx, input{Title: "Go basics", IDs: []string{id}}); len(got) != 0 {
+		t.Fatalf("valid input: %v", got)
+	}
+
+	got := messages(t, ctx, input{Title: "Go", Email: "nope", IDs: []string{id, id, id}, Level: "expert"})
+	want := map[string]string{
+		"title": "must be at least 3 characters",
+		"email": "must be a valid email address",
+		"ids":   "must contain at most 2 items",
+		"level": "must be one of basic advanced",
+	}
+	for field, msg := range want {
+		if got[field] != msg {
+			t.Fatalf("%s=%q, want %q (all: %v)", field, got[field], msg, got)
+		}
+	}
+
+	if got := messages(t, ctx, input{Title: "Go basics", Internal: "x"}); got["Internal"] != "must be at least 5 characters" {
+		t.Fatalf("untagged field: %v", got)
+	}
+}
+
+func TestStruct_CustomRules(t *testing.T) {
+	ctx := context.Background()
+
+	cases := map[string]string{
+		"<b>Go</b> basics":        "must not contain HTML",
+		"Go <script src=x>":       "must not contain HTML",
+		"Go <!-- hidden":          "must not contain HTML",
+		"Puta que pariu, Go":      "must not contain offensive words",
+		"Go e CARALHO":            "must not contain offensive words",
+		"Go & Rust: 1 < 2":        "",
+		"Computação com Go":       "",
+		"Shiitake growing in Go!": "",
+	}
+	for title, want := range cases {
+		if got := messages(t, ctx, input{Title: title})["title"]; got != want {
+			t.Fatalf("title %q: %q, want %q", title, got, want)
+		}
+	}
+
+	if got := messages(t, ctx, input{Title: "Go basics", IDs: []string{"nope"}})["ids"]; got != "must contain only UUIDs" {
+		t.Fatalf("ids=%q", got)
+	}
+}
+
+func TestStruct_MessagesInTheLocale(t *testing.T) {
+	pt := i18n.WithLocales(context.Background(), []string{"pt-BR", "en"})
+
+	got := messages(t, pt, input{Title: "", IDs: []string{"nope"}})
+	if got["title"] != "é obrigatório" || got["ids"] != "deve conter apenas UUIDs" {
+		t.Fatalf("messages=%v", got)
+	}
+	if got := messages(t, pt, input{Title: "Go"}); got["title"] != "deve ter pelo menos 3 caracteres" {
+		t.Fatalf("messages=%v", got)
+	}
+}
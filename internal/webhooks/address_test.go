@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPrivate(t *testing.T) {
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", false},
+		{"1.1.1.1", false},
+		{"100.63.255.255", false},
+		{"100.128.0.0", false},
+		{"2606:4700:4700::1111", false},
+		{"::ffff:8.8.8.8", false},
+
+		{"0.0.0.0", true},
+		{"10.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.255", true},
+		{"127.0.0.1", true},
+		{"169.254.169.254", true},
+		{"172.16.0.1", true},
+		{"192.0.0.8", true},
+		{"192.168.1.1", true},
+		{"198.18.0.1", true},
+		{"224.0.0.1", true},
+		{"255.255.255.255", true},
+
+		{"::", true},
+		{"::1", true},
+		{"::127.0.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"::ffff:100.64.0.1", true},
+		{"64:ff9b::7f00:1", true},
+		{"64:ff9b::a9fe:a9fe", true},
+		{"64:ff9b:1::a00:1", true},
+		{"2002:7f00:1::", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"fe80::1%eth0", true},
+		{"ff02::1", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.addr, func(t *testing.T) {
+			if got := isPrivate(netip.MustParseAddr(tc.addr)); got != tc.want {
+				t.Fatalf("isPrivate(%s)=%v want=%v", tc.addr, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+// Package webhooks delivers domain events to partner endpoints.
+//
+// The Dispatcher is an events.Sink: for every published event it enqueues one
+// delivery job per matching active subscription. Each attempt is signed (see
+// Sign), logged as a domain.WebhookDelivery and retried by the job queue with
+// backoff. Consecutive failures disable the subscription.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/metrics"
+	"github.com/guycanella/api-courses-golang/internal/obs"
+	"gorm.io/gorm"
+)
+
+const DeliverKind = "webhooks.deliver"
+
+// maxResponseSnapshot bounds the response body kept in the delivery log.
+const maxResponseSnapshot = 4 << 10
+
+var errPrivateAddress = errors.New("webhooks: destination is a private address")
+
+type Config struct {
+	// Timeout bounds one delivery request.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts per event and subscription.
+	MaxAttempts int
+	// DisableAfter is the number of consecutive failed attempts after which a
+	// subscription is disabled.
+	DisableAfter int
+	// AllowPrivate permits loopback and private destinations. It is meant for
+	// local development and tests; otherwise it would let anyone who can
+	// create a subscription reach internal services.
+	AllowPrivate bool
+}
+
+// ConfigFromEnv reads WEBHOOKS_TIMEOUT (default 10s), WEBHOOKS_MAX_ATTEMPTS
+// (8), WEBHOOKS_DISABLE_AFTER (25) and WEBHOOKS_ALLOW_PRIVATE (false).
+func ConfigFromEnv() Config {
+	return Config{
+		Timeout:      getdur("WEBHOOKS_TIMEOUT", 10*time.Second),
+		MaxAttempts:  getint("WEBHOOKS_MAX_ATTEMPTS", 8),
+		DisableAfter: getint("WEBHOOKS_DISABLE_AFTER", 25),
+		AllowPrivate: os.Getenv("WEBHOOKS_ALLOW_PRIVATE") == "true",
+	}
+}
+
+type deliverPayload struct {
+	SubscriptionID string       `json:"subscription_id"`
+	Event          events.Event `json:"event"`
+}
+
+type Dispatcher struct {
+	db     *gorm.DB
+	queue  *jobs.Queue
+	cfg    Config
+	client *http.Client
+}
+
+func New(db *gorm.DB, queue *jobs.Queue, cfg Config) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		// Checked on the resolved address, so DNS cannot point around it.
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip, err := netip.ParseAddr(host); err != nil || isPrivate(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		db:    db,
+		queue: queue,
+		cfg:   cfg,
+		client: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: transport,
+			// A redirect would be followed without the private address check
+			// on the original URL; partners must answer directly.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// ValidateURL checks that raw is an absolute http or https URL without
+// credentials.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("must be an absolute http or https URL")
+	}
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	return nil
+}
+
+// Register adds the delivery job handler to the queue.
+func (d *Dispatcher) Register() {
+	d.queue.Register(DeliverKind, func(ctx context.Context, job *jobs.Job) error {
+		var p deliverPayload
+		if err := json.Unmarshal(job.Payload, &p); err != nil {
+			return jobs.Permanent(fmt.Errorf("decode %s payload: %w", DeliverKind, err))
+		}
+
+		return d.Deliver(ctx, p.SubscriptionID, p.Event, job.Attempts)
+	})
+}
+
+func (d *Dispatcher) Name() string { return "webhooks" }
+
+// Publish enqueues a delivery of e to every active subscription of its type.
+func (d *Dispatcher) Publish(ctx context.Context, e events.Event) error {
+	var subs []domain.WebhookSubscription
+	if err := d.db.WithContext(ctx).Where("active = ?", true).Find(&subs).Error; err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !sub.Subscribes(e.Type) {
+			continue
+		}
+		// The relay may publish an event again; the key drops the repeat
+		// while the first delivery is still pending.
+		_, err := d.Enqueue(ctx, sub.ID, e, jobs.Unique("webhook:"+sub.ID+":"+e.ID))
+		if err != nil && !errors.Is(err, jobs.ErrDuplicate) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Enqueue schedules a delivery of e to the subscription.
+func (d *Dispatcher) Enqueue(ctx context.Context, subscriptionID string, e events.Event, opts ...jobs.EnqueueOption) (*jobs.Job, error) {
+	opts = append([]jobs.EnqueueOption{jobs.MaxAttempts(d.cfg.MaxAttempts)}, opts...)
+	return d.queue.Enqueue(ctx, DeliverKind, deliverPayload{SubscriptionID: subscriptionID, Event: e}, opts...)
+}
+
+// Send makes one signed delivery attempt and returns its log entry. It does
+// not touch the database.
+func (d *Dispatcher) Send(ctx context.Context, sub domain.WebhookSubscription, e events.Event, attempt int) domain.WebhookDelivery {
+	body, _ := json.Marshal(e)
+	delivery := domain.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        e.ID,
+		EventType:      e.Type,
+		Attempt:        attempt,
+		RequestURL:     sub.URL,
+		RequestBody:    string(body),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "api-courses-webhooks/1")
+	req.Header.Set(HeaderID, e.ID)
+	req.Header.Set(HeaderEventType, e.Type)
+	now := time.Now()
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, body))
+	delivery.RequestHeaders = flatten(req.Header)
+
+	start := time.Now()
+	res, err := d.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	metrics.WebhookDeliveryDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer res.Body.Close()
+
+	snapshot, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSnapshot))
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+
+	delivery.ResponseStatus = res.StatusCode
+	delivery.ResponseHeaders = flatten(res.Header)
+	delivery.ResponseBody = string(snapshot)
+	delivery.Succeeded = res.StatusCode/100 == 2
+	if !delivery.Succeeded {
+		delivery.Error = "endpoint responded " + res.Status
+	}
+
+	return delivery
+}
+
+// Deliver runs one attempt for the job queue: it sends the event, logs the
+// delivery and updates the failure count of the subscription. The returned
+// error makes the queue retry; it is permanent once the subscription is
+// disabled.
+func (d *Dispatcher) Deliver(ctx context.Context, subscriptionID string, e events.Event, attempt int) error {
+	logger := obs.Logger(ctx)
+
+	var sub domain.WebhookSubscription
+	if err := d.db.WithContext(ctx).First(&sub, "id = ?", subscriptionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !sub.Active {
+		logger.InfoContext(ctx, "webhook skipped, subscription disabled", "subscription_id", sub.ID, "event_id", e.ID)
+		return nil
+	}
+
+	delivery := d.Send(ctx, sub, e, attempt)
+	if err := d.db.WithContext(ctx).Create(&delivery).Error; err != nil {
+		logger.ErrorContext(ctx, "log webhook delivery failed", "error", err)
+	}
+
+	if delivery.Succeeded {
+		metrics.WebhookDeliveriesTotal.WithLabelValues(e.Type, metrics.WebhookSucceeded).Inc()
+		return d.db.WithContext(ctx).Model(&sub).Updates(map[string]any{
+			"failure_count":   0,
+			"last_success_at": time.Now(),
+		}).Error
+	}
+	metrics.WebhookDeliveriesTotal.WithLabelValues(e.Type, metrics.WebhookFailed).Inc()
+
+	failures := sub.FailureCount + 1
+	reason := ""
+	switch {
+	case delivery.ResponseStatus == http.StatusGone:
+		reason = "endpoint responded 410 Gone"
+	case failures >= d.cfg.DisableAfter:
+		reason = fmt.Sprintf("%d consecutive failed deliveries", failures)
+	}
+
+	fields := map[string]any{"failure_count": gorm.Expr("failure_count + 1")}
+	if reason != "" {
+		fields["active"], fields["disabled_at"], fields["disabled_reason"] = false, time.Now(), reason
+	}
+	if err := d.db.WithContext(ctx).Model(&sub).Updates(fields).Error; err != nil {
+		return err
+	}
+
+	err := errors.New(delivery.Error)
+	if reason != "" {
+		metrics.WebhooksDisabledTotal.Inc()
+		logger.WarnContext(ctx, "webhook subscription disabled", "subscription_id", sub.ID, "reason", reason)
+		return jobs.Permanent(err)
+	}
+
+	return err
+}
+
+func flatten(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k := range h {
+		out[k] = h.Get(k)
+	}
+	return out
+}
+
+func getdur(k string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(k)); err == nil {
+		return d
+	}
+	return def
+}
+
+func getint(k string, def int) int {
+	if n, err := strconv.Atoi(os.Getenv(k)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
@@ -0,0 +1,181 @@
+package webhooks_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/guycanella/api-courses-golang/internal/domain"
+	"github.com/guycanella/api-courses-golang/internal/events"
+	"github.com/guycanella/api-courses-golang/internal/jobs"
+	"github.com/guycanella/api-courses-golang/internal/webhooks"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		Conn:                      sqlDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("gorm: %v", err)
+	}
+
+	return db, mock
+}
+
+var testConfig = webhooks.Config{Timeout: 5 * time.Second, MaxAttempts: 3, DisableAfter: 3, AllowPrivate: true}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Now()
+	header := webhooks.Sign("secret", now, body)
+
+	if err := webhooks.Verify("secret", header, body, 5*time.Minute, now); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := webhooks.Verify("secret", header, []byte(`{"id":"2"}`), 5*time.Minute, now); !errors.Is(err, webhooks.ErrBadSignature) {
+		t.Fatalf("tampered body: err = %v", err)
+	}
+	if err := webhooks.Verify("other", header, body, 5*time.Minute, now); !errors.Is(err, webhooks.ErrBadSignature) {
+		t.Fatalf("wrong secret: err = %v", err)
+	}
+	if err := webhooks.Verify("secret", header, body, 5*time.Minute, now.Add(10*time.Minute)); !errors.Is(err, webhooks.ErrStaleTimestamp) {
+		t.Fatalf("replayed: err = %v", err)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for raw, ok := range map[string]bool{
+		"https://partner.example.com/hooks": true,
+		"http://partner.example.com":        true,
+		"ftp://partner.example.com":         false,
+		"/hooks":                            false,
+		"https://user:pw@partner.example":   false,
+	} {
+		if err := webhooks.ValidateURL(raw); (err == nil) != ok {
+			t.Fatalf("ValidateURL(%q) = %v, want ok=%v", raw, err, ok)
+		}
+	}
+}
+
+func TestSend_SignsRequest(t *testing.T) {
+	sub := domain.WebhookSubscription{ID: "s1", Secret: "whsec_test"}
+	e := events.CourseCreated(domain.Course{ID: "c1", Title: "Go"})
+
+	var verifyErr error
+	var gotID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = webhooks.Verify(sub.Secret, r.Header.Get(webhooks.HeaderSignature), body, time.Minute, time.Now())
+		gotID = r.Header.Get(webhooks.HeaderID)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	sub.URL = srv.URL
+
+	delivery := webhooks.New(nil, nil, testConfig).Send(context.Background(), sub, e, 1)
+
+	if !delivery.Succeeded || delivery.ResponseStatus != http.StatusOK || delivery.ResponseBody != "ok" {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+	if verifyErr != nil || gotID != e.ID {
+		t.Fatalf("receiver saw id %q, verify error %v", gotID, verifyErr)
+	}
+	if delivery.RequestHeaders[webhooks.HeaderSignature] == "" {
+		t.Fatalf("request snapshot lacks the signature: %v", delivery.RequestHeaders)
+	}
+}
+
+func TestSend_RecordsFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	sub := domain.WebhookSubscription{ID: "s1", URL: srv.URL, Secret: "whsec_test"}
+	delivery := webhooks.New(nil, nil, testConfig).Send(context.Background(), sub, events.Event{ID: "e1"}, 2)
+
+	if delivery.Succeeded || delivery.ResponseStatus != http.StatusServiceUnavailable || delivery.Error == "" || delivery.Attempt != 2 {
+		t.Fatalf("unexpected delivery: %+v", delivery)
+	}
+}
+
+func TestSend_BlocksPrivateAddresses(t *testing.T) {
+	var called bool
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { called = true }))
+	defer srv.Close()
+
+	cfg := testConfig
+	cfg.AllowPrivate = false
+	sub := domain.WebhookSubscription{ID: "s1", URL: srv.URL, Secret: "whsec_test"}
+	delivery := webhooks.New(nil, nil, cfg).Send(context.Background(), sub, events.Event{ID: "e1"}, 1)
+
+	if called || delivery.Succeeded || delivery.Error == "" {
+		t.Fatalf("loopback delivery should be refused: %+v", delivery)
+	}
+}
+
+func TestDeliver_DisablesAfterRepeatedFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	db, mock := openMockDB(t)
+	mock.ExpectQuery("SELECT \\* FROM `webhook_subscriptions` WHERE id = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "event_types", "secret", "active", "failure_count"}).
+			AddRow("s1", srv.URL, `["course.created"]`, "whsec_test", true, 2))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `webhook_deliveries`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE `webhook_subscriptions` SET `active`=\\?,`disabled_at`=\\?,`disabled_reason`=\\?,`failure_count`=failure_count \\+ 1").
+		WithArgs(false, sqlmock.AnyArg(), "3 consecutive failed deliveries", sqlmock.AnyArg(), "s1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	d := webhooks.New(db, jobs.New(db), testConfig)
+	err := d.Deliver(context.Background(), "s1", events.CourseCreated(domain.Course{ID: "c1"}), 3)
+	if err == nil {
+		t.Fatalf("expected the failure to be returned")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}
+
+func TestPublish_EnqueuesMatchingSubscriptions(t *testing.T) {
+	db, mock := openMockDB(t)
+	mock.ExpectQuery("SELECT \\* FROM `webhook_subscriptions` WHERE active = \\?").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_types", "active"}).
+			AddRow("s1", `["course.created"]`, true).
+			AddRow("s2", `["enrollment.created"]`, true))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `jobs`").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	d := webhooks.New(db, jobs.New(db), testConfig)
+	if err := d.Publish(context.Background(), events.CourseCreated(domain.Course{ID: "c1"})); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations: %v", err)
+	}
+}